@@ -4,23 +4,70 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/apikeygrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/auditgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/eventgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/jwksgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/prdgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/rolegrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/salegrp"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/testgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/tntgrp"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/usrgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/usrsummgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/webhookgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey/stores/apikeydb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit/stores/auditdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/change"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/mfa"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/mfa/stores/mfadb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product/stores/productdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role/stores/roledb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale/stores/saledb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant/stores/tenantdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token/stores/tokendb"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user/stores/userdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/verify"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/verify/stores/verifydb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook/stores/webhookdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/cview/user/summary"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/cview/user/summary/stores/summarydb"
+	database "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/mid"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/mailer"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
 // APIMuxConfig contains all the mandatory systems required by handlers.
+// MFAIssuer names the service in authenticator apps and AdminMFA makes the
+// admin-only and super admin routes require a token issued with mfa.
+// Changes is the feed streamed to the clients, it is optional.
 type APIMuxConfig struct {
-	Shutdown chan os.Signal
-	Log      *zap.SugaredLogger
-	Auth     *auth.Auth
-	DB       *sqlx.DB
+	Shutdown       chan os.Signal
+	Log            *zap.SugaredLogger
+	Auth           *auth.Auth
+	DB             *sqlx.DB
+	KeySet         jwksgrp.KeySet
+	Lockout        user.Lockout
+	PasswordPolicy user.PasswordPolicy
+	Mailer         verify.Mailer
+	Secret         []byte
+	MFAIssuer      string
+	AdminMFA       bool
+	Changes        *change.Core
 }
 
 // APIMux constructs a http.Handler with all application routes defined.
@@ -30,9 +77,165 @@ func APIMux(cfg APIMuxConfig) *web.App {
 	app.Handle(http.MethodGet, "/test", testgrp.Test)
 	app.Handle(http.MethodGet, "/test/auth", testgrp.Test, mid.Authenticate(cfg.Auth), mid.Authorize(cfg.Auth, auth.RuleAdminOnly))
 
+	// The public keys are only published when the service has a key set.
+	if cfg.KeySet != nil {
+		jgh := jwksgrp.New(cfg.KeySet)
+		app.Handle(http.MethodGet, "/.well-known/jwks.json", jgh.Query)
+	}
+
+	// ==============================================================================
+	// The cores publish the events of their domain through the delegate and
+	// the handlers run in the transaction of the change that published them.
+	dlg := delegate.New(cfg.Log)
+
+	// Every change the cores publish is recorded in the audit log, in the
+	// transaction of the change.
+	audCore := audit.NewCore(auditdb.NewStore(cfg.Log, cfg.DB), audit.WithDelegate(dlg))
+
+	// The webhooks write the events of the tenants to the outbox, the
+	// dispatcher started by main delivers them.
+	whCore := webhook.NewCore(webhookdb.NewStore(cfg.Log, cfg.DB), webhook.WithDelegate(dlg))
+
 	// ==============================================================================
-	usrCore := user.NewCore(userdb.NewStore(cfg.Log, cfg.DB))
-	ugh := usrgrp.New(usrCore)
-	app.Handle(http.MethodGet, "/users", ugh.Query)
+	usrCore := user.NewCore(userdb.NewStore(cfg.Log, cfg.DB), user.WithLockout(cfg.Lockout), user.WithPasswordPolicy(cfg.PasswordPolicy), user.WithDelegate(dlg))
+	tknCore := token.NewCore(tokendb.NewStore(cfg.Log, cfg.DB), token.WithDelegate(dlg))
+
+	// Without a mailer the emails are only logged.
+	mlr := cfg.Mailer
+	if mlr == nil {
+		mlr = mailer.NewLog(cfg.Log)
+	}
+	vfyCore := verify.NewCore(cfg.Log, usrCore, verifydb.NewStore(cfg.Log, cfg.DB), mlr, cfg.Secret)
+	mfaCore := mfa.NewCore(mfadb.NewStore(cfg.Log, cfg.DB), cfg.MFAIssuer, cfg.Secret, mfa.WithDelegate(dlg))
+
+	authen := mid.Authenticate(cfg.Auth)
+
+	// The admin routes are authenticated with authenAdmin, which also
+	// requires a token issued with mfa when AdminMFA is set.
+	authenAdmin := authen
+	if cfg.AdminMFA {
+		ruleMFA := mid.Authorize(cfg.Auth, auth.RuleMFA)
+		authenAdmin = func(handler web.Handler) web.Handler {
+			return authen(ruleMFA(handler))
+		}
+	}
+
+	ruleAdmin := mid.Authorize(cfg.Auth, auth.RuleAdminOnly)
+	ruleAdminOrSubject := mid.AuthorizeUser(cfg.Auth, usrCore, auth.RuleAdminOrSubject)
+	ruleUserRead := mid.Authorize(cfg.Auth, auth.RuleUserRead)
+	ruleUserWrite := mid.Authorize(cfg.Auth, auth.RuleUserWrite)
+	ruleUserWriteUser := mid.AuthorizeUser(cfg.Auth, usrCore, auth.RuleUserWrite)
+	ruleProductRead := mid.Authorize(cfg.Auth, auth.RuleProductRead)
+	ruleSaleRead := mid.Authorize(cfg.Auth, auth.RuleSaleRead)
+	ruleSaleWrite := mid.Authorize(cfg.Auth, auth.RuleSaleWrite)
+	ruleTenantRead := mid.Authorize(cfg.Auth, auth.RuleTenantRead)
+	ruleTenantWrite := mid.Authorize(cfg.Auth, auth.RuleTenantWrite)
+	ruleRoleWrite := mid.Authorize(cfg.Auth, auth.RuleRoleWrite)
+	ruleAPIKeyWrite := mid.Authorize(cfg.Auth, auth.RuleAPIKeyWrite)
+	ruleEventRead := mid.Authorize(cfg.Auth, auth.RuleEventRead)
+	tran := mid.ExecuteInTransaction(cfg.Log, database.NewBeginner(cfg.DB))
+	scopeAll := mid.ScopeAll()
+
+	// The token route is protected by the Basic auth credentials it requires,
+	// the refresh route by the refresh token in the request and the password
+	// and verify routes by the tokens that were emailed. No user is
+	// authenticated yet, so they see the users of every tenant.
+	ugh := usrgrp.New(usrCore, tknCore, vfyCore, mfaCore, cfg.Auth)
+	app.Handle(http.MethodGet, "/users/token/:kid", ugh.Token, scopeAll)
+	app.Handle(http.MethodPost, "/users/token/refresh", ugh.Refresh, scopeAll, tran)
+	app.Handle(http.MethodPost, "/users/token/logout", ugh.Logout, authen, tran)
+	app.Handle(http.MethodPost, "/users/password/forgot", ugh.ForgotPassword, scopeAll)
+	app.Handle(http.MethodPost, "/users/password/reset", ugh.ResetPassword, scopeAll, tran)
+	app.Handle(http.MethodPost, "/users/verify", ugh.VerifyEmail, scopeAll, tran)
+	app.Handle(http.MethodPost, "/users/mfa", ugh.EnrollMFA, authen, tran)
+	app.Handle(http.MethodPost, "/users/mfa/confirm", ugh.ConfirmMFA, authen, tran)
+	app.Handle(http.MethodGet, "/users", ugh.Query, authenAdmin, ruleUserRead)
+	app.Handle(http.MethodGet, "/users/:user_id", ugh.QueryByID, authen, ruleAdminOrSubject)
+	app.Handle(http.MethodPost, "/users", ugh.Create, authenAdmin, ruleUserWrite, tran)
+	app.Handle(http.MethodPut, "/users/:user_id", ugh.Update, authen, ruleAdminOrSubject, tran)
+	app.Handle(http.MethodDelete, "/users/:user_id", ugh.Delete, authen, ruleAdminOrSubject, tran)
+	app.Handle(http.MethodPost, "/users/:user_id/unlock", ugh.Unlock, authenAdmin, ruleUserWriteUser, tran)
+	app.Handle(http.MethodDelete, "/users/:user_id/mfa", ugh.DisableMFA, authen, ruleAdminOrSubject, tran)
+
+	// ==============================================================================
+	tntCore := tenant.NewCore(tenantdb.NewStore(cfg.Log, cfg.DB), tenant.WithDelegate(dlg))
+
+	// Admins can read their own tenant, only super admins manage them.
+	tgh := tntgrp.New(tntCore)
+	app.Handle(http.MethodGet, "/tenants", tgh.Query, authenAdmin, ruleTenantRead)
+	app.Handle(http.MethodGet, "/tenants/:tenant_id", tgh.QueryByID, authenAdmin, ruleTenantRead)
+	app.Handle(http.MethodPost, "/tenants", tgh.Create, authenAdmin, ruleTenantWrite, tran)
+	app.Handle(http.MethodPut, "/tenants/:tenant_id", tgh.Update, authenAdmin, ruleTenantWrite, tran)
+	app.Handle(http.MethodDelete, "/tenants/:tenant_id", tgh.Delete, authenAdmin, ruleTenantWrite, tran)
+
+	// ==============================================================================
+	rlCore := role.NewCore(roledb.NewStore(cfg.Log, cfg.DB), role.WithDelegate(dlg))
+
+	// Roles are shared by every tenant, so only super admins manage them.
+	rgh := rolegrp.New(rlCore, cfg.Auth)
+	app.Handle(http.MethodGet, "/roles", rgh.Query, authenAdmin, ruleUserRead)
+	app.Handle(http.MethodGet, "/roles/:name", rgh.QueryByName, authenAdmin, ruleUserRead)
+	app.Handle(http.MethodPost, "/roles", rgh.Create, authenAdmin, ruleRoleWrite, tran)
+	app.Handle(http.MethodPut, "/roles/:name", rgh.Update, authenAdmin, ruleRoleWrite, tran)
+	app.Handle(http.MethodDelete, "/roles/:name", rgh.Delete, authenAdmin, ruleRoleWrite, tran)
+
+	// ==============================================================================
+	prdCore := product.NewCore(usrCore, productdb.NewStore(cfg.Log, cfg.DB), product.WithDelegate(dlg))
+
+	ruleProductWrite := mid.Authorize(cfg.Auth, auth.RuleProductWrite)
+	ruleProductOwner := mid.AuthorizeProduct(cfg.Auth, prdCore, auth.RuleAdminOrSubject)
+
+	pgh := prdgrp.New(prdCore)
+	app.Handle(http.MethodGet, "/products", pgh.Query, authen, ruleProductRead)
+	app.Handle(http.MethodGet, "/products/:product_id", pgh.QueryByID, authen, ruleProductOwner)
+	app.Handle(http.MethodPost, "/products", pgh.Create, authen, ruleProductWrite, ruleProductOwner, tran)
+	app.Handle(http.MethodPut, "/products/:product_id", pgh.Update, authen, ruleProductWrite, ruleProductOwner, tran)
+	app.Handle(http.MethodDelete, "/products/:product_id", pgh.Delete, authen, ruleProductWrite, ruleProductOwner, tran)
+
+	// ==============================================================================
+	akCore := apikey.NewCore(usrCore, apikeydb.NewStore(cfg.Log, cfg.DB), apikey.WithDelegate(dlg))
+
+	ruleKeyOwner := mid.AuthorizeAPIKey(cfg.Auth, akCore, auth.RuleAdminOrSubject)
+
+	akgh := apikeygrp.New(akCore)
+	app.Handle(http.MethodGet, "/apikeys", akgh.Query, authen, ruleAPIKeyWrite)
+	app.Handle(http.MethodPost, "/apikeys", akgh.Create, authen, ruleAPIKeyWrite, tran)
+	app.Handle(http.MethodDelete, "/apikeys/:key_id", akgh.Revoke, authen, ruleKeyOwner, tran)
+
+	// ==============================================================================
+	slCore := sale.NewCore(prdCore, saledb.NewStore(cfg.Log, cfg.DB), sale.WithDelegate(dlg))
+
+	slgh := salegrp.New(slCore)
+	app.Handle(http.MethodGet, "/sales", slgh.Query, authenAdmin, ruleSaleRead)
+	app.Handle(http.MethodGet, "/sales/:sale_id", slgh.QueryByID, authenAdmin, ruleSaleRead)
+	app.Handle(http.MethodPost, "/sales", slgh.Create, authen, ruleSaleWrite, tran)
+
+	// ==============================================================================
+	smmCore := summary.NewCore(summarydb.NewStore(cfg.Log, cfg.DB))
+
+	sgh := usrsummgrp.New(smmCore)
+	app.Handle(http.MethodGet, "/usersummary", sgh.Query, authenAdmin, ruleUserRead)
+
+	// ==============================================================================
+	wgh := webhookgrp.New(whCore)
+	app.Handle(http.MethodGet, "/webhooks", wgh.Query, authenAdmin, ruleAdmin)
+	app.Handle(http.MethodGet, "/webhooks/:webhook_id", wgh.QueryByID, authenAdmin, ruleAdmin)
+	app.Handle(http.MethodGet, "/webhooks/:webhook_id/deadletters", wgh.QueryDeadLetters, authenAdmin, ruleAdmin)
+	app.Handle(http.MethodPost, "/webhooks", wgh.Create, authenAdmin, ruleAdmin, tran)
+	app.Handle(http.MethodPut, "/webhooks/:webhook_id", wgh.Update, authenAdmin, ruleAdmin, tran)
+	app.Handle(http.MethodDelete, "/webhooks/:webhook_id", wgh.Delete, authenAdmin, ruleAdmin, tran)
+
+	// ==============================================================================
+	// The changes are only streamed when the service was given a feed.
+	if cfg.Changes != nil {
+		egh := eventgrp.New(cfg.Changes, cfg.Auth)
+		app.Handle(http.MethodGet, "/events/stream", egh.Stream, authen, ruleEventRead)
+	}
+
+	// ==============================================================================
+	adgh := auditgrp.New(audCore)
+	app.Handle(http.MethodGet, "/audit", adgh.Query, authenAdmin, ruleAdmin)
+	app.Handle(http.MethodGet, "/audit/export", adgh.Export, authenAdmin, ruleAdmin)
+
 	return app
 }
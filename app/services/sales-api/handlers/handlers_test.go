@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const kid = "s4sKIjD9kIRjxs2tulPqGLdxSfgPErRN1Mu3Hd9k9NQ"
+
+// A role without product:write can't write products, even their own. The
+// request is refused before anything is read from the database.
+func Test_ProductWrite(t *testing.T) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to generate a key : %s", err)
+	}
+
+	roles := roleStore{
+		{Name: "USER", Permissions: []string{"product:read", "product:write", "sale:write", "apikey:write"}},
+		{Name: "CLERK", Permissions: []string{"product:read"}},
+	}
+
+	a, err := auth.New(auth.Config{
+		Log:       zap.NewNop().Sugar(),
+		KeyLookup: keyStore{pk: pk},
+		Issuer:    "service project",
+		Roles:     roles,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the auth : %s", err)
+	}
+
+	if err := a.ReloadRoles(context.Background()); err != nil {
+		t.Fatalf("Should be able to load the roles : %s", err)
+	}
+
+	app := handlers.APIMux(handlers.APIMuxConfig{
+		Shutdown: make(chan os.Signal, 1),
+		Log:      zap.NewNop().Sugar(),
+		Auth:     a,
+	})
+
+	claims := auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.NewString(),
+			Issuer:    "service project",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Roles:    []user.Role{user.MustParseRole("CLERK")},
+		TenantID: uuid.NewString(),
+	}
+
+	tkn, err := a.GenerateToken(kid, claims)
+	if err != nil {
+		t.Fatalf("Should be able to generate a token : %s", err)
+	}
+
+	body := `{"name":"Comic Books","cost":10,"quantity":5}`
+
+	r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+tkn)
+	w := httptest.NewRecorder()
+
+	app.ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Logf("got: %d %s", w.Code, w.Body.String())
+		t.Logf("exp: %d", http.StatusForbidden)
+		t.Errorf("Should NOT be able to create a product without product:write")
+	}
+}
+
+// =============================================================================
+
+// roleStore is a RoleLookup with the roles of the test.
+type roleStore []role.Role
+
+func (rs roleStore) QueryAll(ctx context.Context) ([]role.Role, error) {
+	return rs, nil
+}
+
+// keyStore is a KeyLookup with the key generated for the test.
+type keyStore struct {
+	pk *rsa.PrivateKey
+}
+
+func (ks keyStore) PrivateKey(kid string) (string, error) {
+	der := x509.MarshalPKCS1PrivateKey(ks.pk)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der})), nil
+}
+
+func (ks keyStore) PublicKey(kid string) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(ks.pk.Public())
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
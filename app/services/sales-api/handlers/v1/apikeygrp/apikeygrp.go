@@ -0,0 +1,134 @@
+// Package apikeygrp maintains the group of handlers for api key access.
+package apikeygrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
+	v1 "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/mid"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of api key endpoints.
+type Handlers struct {
+	apiKey *apikey.Core
+}
+
+// New constructs a handlers for route access.
+func New(apiKey *apikey.Core) *Handlers {
+	return &Handlers{
+		apiKey: apiKey,
+	}
+}
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *Handlers) executeUnderTransaction(ctx context.Context) (*Handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		apiKey, err := h.apiKey.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		h = &Handlers{
+			apiKey: apiKey,
+		}
+
+		return h, nil
+	}
+
+	return h, nil
+}
+
+// Create adds a new api key for the user making the call. The key is only
+// returned in this response.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewAPIKey
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	claims := auth.GetClaims(ctx)
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return auth.NewAuthError("create: invalid subject in claims: %s", err)
+	}
+
+	nak, err := toCoreNewAPIKey(app, userID)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	// A caller using an api key can't create a key with more roles than the
+	// key it is using.
+	for _, role := range nak.Roles {
+		if !claims.HasRole(role) {
+			return v1.NewRequestError(apikey.ErrInvalidRoles, http.StatusForbidden)
+		}
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	ak, key, err := h.apiKey.Create(ctx, nak)
+	if err != nil {
+		switch {
+		case errors.Is(err, apikey.ErrUserDisabled):
+			return v1.NewRequestError(err, http.StatusForbidden)
+		case errors.Is(err, apikey.ErrInvalidRoles):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("create: app[%+v]: %w", app, err)
+		}
+	}
+
+	// The key is only shown once, it can't be recovered later.
+	resp := toAppAPIKey(ak)
+	resp.Key = key
+
+	return web.Respond(ctx, w, resp, http.StatusCreated)
+}
+
+// Revoke revokes an api key so it can no longer be used.
+func (h *Handlers) Revoke(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	ak, err := mid.GetAPIKey(ctx)
+	if err != nil {
+		return fmt.Errorf("getapikey: %w", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := h.apiKey.Revoke(ctx, ak); err != nil {
+		return fmt.Errorf("revoke: keyID[%s]: %w", ak.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns the api keys of the user making the call.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID, err := uuid.Parse(auth.GetClaims(ctx).Subject)
+	if err != nil {
+		return auth.NewAuthError("query: invalid subject in claims: %s", err)
+	}
+
+	keys, err := h.apiKey.QueryByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("querybyuserid: userID[%s]: %w", userID, err)
+	}
+
+	return web.Respond(ctx, w, toAppAPIKeys(keys), http.StatusOK)
+}
@@ -0,0 +1,91 @@
+package apikeygrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppAPIKey represents information about an individual api key. The key
+// itself is only set in the response that created it.
+type AppAPIKey struct {
+	ID          string   `json:"id"`
+	UserID      string   `json:"userID"`
+	Name        string   `json:"name"`
+	Prefix      string   `json:"prefix"`
+	Key         string   `json:"key,omitempty"`
+	Roles       []string `json:"roles"`
+	DateCreated string   `json:"dateCreated"`
+	DateRevoked string   `json:"dateRevoked,omitempty"`
+}
+
+func toAppAPIKey(ak apikey.APIKey) AppAPIKey {
+	roles := make([]string, len(ak.Roles))
+	for i, role := range ak.Roles {
+		roles[i] = role.Name()
+	}
+
+	app := AppAPIKey{
+		ID:          ak.ID.String(),
+		UserID:      ak.UserID.String(),
+		Name:        ak.Name,
+		Prefix:      ak.Prefix,
+		Roles:       roles,
+		DateCreated: ak.DateCreated.Format(time.RFC3339),
+	}
+
+	if !ak.DateRevoked.IsZero() {
+		app.DateRevoked = ak.DateRevoked.Format(time.RFC3339)
+	}
+
+	return app
+}
+
+func toAppAPIKeys(keys []apikey.APIKey) []AppAPIKey {
+	items := make([]AppAPIKey, len(keys))
+	for i, ak := range keys {
+		items[i] = toAppAPIKey(ak)
+	}
+
+	return items
+}
+
+// =============================================================================
+
+// AppNewAPIKey contains information needed to create a new api key.
+type AppNewAPIKey struct {
+	Name  string   `json:"name" validate:"required"`
+	Roles []string `json:"roles" validate:"required"`
+}
+
+func toCoreNewAPIKey(app AppNewAPIKey, userID uuid.UUID) (apikey.NewAPIKey, error) {
+	roles := make([]user.Role, len(app.Roles))
+	for i, roleStr := range app.Roles {
+		role, err := user.ParseRole(roleStr)
+		if err != nil {
+			return apikey.NewAPIKey{}, fmt.Errorf("parsing role: %w", err)
+		}
+		roles[i] = role
+	}
+
+	nak := apikey.NewAPIKey{
+		UserID: userID,
+		Name:   app.Name,
+		Roles:  roles,
+	}
+
+	return nak, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewAPIKey) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+// Package auditgrp maintains the group of handlers for audit log access.
+package auditgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	paging "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/paging"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+)
+
+// Handlers manages the set of audit endpoints.
+type Handlers struct {
+	audit *audit.Core
+}
+
+// New constructs a handlers for route access.
+func New(audit *audit.Core) *Handlers {
+	return &Handlers{
+		audit: audit,
+	}
+}
+
+// Query returns a list of the recorded changes with paging, the latest
+// first. Admins only see the changes made to the entities of their tenant.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	auds, err := h.audit.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.audit.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppAudits(auds), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// Export streams every recorded change the filter matches as NDJSON, the
+// latest first, reading them a page at a time so the log is never held in
+// memory. The changes recorded after the export started are left out, they
+// would shift the pages still to be read.
+func (h *Handlers) Export(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	const rowsPerPage = 100
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	if filter.EndCreatedDate == nil {
+		filter.WithEndCreatedDate(time.Now())
+	}
+
+	var auds []audit.Audit
+	var pageNumber int
+	var last bool
+
+	next := func(ctx context.Context) (AppAudit, bool, error) {
+		if len(auds) == 0 {
+			if last {
+				return AppAudit{}, false, nil
+			}
+
+			pageNumber++
+
+			var err error
+			auds, err = h.audit.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+			if err != nil {
+				return AppAudit{}, false, fmt.Errorf("query: page[%d]: %w", pageNumber, err)
+			}
+
+			last = len(auds) < rowsPerPage
+
+			if len(auds) == 0 {
+				return AppAudit{}, false, nil
+			}
+		}
+
+		aud := auds[0]
+		auds = auds[1:]
+
+		return toAppAudit(aud), true, nil
+	}
+
+	return web.RespondIter(ctx, w, web.NDJSON, http.StatusOK, next)
+}
@@ -0,0 +1,62 @@
+package auditgrp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (audit.QueryFilter, error) {
+	const (
+		filterByActorID          = "actor_id"
+		filterByEntity           = "entity"
+		filterByEntityID         = "entity_id"
+		filterByStartCreatedDate = "start_created_date"
+		filterByEndCreatedDate   = "end_created_date"
+	)
+
+	values := r.URL.Query()
+
+	var filter audit.QueryFilter
+
+	if actorID := values.Get(filterByActorID); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByActorID, err)
+		}
+		filter.WithActorID(id)
+	}
+
+	if entity := values.Get(filterByEntity); entity != "" {
+		filter.WithEntity(entity)
+	}
+
+	if entityID := values.Get(filterByEntityID); entityID != "" {
+		filter.WithEntityID(entityID)
+	}
+
+	if createdDate := values.Get(filterByStartCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByStartCreatedDate, err)
+		}
+		filter.WithStartDateCreated(t)
+	}
+
+	if createdDate := values.Get(filterByEndCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return audit.QueryFilter{}, validate.NewFieldsError(filterByEndCreatedDate, err)
+		}
+		filter.WithEndCreatedDate(t)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return audit.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,50 @@
+package auditgrp
+
+import (
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/google/uuid"
+)
+
+// AppAudit represents a record of a change. The actor is empty for the
+// changes the system made on its own.
+type AppAudit struct {
+	ID          string         `json:"id"`
+	ActorID     string         `json:"actorID,omitempty"`
+	Action      string         `json:"action"`
+	Entity      string         `json:"entity"`
+	EntityID    string         `json:"entityID"`
+	Before      map[string]any `json:"before,omitempty"`
+	After       map[string]any `json:"after,omitempty"`
+	TraceID     string         `json:"traceID"`
+	DateCreated string         `json:"dateCreated"`
+}
+
+func toAppAudit(aud audit.Audit) AppAudit {
+	app := AppAudit{
+		ID:          aud.ID.String(),
+		Action:      aud.Action,
+		Entity:      aud.Entity,
+		EntityID:    aud.EntityID,
+		Before:      aud.Before,
+		After:       aud.After,
+		TraceID:     aud.TraceID,
+		DateCreated: aud.DateCreated.Format(time.RFC3339),
+	}
+
+	if aud.ActorID != uuid.Nil {
+		app.ActorID = aud.ActorID.String()
+	}
+
+	return app
+}
+
+func toAppAudits(auds []audit.Audit) []AppAudit {
+	items := make([]AppAudit, len(auds))
+	for i, aud := range auds {
+		items[i] = toAppAudit(aud)
+	}
+
+	return items
+}
@@ -0,0 +1,39 @@
+package auditgrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+)
+
+func parseOrder(r *http.Request) (order.By, error) {
+	const (
+		orderByID          = "audit_id"
+		orderByActorID     = "actor_id"
+		orderByEntity      = "entity"
+		orderByDateCreated = "date_created"
+	)
+
+	var orderByFields = map[string]string{
+		orderByID:          audit.OrderByID,
+		orderByActorID:     audit.OrderByActorID,
+		orderByEntity:      audit.OrderByEntity,
+		orderByDateCreated: audit.OrderByDateCreated,
+	}
+
+	orderBy, err := order.Parse(r, order.NewBy(orderByDateCreated, order.DESC))
+	if err != nil {
+		return order.By{}, err
+	}
+
+	if _, exists := orderByFields[orderBy.Field]; !exists {
+		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
+	}
+
+	orderBy.Field = orderByFields[orderBy.Field]
+
+	return orderBy, nil
+}
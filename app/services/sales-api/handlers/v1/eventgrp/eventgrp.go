@@ -0,0 +1,106 @@
+// Package eventgrp maintains the group of handlers for the event stream.
+package eventgrp
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/change"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"github.com/google/uuid"
+)
+
+// heartbeat is how often a quiet stream sends a comment, so proxies don't
+// close it.
+const heartbeat = 15 * time.Second
+
+// Handlers manages the set of event endpoints.
+type Handlers struct {
+	change *change.Core
+	auth   *auth.Auth
+}
+
+// New constructs a handlers for route access.
+func New(change *change.Core, auth *auth.Auth) *Handlers {
+	return &Handlers{
+		change: change,
+		auth:   auth,
+	}
+}
+
+// Stream sends the changes made to users and products to the client as
+// Server-Sent Events, until the client goes away or the service shuts
+// down. A super admin sees every change, an admin the changes in their
+// tenant and anyone else the changes to their own user and products.
+func (h *Handlers) Stream(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims := auth.GetClaims(ctx)
+
+	visible, err := h.visibility(ctx, claims)
+	if err != nil {
+		return err
+	}
+
+	sub := h.change.Subscribe()
+	defer sub.Close()
+
+	stream, err := web.NewStream(ctx, w)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	// The response has started, an error writing to it means the client went
+	// away and there is nobody left to tell.
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-ticker.C:
+			if err := stream.Comment("heartbeat"); err != nil {
+				return nil
+			}
+
+		case chg, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+
+			if !visible(chg) {
+				continue
+			}
+
+			if err := stream.Send(chg.Entity+"."+chg.Action, "", toAppChange(chg)); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// visibility decides once for the stream which changes the claims allow the
+// client to see.
+func (h *Handlers) visibility(ctx context.Context, claims auth.Claims) (func(change.Change) bool, error) {
+	if err := h.auth.Authorize(ctx, claims, uuid.UUID{}, auth.RuleSuperAdminOnly); err == nil {
+		return func(change.Change) bool { return true }, nil
+	}
+
+	if err := h.auth.Authorize(ctx, claims, uuid.UUID{}, auth.RuleAdminOnly); err == nil {
+		tenantID, err := uuid.Parse(claims.TenantID)
+		if err != nil {
+			return nil, auth.NewAuthError("stream: no tenant in claims")
+		}
+
+		return func(chg change.Change) bool { return chg.TenantID == tenantID }, nil
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, auth.NewAuthError("stream: invalid subject in claims: %s", err)
+	}
+
+	return func(chg change.Change) bool { return chg.UserID == userID }, nil
+}
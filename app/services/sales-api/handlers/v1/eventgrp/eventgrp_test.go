@@ -0,0 +1,192 @@
+package eventgrp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/eventgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/change"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func Test_Visibility(t *testing.T) {
+	a, err := auth.New(auth.Config{
+		Log:    zap.NewNop().Sugar(),
+		Issuer: "service project",
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the auth : %s", err)
+	}
+
+	tenantA := uuid.New()
+	tenantB := uuid.New()
+	subject := uuid.New()
+
+	changes := []change.Change{
+		{Entity: change.EntityProduct, Action: change.ActionCreated, ID: uuid.New(), UserID: subject, TenantID: tenantA},
+		{Entity: change.EntityProduct, Action: change.ActionUpdated, ID: uuid.New(), UserID: uuid.New(), TenantID: tenantA},
+		{Entity: change.EntityUser, Action: change.ActionDeleted, ID: uuid.New(), UserID: uuid.New(), TenantID: tenantB},
+	}
+
+	tt := []struct {
+		name  string
+		roles []user.Role
+		exp   []change.Change
+	}{
+		{"super-admin", []user.Role{user.RoleSuperAdmin}, changes},
+		{"admin", []user.Role{user.RoleAdmin}, changes[:2]},
+		{"subject", []user.Role{user.RoleUser}, changes[:1]},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			claims := auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: subject.String()},
+				Roles:            tst.roles,
+				TenantID:         tenantA.String(),
+			}
+
+			got := stream(t, a, claims, changes)
+
+			var exp []string
+			for _, chg := range tst.exp {
+				exp = append(exp, chg.Entity+"."+chg.Action+" "+chg.ID.String())
+			}
+
+			if diff := cmp.Diff(got, exp); diff != "" {
+				t.Errorf("Should only stream the changes the claims allow. Diff:\n%s", diff)
+			}
+		})
+	}
+}
+
+// =============================================================================
+
+// stream runs the handler for the claims until the feed has passed on the
+// changes, and returns the events it sent as "name id".
+func stream(t *testing.T, a *auth.Auth, claims auth.Claims, changes []change.Change) []string {
+	t.Helper()
+
+	fd := feed{
+		changes:   changes,
+		publish:   make(chan struct{}),
+		published: make(chan struct{}),
+	}
+
+	chgCore := change.NewCore(zap.NewNop().Sugar(), &fd)
+
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	done := make(chan struct{})
+	go func() {
+		chgCore.Run(runCtx, time.Second)
+		close(done)
+	}()
+
+	h := eventgrp.New(chgCore, a)
+
+	w := startWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+		started:          make(chan struct{}),
+	}
+	r := httptest.NewRequest(http.MethodGet, "/v1/events", nil)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- h.Stream(auth.SetClaims(context.Background(), claims), &w, r)
+	}()
+
+	// The handler subscribes before it starts the response, so the changes
+	// are published once it did.
+	select {
+	case <-w.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Should start the stream")
+	}
+
+	close(fd.publish)
+	<-fd.published
+
+	// Stopping the feed closes the subscription once the handler got the
+	// changes, which ends the stream.
+	stop()
+	<-done
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Fatalf("Should be able to stream the changes : %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Should end the stream once the feed stops")
+	}
+
+	var events []string
+	var name string
+
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+
+		case strings.HasPrefix(line, "data: "):
+			var app eventgrp.AppChange
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &app); err != nil {
+				t.Fatalf("Should be able to decode the change : %s", err)
+			}
+
+			events = append(events, name+" "+app.ID)
+		}
+	}
+
+	return events
+}
+
+// feed is a change.Storer that passes the changes on once it is told to.
+type feed struct {
+	changes   []change.Change
+	publish   chan struct{}
+	published chan struct{}
+}
+
+func (f *feed) Listen(ctx context.Context, fn func(change.Change)) error {
+	select {
+	case <-f.publish:
+	case <-ctx.Done():
+		return nil
+	}
+
+	for _, chg := range f.changes {
+		fn(chg)
+	}
+	close(f.published)
+
+	<-ctx.Done()
+	return nil
+}
+
+// startWriter signals once the handler starts the response.
+type startWriter struct {
+	*httptest.ResponseRecorder
+	started chan struct{}
+}
+
+func (w *startWriter) WriteHeader(statusCode int) {
+	w.ResponseRecorder.WriteHeader(statusCode)
+	close(w.started)
+}
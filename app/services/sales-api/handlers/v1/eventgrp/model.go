@@ -0,0 +1,28 @@
+package eventgrp
+
+import (
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/change"
+)
+
+// AppChange represents a change made to a user or a product.
+type AppChange struct {
+	Entity   string `json:"entity"`
+	Action   string `json:"action"`
+	ID       string `json:"id"`
+	UserID   string `json:"userID"`
+	TenantID string `json:"tenantID"`
+	Date     string `json:"date"`
+}
+
+func toAppChange(chg change.Change) AppChange {
+	return AppChange{
+		Entity:   chg.Entity,
+		Action:   chg.Action,
+		ID:       chg.ID.String(),
+		UserID:   chg.UserID.String(),
+		TenantID: chg.TenantID.String(),
+		Date:     chg.Date.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,40 @@
+// Package jwksgrp maintains the group of handlers for publishing the public
+// keys used to verify tokens.
+package jwksgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/keystore"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+)
+
+// KeySet declares the behavior required to produce a JSON Web Key Set.
+type KeySet interface {
+	JWKS() (keystore.JWKS, error)
+}
+
+// Handlers manages the set of jwks endpoints.
+type Handlers struct {
+	keySet KeySet
+}
+
+// New constructs a handlers for route access.
+func New(keySet KeySet) *Handlers {
+	return &Handlers{
+		keySet: keySet,
+	}
+}
+
+// Query returns the public keys as a JSON Web Key Set so other services can
+// verify the tokens we generate using the kid in the token header.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	jwks, err := h.keySet.JWKS()
+	if err != nil {
+		return fmt.Errorf("jwks: %w", err)
+	}
+
+	return web.Respond(ctx, w, jwks, http.StatusOK)
+}
@@ -0,0 +1,106 @@
+package prdgrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppProduct represents an individual product.
+type AppProduct struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Cost        float64 `json:"cost"`
+	Quantity    int     `json:"quantity"`
+	Sold        int     `json:"sold"`
+	Revenue     float64 `json:"revenue"`
+	UserID      string  `json:"userID"`
+	TenantID    string  `json:"tenantID"`
+	DateCreated string  `json:"dateCreated"`
+	DateUpdated string  `json:"dateUpdated"`
+}
+
+func toAppProduct(prd product.Product) AppProduct {
+	return AppProduct{
+		ID:          prd.ID.String(),
+		Name:        prd.Name,
+		Cost:        prd.Cost,
+		Quantity:    prd.Quantity,
+		Sold:        prd.Sold,
+		Revenue:     prd.Revenue,
+		UserID:      prd.UserID.String(),
+		TenantID:    prd.TenantID.String(),
+		DateCreated: prd.DateCreated.Format(time.RFC3339),
+		DateUpdated: prd.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppProducts(prds []product.Product) []AppProduct {
+	items := make([]AppProduct, len(prds))
+	for i, prd := range prds {
+		items[i] = toAppProduct(prd)
+	}
+
+	return items
+}
+
+// =============================================================================
+
+// AppNewProduct is what we require from clients when adding a Product.
+// The user the product belongs to is taken from the claims of the caller.
+type AppNewProduct struct {
+	Name     string  `json:"name" validate:"required"`
+	Cost     float64 `json:"cost" validate:"required,gte=0"`
+	Quantity int     `json:"quantity" validate:"gte=1"`
+}
+
+func toCoreNewProduct(app AppNewProduct, userID uuid.UUID) product.NewProduct {
+	prd := product.NewProduct{
+		UserID:   userID,
+		Name:     app.Name,
+		Cost:     app.Cost,
+		Quantity: app.Quantity,
+	}
+
+	return prd
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewProduct) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdateProduct contains information needed to update a product.
+type AppUpdateProduct struct {
+	Name     *string  `json:"name"`
+	Cost     *float64 `json:"cost" validate:"omitempty,gte=0"`
+	Quantity *int     `json:"quantity" validate:"omitempty,gte=1"`
+}
+
+func toCoreUpdateProduct(app AppUpdateProduct) product.UpdateProduct {
+	core := product.UpdateProduct{
+		Name:     app.Name,
+		Cost:     app.Cost,
+		Quantity: app.Quantity,
+	}
+
+	return core
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateProduct) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
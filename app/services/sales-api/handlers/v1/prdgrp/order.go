@@ -0,0 +1,45 @@
+package prdgrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+)
+
+func parseOrder(r *http.Request) (order.By, error) {
+	const (
+		orderByProdID   = "product_id"
+		orderByName     = "name"
+		orderByCost     = "cost"
+		orderByQuantity = "quantity"
+		orderBySold     = "sold"
+		orderByRevenue  = "revenue"
+		orderByUserID   = "user_id"
+	)
+
+	var orderByFields = map[string]string{
+		orderByProdID:   product.OrderByProdID,
+		orderByName:     product.OrderByName,
+		orderByCost:     product.OrderByCost,
+		orderByQuantity: product.OrderByQuantity,
+		orderBySold:     product.OrderBySold,
+		orderByRevenue:  product.OrderByRevenue,
+		orderByUserID:   product.OrderByUserID,
+	}
+
+	orderBy, err := order.Parse(r, order.NewBy(orderByProdID, order.ASC))
+	if err != nil {
+		return order.By{}, err
+	}
+
+	if _, exists := orderByFields[orderBy.Field]; !exists {
+		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
+	}
+
+	orderBy.Field = orderByFields[orderBy.Field]
+
+	return orderBy, nil
+}
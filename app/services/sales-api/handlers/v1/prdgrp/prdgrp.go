@@ -0,0 +1,173 @@
+// Package prdgrp maintains the group of handlers for product access.
+package prdgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
+	v1 "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/mid"
+	paging "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/paging"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of product endpoints.
+type Handlers struct {
+	product *product.Core
+}
+
+// New constructs a handlers for route access.
+func New(product *product.Core) *Handlers {
+	return &Handlers{
+		product: product,
+	}
+}
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *Handlers) executeUnderTransaction(ctx context.Context) (*Handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		product, err := h.product.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		h = &Handlers{
+			product: product,
+		}
+
+		return h, nil
+	}
+
+	return h, nil
+}
+
+// Create adds a new product to the system.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewProduct
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	// The product is always owned by the user making the call.
+	userID, err := uuid.Parse(auth.GetClaims(ctx).Subject)
+	if err != nil {
+		return auth.NewAuthError("create: invalid subject in claims: %s", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	prd, err := h.product.Create(ctx, toCoreNewProduct(app, userID))
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrUserDisabled):
+			return v1.NewRequestError(err, http.StatusForbidden)
+		case errors.Is(err, product.ErrInvalidCost):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("create: app[%+v]: %w", app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppProduct(prd), http.StatusCreated)
+}
+
+// Update updates a product in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateProduct
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	// The product was already loaded and authorized by the AuthorizeProduct middleware.
+	prd, err := mid.GetProduct(ctx)
+	if err != nil {
+		return fmt.Errorf("getproduct: %w", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	updPrd, err := h.product.Update(ctx, prd, toCoreUpdateProduct(app))
+	if err != nil {
+		if errors.Is(err, product.ErrInvalidCost) {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("update: productID[%s] app[%+v]: %w", prd.ID, app, err)
+	}
+
+	return web.Respond(ctx, w, toAppProduct(updPrd), http.StatusOK)
+}
+
+// Delete removes a product from the system.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	prd, err := mid.GetProduct(ctx)
+	if err != nil {
+		return fmt.Errorf("getproduct: %w", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := h.product.Delete(ctx, prd); err != nil {
+		if errors.Is(err, product.ErrHasSales) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("delete: productID[%s]: %w", prd.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns a list of products with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	prds, err := h.product.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.product.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppProducts(prds), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a product by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	prd, err := mid.GetProduct(ctx)
+	if err != nil {
+		return fmt.Errorf("getproduct: %w", err)
+	}
+
+	return web.Respond(ctx, w, toAppProduct(prd), http.StatusOK)
+}
@@ -0,0 +1,81 @@
+package rolegrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+)
+
+// AppRole represents an individual role.
+type AppRole struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+	DateCreated string   `json:"dateCreated"`
+	DateUpdated string   `json:"dateUpdated"`
+}
+
+func toAppRole(rl role.Role) AppRole {
+	return AppRole{
+		Name:        rl.Name,
+		Permissions: rl.Permissions,
+		DateCreated: rl.DateCreated.Format(time.RFC3339),
+		DateUpdated: rl.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppRoles(rls []role.Role) []AppRole {
+	items := make([]AppRole, len(rls))
+	for i, rl := range rls {
+		items[i] = toAppRole(rl)
+	}
+
+	return items
+}
+
+// =============================================================================
+
+// AppNewRole is what we require from clients when adding a Role.
+type AppNewRole struct {
+	Name        string   `json:"name" validate:"required"`
+	Permissions []string `json:"permissions" validate:"dive,required"`
+}
+
+func toCoreNewRole(app AppNewRole) role.NewRole {
+	return role.NewRole{
+		Name:        app.Name,
+		Permissions: app.Permissions,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewRole) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdateRole contains information needed to update a role.
+type AppUpdateRole struct {
+	Permissions []string `json:"permissions" validate:"required,dive,required"`
+}
+
+func toCoreUpdateRole(app AppUpdateRole) role.UpdateRole {
+	return role.UpdateRole{
+		Permissions: app.Permissions,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateRole) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package rolegrp maintains the group of handlers for role access.
+package rolegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
+	v1 "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+)
+
+// Handlers manages the set of role endpoints. Every change reloads the roles
+// of this instance right away, the other instances pick it up on their next
+// reload.
+type Handlers struct {
+	role *role.Core
+	auth *auth.Auth
+}
+
+// New constructs a handlers for route access.
+func New(role *role.Core, auth *auth.Auth) *Handlers {
+	return &Handlers{
+		role: role,
+		auth: auth,
+	}
+}
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *Handlers) executeUnderTransaction(ctx context.Context) (*Handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		role, err := h.role.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		h = &Handlers{
+			role: role,
+			auth: h.auth,
+		}
+
+		return h, nil
+	}
+
+	return h, nil
+}
+
+// Create adds a new role to the system.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewRole
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	rl, err := h.role.Create(ctx, toCoreNewRole(app))
+	if err != nil {
+		if errors.Is(err, role.ErrUniqueName) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("create: app[%+v]: %w", app, err)
+	}
+
+	h.auth.ReloadRolesOnCommit(ctx)
+
+	return web.Respond(ctx, w, toAppRole(rl), http.StatusCreated)
+}
+
+// Update replaces the permissions of a role in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateRole
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	rl, err := h.queryByName(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	rl, err = h.role.Update(ctx, rl, toCoreUpdateRole(app))
+	if err != nil {
+		return fmt.Errorf("update: name[%s] app[%+v]: %w", rl.Name, app, err)
+	}
+
+	h.auth.ReloadRolesOnCommit(ctx)
+
+	return web.Respond(ctx, w, toAppRole(rl), http.StatusOK)
+}
+
+// Delete removes a role that isn't built in and no one holds.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	rl, err := h.queryByName(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.role.Delete(ctx, rl); err != nil {
+		switch {
+		case errors.Is(err, role.ErrBuiltIn):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, role.ErrInUse):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("delete: name[%s]: %w", rl.Name, err)
+		}
+	}
+
+	h.auth.ReloadRolesOnCommit(ctx)
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns every role.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	rls, err := h.role.QueryAll(ctx)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	return web.Respond(ctx, w, toAppRoles(rls), http.StatusOK)
+}
+
+// QueryByName returns a role by its name.
+func (h *Handlers) QueryByName(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	rl, err := h.queryByName(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppRole(rl), http.StatusOK)
+}
+
+// queryByName loads the role specified in the path.
+func (h *Handlers) queryByName(ctx context.Context, r *http.Request) (role.Role, error) {
+	name := web.Param(r, "name")
+
+	rl, err := h.role.QueryByName(ctx, name)
+	if err != nil {
+		switch {
+		case errors.Is(err, role.ErrNotFound):
+			return role.Role{}, v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return role.Role{}, fmt.Errorf("querybyname: name[%s]: %w", name, err)
+		}
+	}
+
+	return rl, nil
+}
@@ -0,0 +1,70 @@
+package salegrp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (sale.QueryFilter, error) {
+	const (
+		filterBySaleID           = "sale_id"
+		filterByProductID        = "product_id"
+		filterByUserID           = "user_id"
+		filterByStartCreatedDate = "start_created_date"
+		filterByEndCreatedDate   = "end_created_date"
+	)
+
+	values := r.URL.Query()
+
+	var filter sale.QueryFilter
+
+	if saleID := values.Get(filterBySaleID); saleID != "" {
+		id, err := uuid.Parse(saleID)
+		if err != nil {
+			return sale.QueryFilter{}, validate.NewFieldsError(filterBySaleID, err)
+		}
+		filter.WithSaleID(id)
+	}
+
+	if productID := values.Get(filterByProductID); productID != "" {
+		id, err := uuid.Parse(productID)
+		if err != nil {
+			return sale.QueryFilter{}, validate.NewFieldsError(filterByProductID, err)
+		}
+		filter.WithProductID(id)
+	}
+
+	if userID := values.Get(filterByUserID); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return sale.QueryFilter{}, validate.NewFieldsError(filterByUserID, err)
+		}
+		filter.WithUserID(id)
+	}
+
+	if createdDate := values.Get(filterByStartCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return sale.QueryFilter{}, validate.NewFieldsError(filterByStartCreatedDate, err)
+		}
+		filter.WithStartDateCreated(t)
+	}
+
+	if createdDate := values.Get(filterByEndCreatedDate); createdDate != "" {
+		t, err := time.Parse(time.RFC3339, createdDate)
+		if err != nil {
+			return sale.QueryFilter{}, validate.NewFieldsError(filterByEndCreatedDate, err)
+		}
+		filter.WithEndCreatedDate(t)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return sale.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,73 @@
+package salegrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppSale represents an individual sale.
+type AppSale struct {
+	ID          string  `json:"id"`
+	ProductID   string  `json:"productID"`
+	UserID      string  `json:"userID"`
+	Quantity    int     `json:"quantity"`
+	Paid        float64 `json:"paid"`
+	DateCreated string  `json:"dateCreated"`
+}
+
+func toAppSale(sl sale.Sale) AppSale {
+	return AppSale{
+		ID:          sl.ID.String(),
+		ProductID:   sl.ProductID.String(),
+		UserID:      sl.UserID.String(),
+		Quantity:    sl.Quantity,
+		Paid:        sl.Paid,
+		DateCreated: sl.DateCreated.Format(time.RFC3339),
+	}
+}
+
+func toAppSales(sls []sale.Sale) []AppSale {
+	items := make([]AppSale, len(sls))
+	for i, sl := range sls {
+		items[i] = toAppSale(sl)
+	}
+
+	return items
+}
+
+// =============================================================================
+
+// AppNewSale is what we require from clients when recording a Sale.
+// The buyer is taken from the claims of the caller.
+type AppNewSale struct {
+	ProductID string `json:"productID" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"required,gte=1"`
+}
+
+func toCoreNewSale(app AppNewSale, userID uuid.UUID) (sale.NewSale, error) {
+	productID, err := uuid.Parse(app.ProductID)
+	if err != nil {
+		return sale.NewSale{}, fmt.Errorf("parsing productID: %w", err)
+	}
+
+	ns := sale.NewSale{
+		ProductID: productID,
+		UserID:    userID,
+		Quantity:  app.Quantity,
+	}
+
+	return ns, nil
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewSale) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
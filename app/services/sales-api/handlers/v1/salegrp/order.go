@@ -0,0 +1,43 @@
+package salegrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+)
+
+func parseOrder(r *http.Request) (order.By, error) {
+	const (
+		orderBySaleID      = "sale_id"
+		orderByProductID   = "product_id"
+		orderByUserID      = "user_id"
+		orderByQuantity    = "quantity"
+		orderByPaid        = "paid"
+		orderByDateCreated = "date_created"
+	)
+
+	var orderByFields = map[string]string{
+		orderBySaleID:      sale.OrderBySaleID,
+		orderByProductID:   sale.OrderByProductID,
+		orderByUserID:      sale.OrderByUserID,
+		orderByQuantity:    sale.OrderByQuantity,
+		orderByPaid:        sale.OrderByPaid,
+		orderByDateCreated: sale.OrderByDateCreated,
+	}
+
+	orderBy, err := order.Parse(r, order.NewBy(orderByDateCreated, order.DESC))
+	if err != nil {
+		return order.By{}, err
+	}
+
+	if _, exists := orderByFields[orderBy.Field]; !exists {
+		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
+	}
+
+	orderBy.Field = orderByFields[orderBy.Field]
+
+	return orderBy, nil
+}
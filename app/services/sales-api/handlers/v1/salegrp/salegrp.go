@@ -0,0 +1,139 @@
+// Package salegrp maintains the group of handlers for sale access.
+package salegrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
+	v1 "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/mid"
+	paging "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/paging"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of sale endpoints.
+type Handlers struct {
+	sale *sale.Core
+}
+
+// New constructs a handlers for route access.
+func New(sale *sale.Core) *Handlers {
+	return &Handlers{
+		sale: sale,
+	}
+}
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *Handlers) executeUnderTransaction(ctx context.Context) (*Handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		sale, err := h.sale.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		h = &Handlers{
+			sale: sale,
+		}
+
+		return h, nil
+	}
+
+	return h, nil
+}
+
+// Create records a new sale for the user making the call.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewSale
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(auth.GetClaims(ctx).Subject)
+	if err != nil {
+		return auth.NewAuthError("create: invalid subject in claims: %s", err)
+	}
+
+	ns, err := toCoreNewSale(app, userID)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	sl, err := h.sale.Create(ctx, ns)
+	if err != nil {
+		switch {
+		case errors.Is(err, product.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, sale.ErrInvalidQuantity):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		case errors.Is(err, sale.ErrInsufficientStock):
+			return v1.NewRequestError(err, http.StatusConflict)
+		default:
+			return fmt.Errorf("create: app[%+v]: %w", app, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppSale(sl), http.StatusCreated)
+}
+
+// Query returns a list of sales with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	sls, err := h.sale.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.sale.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppSales(sls), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a sale by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	saleID, err := uuid.Parse(web.Param(r, "sale_id"))
+	if err != nil {
+		return v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	sl, err := h.sale.QueryByID(ctx, saleID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sale.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return fmt.Errorf("querybyid: saleID[%s]: %w", saleID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppSale(sl), http.StatusOK)
+}
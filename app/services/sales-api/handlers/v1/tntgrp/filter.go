@@ -0,0 +1,38 @@
+package tntgrp
+
+import (
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (tenant.QueryFilter, error) {
+	const (
+		filterByTenantID = "tenant_id"
+		filterByName     = "name"
+	)
+
+	values := r.URL.Query()
+
+	var filter tenant.QueryFilter
+
+	if tenantID := values.Get(filterByTenantID); tenantID != "" {
+		id, err := uuid.Parse(tenantID)
+		if err != nil {
+			return tenant.QueryFilter{}, validate.NewFieldsError(filterByTenantID, err)
+		}
+		filter.WithTenantID(id)
+	}
+
+	if name := values.Get(filterByName); name != "" {
+		filter.WithName(name)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return tenant.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,79 @@
+package tntgrp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+)
+
+// AppTenant represents an individual tenant.
+type AppTenant struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DateCreated string `json:"dateCreated"`
+	DateUpdated string `json:"dateUpdated"`
+}
+
+func toAppTenant(tnt tenant.Tenant) AppTenant {
+	return AppTenant{
+		ID:          tnt.ID.String(),
+		Name:        tnt.Name,
+		DateCreated: tnt.DateCreated.Format(time.RFC3339),
+		DateUpdated: tnt.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppTenants(tnts []tenant.Tenant) []AppTenant {
+	items := make([]AppTenant, len(tnts))
+	for i, tnt := range tnts {
+		items[i] = toAppTenant(tnt)
+	}
+
+	return items
+}
+
+// =============================================================================
+
+// AppNewTenant is what we require from clients when adding a Tenant.
+type AppNewTenant struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func toCoreNewTenant(app AppNewTenant) tenant.NewTenant {
+	return tenant.NewTenant{
+		Name: app.Name,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewTenant) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdateTenant contains information needed to update a tenant.
+type AppUpdateTenant struct {
+	Name *string `json:"name" validate:"omitempty,min=1"`
+}
+
+func toCoreUpdateTenant(app AppUpdateTenant) tenant.UpdateTenant {
+	return tenant.UpdateTenant{
+		Name: app.Name,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateTenant) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
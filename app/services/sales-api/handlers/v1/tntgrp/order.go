@@ -0,0 +1,35 @@
+package tntgrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+)
+
+func parseOrder(r *http.Request) (order.By, error) {
+	const (
+		orderByTenantID = "tenant_id"
+		orderByName     = "name"
+	)
+
+	var orderByFields = map[string]string{
+		orderByTenantID: tenant.OrderByID,
+		orderByName:     tenant.OrderByName,
+	}
+
+	orderBy, err := order.Parse(r, order.NewBy(orderByTenantID, order.ASC))
+	if err != nil {
+		return order.By{}, err
+	}
+
+	if _, exists := orderByFields[orderBy.Field]; !exists {
+		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
+	}
+
+	orderBy.Field = orderByFields[orderBy.Field]
+
+	return orderBy, nil
+}
@@ -0,0 +1,181 @@
+// Package tntgrp maintains the group of handlers for tenant access.
+package tntgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	v1 "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/mid"
+	paging "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/paging"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of tenant endpoints.
+type Handlers struct {
+	tenant *tenant.Core
+}
+
+// New constructs a handlers for route access.
+func New(tenant *tenant.Core) *Handlers {
+	return &Handlers{
+		tenant: tenant,
+	}
+}
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *Handlers) executeUnderTransaction(ctx context.Context) (*Handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		tenant, err := h.tenant.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		h = &Handlers{
+			tenant: tenant,
+		}
+
+		return h, nil
+	}
+
+	return h, nil
+}
+
+// Create adds a new tenant to the system.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewTenant
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	tnt, err := h.tenant.Create(ctx, toCoreNewTenant(app))
+	if err != nil {
+		if errors.Is(err, tenant.ErrUniqueName) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("create: app[%+v]: %w", app, err)
+	}
+
+	return web.Respond(ctx, w, toAppTenant(tnt), http.StatusCreated)
+}
+
+// Update updates a tenant in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateTenant
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	tnt, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	tnt, err = h.tenant.Update(ctx, tnt, toCoreUpdateTenant(app))
+	if err != nil {
+		if errors.Is(err, tenant.ErrUniqueName) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("update: tenantID[%s] app[%+v]: %w", tnt.ID, app, err)
+	}
+
+	return web.Respond(ctx, w, toAppTenant(tnt), http.StatusOK)
+}
+
+// Delete removes a tenant without users from the system.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	tnt, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.tenant.Delete(ctx, tnt); err != nil {
+		if errors.Is(err, tenant.ErrNotEmpty) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("delete: tenantID[%s]: %w", tnt.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns a list of tenants with paging. Admins only see their own.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	tnts, err := h.tenant.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.tenant.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppTenants(tnts), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
+
+// QueryByID returns a tenant by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	tnt, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppTenant(tnt), http.StatusOK)
+}
+
+// queryByID loads the tenant specified in the path.
+func (h *Handlers) queryByID(ctx context.Context, r *http.Request) (tenant.Tenant, error) {
+	tenantID, err := uuid.Parse(web.Param(r, "tenant_id"))
+	if err != nil {
+		return tenant.Tenant{}, v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	tnt, err := h.tenant.QueryByID(ctx, tenantID)
+	if err != nil {
+		switch {
+		case errors.Is(err, tenant.ErrNotFound):
+			return tenant.Tenant{}, v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return tenant.Tenant{}, fmt.Errorf("querybyid: tenantID[%s]: %w", tenantID, err)
+		}
+	}
+
+	return tnt, nil
+}
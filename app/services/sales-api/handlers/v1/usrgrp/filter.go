@@ -13,6 +13,7 @@ import (
 func parseFilter(r *http.Request) (user.QueryFilter, error) {
 	const (
 		filterByUserID           = "user_id"
+		filterByTenantID         = "tenant_id"
 		filterByEmail            = "email"
 		filterByStartCreatedDate = "start_created_date"
 		filterByEndCreatedDate   = "end_created_date"
@@ -31,6 +32,14 @@ func parseFilter(r *http.Request) (user.QueryFilter, error) {
 		filter.WithUserID(id)
 	}
 
+	if tenantID := values.Get(filterByTenantID); tenantID != "" {
+		id, err := uuid.Parse(tenantID)
+		if err != nil {
+			return user.QueryFilter{}, validate.NewFieldsError(filterByTenantID, err)
+		}
+		filter.WithTenantID(id)
+	}
+
 	if email := values.Get(filterByEmail); email != "" {
 		addr, err := mail.ParseAddress(email)
 		if err != nil {
@@ -5,8 +5,10 @@ import (
 	"net/mail"
 	"time"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/mfa"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
 )
 
 // AppUser represents information about an individual user.
@@ -16,15 +18,17 @@ import (
 // Here all types are scaler types, you won't see UIID or email types here
 // We are using this due to the shortcoming of the json standard library package
 type AppUser struct {
-	ID           string   `json:"id"`
-	Name         string   `json:"name"`
-	Email        string   `json:"email"`
-	Roles        []string `json:"roles"`
-	PasswordHash []byte   `json:"-"`
-	Department   string   `json:"department"`
-	Enabled      bool     `json:"enabled"`
-	DateCreated  string   `json:"dateCreated"`
-	DateUpdated  string   `json:"dateUpdated"`
+	ID            string   `json:"id"`
+	TenantID      string   `json:"tenantID"`
+	Name          string   `json:"name"`
+	Email         string   `json:"email"`
+	Roles         []string `json:"roles"`
+	PasswordHash  []byte   `json:"-"`
+	Department    string   `json:"department"`
+	Enabled       bool     `json:"enabled"`
+	EmailVerified bool     `json:"emailVerified"`
+	DateCreated   string   `json:"dateCreated"`
+	DateUpdated   string   `json:"dateUpdated"`
 }
 
 func toAppUser(usr user.User) AppUser {
@@ -34,15 +38,17 @@ func toAppUser(usr user.User) AppUser {
 	}
 
 	return AppUser{
-		ID:           usr.ID.String(),
-		Name:         usr.Name,
-		Email:        usr.Email.Address,
-		Roles:        roles,
-		PasswordHash: usr.PasswordHash,
-		Department:   usr.Department,
-		Enabled:      usr.Enabled,
-		DateCreated:  usr.DateCreated.Format(time.RFC3339),
-		DateUpdated:  usr.DateUpdated.Format(time.RFC3339),
+		ID:            usr.ID.String(),
+		TenantID:      usr.TenantID.String(),
+		Name:          usr.Name,
+		Email:         usr.Email.Address,
+		Roles:         roles,
+		PasswordHash:  usr.PasswordHash,
+		Department:    usr.Department,
+		Enabled:       usr.Enabled,
+		EmailVerified: usr.EmailVerified,
+		DateCreated:   usr.DateCreated.Format(time.RFC3339),
+		DateUpdated:   usr.DateUpdated.Format(time.RFC3339),
 	}
 }
 
@@ -58,8 +64,10 @@ func toAppUsers(users []user.User) []AppUser {
 
 // =============================================================================
 
-// AppNewUser contains information needed to create a new user.
+// AppNewUser contains information needed to create a new user. The tenant
+// is the one of the caller when it is not specified.
 type AppNewUser struct {
+	TenantID        string   `json:"tenantID" validate:"omitempty,uuid"`
 	Name            string   `json:"name" validate:"required"`
 	Email           string   `json:"email" validate:"required,email"`
 	Roles           []string `json:"roles" validate:"required"`
@@ -83,7 +91,16 @@ func toCoreNewUser(app AppNewUser) (user.NewUser, error) {
 		return user.NewUser{}, fmt.Errorf("parsing email: %w", err)
 	}
 
+	var tenantID uuid.UUID
+	if app.TenantID != "" {
+		tenantID, err = uuid.Parse(app.TenantID)
+		if err != nil {
+			return user.NewUser{}, fmt.Errorf("parsing tenant id: %w", err)
+		}
+	}
+
 	usr := user.NewUser{
+		TenantID:        tenantID,
 		Name:            app.Name,
 		Email:           *addr,
 		Roles:           roles,
@@ -163,12 +180,118 @@ func (app AppUpdateUser) Validate() error {
 
 // =============================================================================
 
-type token struct {
-	Token string `json:"token"`
+// AppToken contains the access token and the refresh token used to get a
+// new one once it expires.
+type AppToken struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+func toAppToken(access string, refresh string) AppToken {
+	return AppToken{
+		Token:        access,
+		RefreshToken: refresh,
+	}
+}
+
+// =============================================================================
+
+// AppRefreshToken contains information needed to exchange a refresh token.
+type AppRefreshToken struct {
+	KID          string `json:"kid" validate:"required"`
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppRefreshToken) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppForgotPassword contains information needed to ask for a password reset.
+type AppForgotPassword struct {
+	Email string `json:"email" validate:"required,email"`
 }
 
-func toToken(v string) token {
-	return token{
-		Token: v,
+// Validate checks the data in the model is considered clean.
+func (app AppForgotPassword) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
 	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppResetPassword contains information needed to reset a password.
+type AppResetPassword struct {
+	Token           string `json:"token" validate:"required"`
+	Password        string `json:"password" validate:"required"`
+	PasswordConfirm string `json:"passwordConfirm" validate:"eqfield=Password"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppResetPassword) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppVerifyEmail contains information needed to verify an email address.
+type AppVerifyEmail struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppVerifyEmail) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppMFAEnrollment contains the TOTP secret to add to an authenticator app,
+// the uri can be shown as a QR code.
+type AppMFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+func toAppMFAEnrollment(enr mfa.Enrollment) AppMFAEnrollment {
+	return AppMFAEnrollment{
+		Secret: enr.Secret,
+		URI:    enr.URI,
+	}
+}
+
+// AppConfirmMFA contains information needed to confirm an mfa enrollment.
+type AppConfirmMFA struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppConfirmMFA) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
+
+// AppRecoveryCodes contains the recovery codes given once mfa is enabled.
+type AppRecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
 }
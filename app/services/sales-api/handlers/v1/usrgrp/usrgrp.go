@@ -5,32 +5,96 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/mail"
+	"slices"
 	"time"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/mfa"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/verify"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
 	v1 "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/mid"
 	paging "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/paging"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
+// Set of token lifetimes.
+const (
+	accessTokenTTL  = time.Hour
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// mfaHeader carries the one-time code when a user with mfa asks for a token.
+const mfaHeader = "X-MFA-Code"
+
 // Handlers manages the set of user endpoints.
 type Handlers struct {
-	user *user.Core
-	auth *auth.Auth
+	user   *user.Core
+	token  *token.Core
+	verify *verify.Core
+	mfa    *mfa.Core
+	auth   *auth.Auth
 }
 
 // New constructs a handlers for route access.
-func New(user *user.Core) *Handlers {
+func New(user *user.Core, token *token.Core, verify *verify.Core, mfa *mfa.Core, auth *auth.Auth) *Handlers {
 	return &Handlers{
-		user: user,
+		user:   user,
+		token:  token,
+		verify: verify,
+		mfa:    mfa,
+		auth:   auth,
 	}
 }
 
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *Handlers) executeUnderTransaction(ctx context.Context) (*Handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		user, err := h.user.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		token, err := h.token.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		verify, err := h.verify.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		mfa, err := h.mfa.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		h = &Handlers{
+			user:   user,
+			token:  token,
+			verify: verify,
+			mfa:    mfa,
+			auth:   h.auth,
+		}
+
+		return h, nil
+	}
+
+	return h, nil
+}
+
 // Create adds a new user to the system.
 func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	var app AppNewUser
@@ -46,77 +110,129 @@ func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Re
 		return v1.NewRequestError(err, http.StatusBadRequest)
 	}
 
+	// An admin creates users in their own tenant, only a super admin can
+	// pick another tenant or grant the super admin role.
+	claims := auth.GetClaims(ctx)
+
+	if nc.TenantID != (uuid.UUID{}) || slices.Contains(nc.Roles, user.RoleSuperAdmin) {
+		if err := h.auth.Authorize(ctx, claims, uuid.UUID{}, auth.RuleSuperAdminOnly); err != nil {
+			return auth.NewForbiddenError("create: only a super admin can choose the tenant or grant super admin: %s", err)
+		}
+	}
+
+	if nc.TenantID == (uuid.UUID{}) {
+		nc.TenantID, err = uuid.Parse(claims.TenantID)
+		if err != nil {
+			return auth.NewAuthError("create: no tenant in claims")
+		}
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
 	usr, err := h.user.Create(ctx, nc)
 	if err != nil {
-		if errors.Is(err, user.ErrUniqueEmail) {
+		switch {
+		case errors.Is(err, user.ErrUniqueEmail):
 			// we return  a trusted error
 			return v1.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, user.ErrUnknownTenant):
+			return v1.NewRequestError(err, http.StatusBadRequest)
 		}
 		return fmt.Errorf("create: usr[%+v]: %w", usr, err)
 	}
 
+	if err := h.verify.SendVerification(ctx, usr); err != nil {
+		return fmt.Errorf("sendverification: userID[%s]: %w", usr.ID, err)
+	}
+
 	// toAppUser() convert the buisness model to the app
 	return web.Respond(ctx, w, toAppUser(usr), http.StatusCreated)
 }
 
-// // Update updates a user in the system.
-// func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-
-// 	var app AppUpdateUser
-// 	if err := web.Decode(r, &app); err != nil {
-// 		return err
-// 	}
-// 	// We are hitting the DB so we can check userID
-// 	userID := auth.GetUserID(ctx)
-
-// 	usr, err := h.user.QueryByID(ctx, userID)
-// 	if err != nil {
-// 		switch {
-// 		case errors.Is(err, user.ErrNotFound):
-// 			return v1.NewRequestError(err, http.StatusNotFound)
-// 		default:
-// 			return fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
-// 		}
-// 	}
-
-// 	uu, err := toCoreUpdateUser(app)
-// 	if err != nil {
-// 		return return v1.NewRequestError(err, http.StatusBadRequest)
-// 	}
-
-// 	usr, err = h.user.Update(ctx, usr, uu)
-// 	if err != nil {
-// 		return fmt.Errorf("update: userID[%s] uu[%+v]: %w", userID, uu, err)
-// 	}
-
-// 	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
-// }
-
-// // Delete removes a user from the system.
-// func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-// 	userID := auth.GetUserID(ctx)
-
-// 	h, err := h.executeUnderTransaction(ctx)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	usr, err := h.user.QueryByID(ctx, userID)
-// 	if err != nil {
-// 		switch {
-// 		case errors.Is(err, user.ErrNotFound):
-// 			return web.Respond(ctx, w, nil, http.StatusNoContent)
-// 		default:
-// 			return fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
-// 		}
-// 	}
-
-// 	if err := h.user.Delete(ctx, usr); err != nil {
-// 		return fmt.Errorf("delete: userID[%s]: %w", userID, err)
-// 	}
-
-// 	return web.Respond(ctx, w, nil, http.StatusNoContent)
-// }
+// Update updates a user in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateUser
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	// The user was already loaded and authorized by the AuthorizeUser middleware.
+	usr, err := mid.GetUser(ctx)
+	if err != nil {
+		return fmt.Errorf("getuser: %w", err)
+	}
+
+	// A subject can update their own user, but only an admin can change
+	// the roles or the enabled state of a user.
+	if app.Roles != nil || app.Enabled != nil {
+		if err := h.auth.Authorize(ctx, auth.GetClaims(ctx), uuid.UUID{}, auth.RuleUserWrite); err != nil {
+			return auth.NewForbiddenError("update: only an admin can change roles or enabled: %s", err)
+		}
+	}
+
+	uu, err := toCoreUpdateUser(app)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	// Only a super admin can grant or take away the super admin role.
+	if uu.Roles != nil && slices.Contains(uu.Roles, user.RoleSuperAdmin) != usr.HasRole(user.RoleSuperAdmin) {
+		if err := h.auth.Authorize(ctx, auth.GetClaims(ctx), uuid.UUID{}, auth.RuleSuperAdminOnly); err != nil {
+			return auth.NewForbiddenError("update: only a super admin can grant or take away super admin: %s", err)
+		}
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	before := usr
+
+	usr, err = h.user.Update(ctx, usr, uu)
+	if err != nil {
+		if errors.Is(err, user.ErrUniqueEmail) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("update: userID[%s] uu[%+v]: %w", usr.ID, uu, err)
+	}
+
+	// A new email address has to be verified again.
+	if usr.Email.Address != before.Email.Address {
+		if err := h.verify.SendVerification(ctx, usr); err != nil {
+			return fmt.Errorf("sendverification: userID[%s]: %w", usr.ID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
+}
+
+// Delete removes a user from the system.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	usr, err := mid.GetUser(ctx)
+	if err != nil {
+		return fmt.Errorf("getuser: %w", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The sales of the user and of their products are kept, so a user with
+	// any can't be deleted.
+	if err := h.user.Delete(ctx, usr); err != nil {
+		if errors.Is(err, user.ErrHasSales) || errors.Is(err, product.ErrHasSales) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("delete: userID[%s]: %w", usr.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
 
 // Query returns a list of users with paging.
 func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
@@ -148,24 +264,19 @@ func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Req
 	return web.Respond(ctx, w, paging.NewResponse(toAppUsers(users), total, page.Number, page.RowsPerPage), http.StatusOK)
 }
 
-// // QueryByID returns a user by its ID.
-// func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-// 	id := auth.GetUserID(ctx)
-
-// 	usr, err := h.user.QueryByID(ctx, id)
-// 	if err != nil {
-// 		switch {
-// 		case errors.Is(err, user.ErrNotFound):
-// 			return response.NewError(err, http.StatusNotFound)
-// 		default:
-// 			return fmt.Errorf("querybyid: id[%s]: %w", id, err)
-// 		}
-// 	}
+// QueryByID returns a user by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	usr, err := mid.GetUser(ctx)
+	if err != nil {
+		return fmt.Errorf("getuser: %w", err)
+	}
 
-// 	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
-// }
+	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
+}
 
-// Token provides an API token for the authenticated user.
+// Token provides an API token for the authenticated user. A user that
+// enabled mfa must also send a TOTP code or a recovery code in the
+// X-MFA-Code header.
 func (h *Handlers) Token(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
 	kid := web.Param(r, "kid")
 	if kid == "" {
@@ -182,32 +293,371 @@ func (h *Handlers) Token(ctx context.Context, w http.ResponseWriter, r *http.Req
 		return auth.NewAuthError("invalid email format")
 	}
 
-	usr, err := h.user.Authenticate(ctx, *addr, pass)
+	usr, err := h.user.Authenticate(ctx, *addr, pass, remoteAddress(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, user.ErrNotFound):
 			return v1.NewRequestError(err, http.StatusNotFound)
 		case errors.Is(err, user.ErrAuthenticationFailure):
 			return auth.NewAuthError(err.Error())
+		case errors.Is(err, user.ErrUserDisabled):
+			return v1.NewRequestError(err, http.StatusForbidden)
+		case errors.Is(err, user.ErrAccountLocked):
+			return v1.NewRequestError(err, http.StatusLocked)
+		case errors.Is(err, user.ErrTooManyAttempts):
+			return v1.NewRequestError(err, http.StatusTooManyRequests)
 		default:
 			return fmt.Errorf("authenticate: %w", err)
 		}
 	}
 
+	amr := []string{auth.AMRPassword}
+
+	enabled, err := h.mfa.IsEnabled(ctx, usr.ID)
+	if err != nil {
+		return fmt.Errorf("mfa.isenabled: userID[%s]: %w", usr.ID, err)
+	}
+
+	if enabled {
+		code := r.Header.Get(mfaHeader)
+		if code == "" {
+			return auth.NewAuthError("mfa code required in the %s header", mfaHeader)
+		}
+
+		if err := h.mfa.Verify(ctx, usr.ID, code); err != nil {
+			switch {
+			case errors.Is(err, mfa.ErrInvalidCode):
+				return auth.NewAuthError(err.Error())
+			case errors.Is(err, mfa.ErrTooManyAttempts):
+				return v1.NewRequestError(err, http.StatusTooManyRequests)
+			default:
+				return fmt.Errorf("mfa.verify: userID[%s]: %w", usr.ID, err)
+			}
+		}
+
+		amr = append(amr, auth.AMROTP, auth.AMRMFA)
+	}
+
+	access, err := h.accessToken(kid, usr, amr)
+	if err != nil {
+		return err
+	}
+
+	refresh, err := h.token.Create(ctx, usr.ID, amr, refreshTokenTTL)
+	if err != nil {
+		return fmt.Errorf("token.create: userID[%s]: %w", usr.ID, err)
+	}
+
+	return web.Respond(ctx, w, toAppToken(access, refresh), http.StatusOK)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token. The refresh token that was used can't be used again.
+func (h *Handlers) Refresh(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppRefreshToken
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	rt, refresh, err := h.token.Exchange(ctx, app.RefreshToken, refreshTokenTTL)
+	if err != nil {
+		if errors.Is(err, token.ErrInvalidToken) {
+			return auth.NewAuthError(err.Error())
+		}
+		return fmt.Errorf("exchange: %w", err)
+	}
+
+	usr, err := h.user.QueryByID(ctx, rt.UserID)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			return auth.NewAuthError("refresh: user no longer exists")
+		}
+		return fmt.Errorf("querybyid: userID[%s]: %w", rt.UserID, err)
+	}
+
+	if !usr.Enabled {
+		return auth.NewAuthError("refresh: user disabled")
+	}
+
+	access, err := h.accessToken(app.KID, usr, rt.AMR)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppToken(access, refresh), http.StatusOK)
+}
+
+// Logout revokes all the refresh tokens of the authenticated user and the
+// access token used to make the call.
+func (h *Handlers) Logout(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	claims := auth.GetClaims(ctx)
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return auth.NewAuthError("logout: invalid subject in claims: %s", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := h.token.RevokeByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("revokebyuserid: %w", err)
+	}
+
+	if claims.ID != "" && claims.ExpiresAt != nil {
+		if err := h.token.RevokeAccess(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			return fmt.Errorf("revokeaccess: %w", err)
+		}
+
+		// Revoking it on this instance right away must wait for the denylist
+		// entry to commit, the other instances only see it then too.
+		jti, expires := claims.ID, claims.ExpiresAt.Time
+		transaction.OnCommit(ctx, func() { h.auth.Revoke(jti, expires) })
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ForgotPassword emails a token to reset the password of the user with the
+// email in the request. The email is sent in the background so the response
+// is the same whether a user has the email or not, even when sending fails.
+func (h *Handlers) ForgotPassword(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppForgotPassword
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	addr, err := mail.ParseAddress(app.Email)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	h.verify.RequestReset(ctx, *addr)
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ResetPassword changes the password of the user with a token that was sent
+// by ForgotPassword. The refresh tokens of the user are revoked and the
+// account is unlocked.
+func (h *Handlers) ResetPassword(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppResetPassword
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	usr, err := h.verify.ResetPassword(ctx, app.Token, app.Password)
+	if err != nil {
+		if errors.Is(err, verify.ErrInvalidToken) {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("resetpassword: %w", err)
+	}
+
+	// The user proved who they are with the token, so the revocation and the
+	// unlock are recorded as theirs.
+	ctx = audit.SetActor(ctx, usr.ID)
+
+	if err := h.token.RevokeByUserID(ctx, usr.ID); err != nil {
+		return fmt.Errorf("revokebyuserid: %w", err)
+	}
+
+	if _, err := h.user.Unlock(ctx, usr); err != nil {
+		return fmt.Errorf("unlock: userID[%s]: %w", usr.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// VerifyEmail marks the email address of a user as verified with a token
+// that was sent to it.
+func (h *Handlers) VerifyEmail(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppVerifyEmail
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.verify.Verify(ctx, app.Token); err != nil {
+		if errors.Is(err, verify.ErrInvalidToken) {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("verify: %w", err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Unlock removes the lock a user got from too many failed logins.
+func (h *Handlers) Unlock(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	usr, err := mid.GetUser(ctx)
+	if err != nil {
+		return fmt.Errorf("getuser: %w", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	usr, err = h.user.Unlock(ctx, usr)
+	if err != nil {
+		return fmt.Errorf("unlock: userID[%s]: %w", usr.ID, err)
+	}
+
+	return web.Respond(ctx, w, toAppUser(usr), http.StatusOK)
+}
+
+// EnrollMFA generates a TOTP secret for the authenticated user. The secret
+// is not used until the user confirms it with a code.
+func (h *Handlers) EnrollMFA(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	userID, err := uuid.Parse(auth.GetClaims(ctx).Subject)
+	if err != nil {
+		return auth.NewAuthError("enrollmfa: invalid subject in claims: %s", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	usr, err := h.user.QueryByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
+	}
+
+	// The route only requires authentication, so a user disabled after the
+	// token was issued is refused here.
+	if !usr.Enabled {
+		return auth.NewAuthError("enrollmfa: user disabled")
+	}
+
+	enr, err := h.mfa.Enroll(ctx, usr)
+	if err != nil {
+		if errors.Is(err, mfa.ErrAlreadyEnrolled) {
+			return v1.NewRequestError(err, http.StatusConflict)
+		}
+		return fmt.Errorf("enroll: userID[%s]: %w", usr.ID, err)
+	}
+
+	return web.Respond(ctx, w, toAppMFAEnrollment(enr), http.StatusCreated)
+}
+
+// ConfirmMFA enables mfa for the authenticated user with a code from the
+// secret given by EnrollMFA. The response holds the recovery codes, they are
+// only shown once.
+func (h *Handlers) ConfirmMFA(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppConfirmMFA
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(auth.GetClaims(ctx).Subject)
+	if err != nil {
+		return auth.NewAuthError("confirmmfa: invalid subject in claims: %s", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	usr, err := h.user.QueryByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
+	}
+
+	if !usr.Enabled {
+		return auth.NewAuthError("confirmmfa: user disabled")
+	}
+
+	codes, err := h.mfa.Confirm(ctx, userID, app.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, mfa.ErrNotFound):
+			return v1.NewRequestError(err, http.StatusNotFound)
+		case errors.Is(err, mfa.ErrAlreadyEnrolled):
+			return v1.NewRequestError(err, http.StatusConflict)
+		case errors.Is(err, mfa.ErrInvalidCode):
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		default:
+			return fmt.Errorf("confirm: userID[%s]: %w", userID, err)
+		}
+	}
+
+	return web.Respond(ctx, w, AppRecoveryCodes{RecoveryCodes: codes}, http.StatusOK)
+}
+
+// DisableMFA removes the mfa enrollment of a user, an admin can use it for a
+// user who lost their device and their recovery codes.
+func (h *Handlers) DisableMFA(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	usr, err := mid.GetUser(ctx)
+	if err != nil {
+		return fmt.Errorf("getuser: %w", err)
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := h.mfa.Disable(ctx, usr.ID); err != nil {
+		return fmt.Errorf("disable: userID[%s]: %w", usr.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// accessToken generates a signed access token for the user that records the
+// methods the user authenticated with. Every token gets its own jti so it can
+// be revoked before it expires.
+func (h *Handlers) accessToken(kid string, usr user.User, amr []string) (string, error) {
+	now := time.Now().UTC()
+
 	claims := auth.Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			Subject:   usr.ID.String(),
 			Issuer:    "service project",
-			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
-		Roles: usr.Roles,
+		Roles:    usr.Roles,
+		AMR:      amr,
+		TenantID: usr.TenantID.String(),
+	}
+
+	tkn, err := h.auth.GenerateToken(kid, claims)
+	if err != nil {
+		return "", fmt.Errorf("generatetoken: %w", err)
 	}
 
-	token, err := h.auth.GenerateToken(kid, claims)
+	return tkn, nil
+}
+
+// remoteAddress returns the address of the client without the port, failed
+// logins are tracked per address.
+func remoteAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		return fmt.Errorf("generatetoken: %w", err)
+		return r.RemoteAddr
 	}
 
-	return web.Respond(ctx, w, toToken(token), http.StatusOK)
+	return host
 }
@@ -0,0 +1,38 @@
+package usrsummgrp
+
+import (
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/cview/user/summary"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+func parseFilter(r *http.Request) (summary.QueryFilter, error) {
+	const (
+		filterByUserID   = "user_id"
+		filterByUserName = "user_name"
+	)
+
+	values := r.URL.Query()
+
+	var filter summary.QueryFilter
+
+	if userID := values.Get(filterByUserID); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			return summary.QueryFilter{}, validate.NewFieldsError(filterByUserID, err)
+		}
+		filter.WithUserID(id)
+	}
+
+	if userName := values.Get(filterByUserName); userName != "" {
+		filter.WithUserName(userName)
+	}
+
+	if err := filter.Validate(); err != nil {
+		return summary.QueryFilter{}, err
+	}
+
+	return filter, nil
+}
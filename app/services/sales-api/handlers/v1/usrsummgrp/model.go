@@ -0,0 +1,31 @@
+package usrsummgrp
+
+import (
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/cview/user/summary"
+)
+
+// AppSummary represents information about an individual user and their products.
+type AppSummary struct {
+	UserID     string  `json:"userID"`
+	UserName   string  `json:"userName"`
+	TotalCount int     `json:"totalCount"`
+	TotalCost  float64 `json:"totalCost"`
+}
+
+func toAppSummary(smm summary.Summary) AppSummary {
+	return AppSummary{
+		UserID:     smm.UserID.String(),
+		UserName:   smm.UserName,
+		TotalCount: smm.TotalCount,
+		TotalCost:  smm.TotalCost,
+	}
+}
+
+func toAppSummaries(smms []summary.Summary) []AppSummary {
+	items := make([]AppSummary, len(smms))
+	for i, smm := range smms {
+		items[i] = toAppSummary(smm)
+	}
+
+	return items
+}
@@ -0,0 +1,35 @@
+package usrsummgrp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/cview/user/summary"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+)
+
+func parseOrder(r *http.Request) (order.By, error) {
+	const (
+		orderByUserID   = "user_id"
+		orderByUserName = "user_name"
+	)
+
+	var orderByFields = map[string]string{
+		orderByUserID:   summary.OrderByUserID,
+		orderByUserName: summary.OrderByUserName,
+	}
+
+	orderBy, err := order.Parse(r, order.NewBy(orderByUserID, order.ASC))
+	if err != nil {
+		return order.By{}, err
+	}
+
+	if _, exists := orderByFields[orderBy.Field]; !exists {
+		return order.By{}, validate.NewFieldsError(orderBy.Field, errors.New("order field does not exist"))
+	}
+
+	orderBy.Field = orderByFields[orderBy.Field]
+
+	return orderBy, nil
+}
@@ -0,0 +1,54 @@
+// Package usrsummgrp maintains the group of handlers for user summary access.
+package usrsummgrp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/cview/user/summary"
+	paging "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/paging"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+)
+
+// Handlers manages the set of user summary endpoints.
+type Handlers struct {
+	summary *summary.Core
+}
+
+// New constructs a handlers for route access.
+func New(summary *summary.Core) *Handlers {
+	return &Handlers{
+		summary: summary,
+	}
+}
+
+// Query returns a list of user summaries with paging.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	page, err := paging.Parse(r)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter(r)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := parseOrder(r)
+	if err != nil {
+		return err
+	}
+
+	smms, err := h.summary.Query(ctx, filter, orderBy, page.Number, page.RowsPerPage)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	total, err := h.summary.Count(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("count: %w", err)
+	}
+
+	return web.Respond(ctx, w, paging.NewResponse(toAppSummaries(smms), total, page.Number, page.RowsPerPage), http.StatusOK)
+}
@@ -0,0 +1,128 @@
+package webhookgrp
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+// AppWebhook represents information about an individual webhook. The secret
+// is only set in the response that created it.
+type AppWebhook struct {
+	ID          string   `json:"id"`
+	TenantID    string   `json:"tenantID"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret,omitempty"`
+	Events      []string `json:"events"`
+	Enabled     bool     `json:"enabled"`
+	DateCreated string   `json:"dateCreated"`
+	DateUpdated string   `json:"dateUpdated"`
+}
+
+func toAppWebhook(wh webhook.Webhook) AppWebhook {
+	return AppWebhook{
+		ID:          wh.ID.String(),
+		TenantID:    wh.TenantID.String(),
+		URL:         wh.URL,
+		Events:      wh.Events,
+		Enabled:     wh.Enabled,
+		DateCreated: wh.DateCreated.Format(time.RFC3339),
+		DateUpdated: wh.DateUpdated.Format(time.RFC3339),
+	}
+}
+
+func toAppWebhooks(whs []webhook.Webhook) []AppWebhook {
+	items := make([]AppWebhook, len(whs))
+	for i, wh := range whs {
+		items[i] = toAppWebhook(wh)
+	}
+
+	return items
+}
+
+// =============================================================================
+
+// AppDeadLetter represents a delivery of a webhook that failed every attempt.
+type AppDeadLetter struct {
+	ID          string          `json:"id"`
+	EventID     string          `json:"eventID"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"lastError"`
+	DateCreated string          `json:"dateCreated"`
+	DateFailed  string          `json:"dateFailed"`
+}
+
+func toAppDeadLetters(dls []webhook.DeadLetter) []AppDeadLetter {
+	items := make([]AppDeadLetter, len(dls))
+	for i, dl := range dls {
+		items[i] = AppDeadLetter{
+			ID:          dl.ID.String(),
+			EventID:     dl.EventID.String(),
+			Event:       dl.Event,
+			Payload:     dl.Payload,
+			Attempts:    dl.Attempts,
+			LastError:   dl.LastError,
+			DateCreated: dl.DateCreated.Format(time.RFC3339),
+			DateFailed:  dl.DateFailed.Format(time.RFC3339),
+		}
+	}
+
+	return items
+}
+
+// =============================================================================
+
+// AppNewWebhook contains information needed to create a new webhook.
+type AppNewWebhook struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Events []string `json:"events" validate:"required,min=1"`
+}
+
+func toCoreNewWebhook(app AppNewWebhook, tenantID uuid.UUID) webhook.NewWebhook {
+	return webhook.NewWebhook{
+		TenantID: tenantID,
+		URL:      app.URL,
+		Events:   app.Events,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppNewWebhook) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// AppUpdateWebhook contains information needed to update a webhook.
+type AppUpdateWebhook struct {
+	URL     *string  `json:"url" validate:"omitempty,url"`
+	Events  []string `json:"events" validate:"omitempty,min=1"`
+	Enabled *bool    `json:"enabled"`
+}
+
+func toCoreUpdateWebhook(app AppUpdateWebhook) webhook.UpdateWebhook {
+	return webhook.UpdateWebhook{
+		URL:     app.URL,
+		Events:  app.Events,
+		Enabled: app.Enabled,
+	}
+}
+
+// Validate checks the data in the model is considered clean.
+func (app AppUpdateWebhook) Validate() error {
+	if err := validate.Check(app); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	return nil
+}
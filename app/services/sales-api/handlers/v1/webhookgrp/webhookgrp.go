@@ -0,0 +1,184 @@
+// Package webhookgrp maintains the group of handlers for webhook access.
+package webhookgrp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
+	v1 "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/mid"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Handlers manages the set of webhook endpoints.
+type Handlers struct {
+	webhook *webhook.Core
+}
+
+// New constructs a handlers for route access.
+func New(webhook *webhook.Core) *Handlers {
+	return &Handlers{
+		webhook: webhook,
+	}
+}
+
+// executeUnderTransaction constructs a new Handlers value with the core apis
+// using a store transaction that was created via middleware.
+func (h *Handlers) executeUnderTransaction(ctx context.Context) (*Handlers, error) {
+	if tx, ok := transaction.Get(ctx); ok {
+		webhook, err := h.webhook.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		h = &Handlers{
+			webhook: webhook,
+		}
+
+		return h, nil
+	}
+
+	return h, nil
+}
+
+// Create adds a new webhook for the tenant of the caller. The secret the
+// deliveries are signed with is only returned in this response.
+func (h *Handlers) Create(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppNewWebhook
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	tenantID, err := uuid.Parse(auth.GetClaims(ctx).TenantID)
+	if err != nil {
+		return auth.NewAuthError("create: no tenant in claims")
+	}
+
+	h, err = h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	wh, err := h.webhook.Create(ctx, toCoreNewWebhook(app, tenantID))
+	if err != nil {
+		if errors.Is(err, webhook.ErrUnknownEvent) {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("create: app[%+v]: %w", app, err)
+	}
+
+	// The secret is only shown once the webhook is created.
+	resp := toAppWebhook(wh)
+	resp.Secret = wh.Secret
+
+	return web.Respond(ctx, w, resp, http.StatusCreated)
+}
+
+// Update updates a webhook in the system.
+func (h *Handlers) Update(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var app AppUpdateWebhook
+	if err := web.Decode(r, &app); err != nil {
+		return err
+	}
+
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	wh, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	wh, err = h.webhook.Update(ctx, wh, toCoreUpdateWebhook(app))
+	if err != nil {
+		if errors.Is(err, webhook.ErrUnknownEvent) {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+		return fmt.Errorf("update: webhookID[%s] app[%+v]: %w", wh.ID, app, err)
+	}
+
+	return web.Respond(ctx, w, toAppWebhook(wh), http.StatusOK)
+}
+
+// Delete removes a webhook from the system.
+func (h *Handlers) Delete(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h, err := h.executeUnderTransaction(ctx)
+	if err != nil {
+		return err
+	}
+
+	wh, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := h.webhook.Delete(ctx, wh); err != nil {
+		return fmt.Errorf("delete: webhookID[%s]: %w", wh.ID, err)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// Query returns the webhooks. Admins only see the ones of their tenant.
+func (h *Handlers) Query(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	whs, err := h.webhook.QueryAll(ctx)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	return web.Respond(ctx, w, toAppWebhooks(whs), http.StatusOK)
+}
+
+// QueryByID returns a webhook by its ID.
+func (h *Handlers) QueryByID(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	wh, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toAppWebhook(wh), http.StatusOK)
+}
+
+// QueryDeadLetters returns the deliveries of a webhook that failed every
+// attempt.
+func (h *Handlers) QueryDeadLetters(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	wh, err := h.queryByID(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	dls, err := h.webhook.QueryDeadLetters(ctx, wh.ID)
+	if err != nil {
+		return fmt.Errorf("querydeadletters: webhookID[%s]: %w", wh.ID, err)
+	}
+
+	return web.Respond(ctx, w, toAppDeadLetters(dls), http.StatusOK)
+}
+
+// queryByID loads the webhook specified in the path.
+func (h *Handlers) queryByID(ctx context.Context, r *http.Request) (webhook.Webhook, error) {
+	webhookID, err := uuid.Parse(web.Param(r, "webhook_id"))
+	if err != nil {
+		return webhook.Webhook{}, v1.NewRequestError(mid.ErrInvalidID, http.StatusBadRequest)
+	}
+
+	wh, err := h.webhook.QueryByID(ctx, webhookID)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhook.ErrNotFound):
+			return webhook.Webhook{}, v1.NewRequestError(err, http.StatusNotFound)
+		default:
+			return webhook.Webhook{}, fmt.Errorf("querybyid: webhookID[%s]: %w", webhookID, err)
+		}
+	}
+
+	return wh, nil
+}
@@ -11,13 +11,30 @@ import (
 
 	"errors"
 	"net/http"
+	"net/mail"
 
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/app/services/sales-api/handlers/v1/jwksgrp"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey/stores/apikeydb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/change"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/change/stores/changedb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role/stores/roledb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token/stores/tokendb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user/stores/userdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/verify"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook/stores/webhookdb"
 	database "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/debug"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/keystore"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/logger"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/mailer"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/vault"
 	"github.com/ardanlabs/conf/v3"
 	"go.uber.org/zap"
 )
@@ -66,18 +83,55 @@ func run(log *zap.SugaredLogger, ctx context.Context) error {
 			DebugHost       string        `conf:"default:0.0.0.0:4000"`
 		}
 		DB struct {
-			User         string `conf:"default:postgres"`
-			Password     string `conf:"default:postgres,mask"`
-			Host         string `conf:"default:database-service.sales-system.svc.cluster.local"`
-			Name         string `conf:"default:postgres"`
-			MaxIdleConns int    `conf:"default:2"`
-			MaxOpenConns int    `conf:"default:0"`
-			DisableTLS   bool   `conf:"default:true"`
+			User         string        `conf:"default:postgres"`
+			Password     string        `conf:"default:postgres,mask"`
+			Host         string        `conf:"default:database-service.sales-system.svc.cluster.local"`
+			Name         string        `conf:"default:postgres"`
+			MaxIdleConns int           `conf:"default:2"`
+			MaxOpenConns int           `conf:"default:0"`
+			DisableTLS   bool          `conf:"default:true"`
+			ListenRetry  time.Duration `conf:"default:5s,help:wait before listening for the changes again after the connection failed"`
 		}
 		Auth struct {
-			KeysFolder string `conf:"default:zarf/keys/"`
-			ActiveKID  string `conf:"default:private"`
-			Issuer     string `conf:"default:service project"`
+			KeysFolder                string        `conf:"default:zarf/keys/"`
+			ActiveKID                 string        `conf:"default:private"`
+			Issuer                    string        `conf:"default:service project"`
+			JWKSURL                   string        `conf:"help:url of a jwks document to verify tokens with instead of the keys folder"`
+			VaultAddress              string        `conf:"help:address of a vault server to read the keys from instead of the keys folder"`
+			VaultToken                string        `conf:"mask"`
+			VaultMountPath            string        `conf:"default:secret"`
+			PolicyPath                string        `conf:"help:directory or .tar.gz bundle of rego policies loaded on top of the embedded ones"`
+			PolicyPoll                time.Duration `conf:"default:30s"`
+			RolePoll                  time.Duration `conf:"default:30s"`
+			KeysPoll                  time.Duration `conf:"default:30s"`
+			KeyCacheTTL               time.Duration `conf:"default:5m"`
+			LockoutMaxFailures        int           `conf:"default:5"`
+			LockoutMaxAddressFailures int           `conf:"default:20"`
+			LockoutWindow             time.Duration `conf:"default:15m"`
+			LockoutDuration           time.Duration `conf:"default:15m"`
+			PasswordMinLength         int           `conf:"default:8"`
+			PasswordMinClasses        int           `conf:"default:2,help:how many of lower case/upper case/digits/symbols a password must mix"`
+			PasswordBcryptCost        int           `conf:"default:10,help:hashes with a lower cost are upgraded on the next login"`
+			TokenSecret               string        `conf:"default:change-me,mask,help:signs the emailed tokens and encrypts the mfa secrets (must be set and changing it breaks the existing mfa enrollments)"`
+			MFAIssuer                 string        `conf:"default:Sales API,help:name of the service shown in authenticator apps"`
+			AdminMFA                  bool          `conf:"help:require a token issued with mfa for the admin-only routes"`
+			SweepInterval             time.Duration `conf:"default:1h,help:how often expired revoked tokens and old failed logins are removed"`
+		}
+		Mail struct {
+			Host       string `conf:"help:smtp server to send email through (email is only logged when empty)"`
+			Port       int    `conf:"default:587"`
+			Username   string
+			Password   string `conf:"mask"`
+			From       string `conf:"default:Sales <noreply@example.com>"`
+			DisableTLS bool
+		}
+		Webhook struct {
+			Interval    time.Duration `conf:"default:5s"`
+			BatchSize   int           `conf:"default:50"`
+			MaxAttempts int           `conf:"default:8,help:failed attempts before a delivery is moved to the dead letters"`
+			MinBackoff  time.Duration `conf:"default:30s"`
+			MaxBackoff  time.Duration `conf:"default:1h"`
+			Timeout     time.Duration `conf:"default:10s"`
 		}
 	}{
 		Version: conf.Version{
@@ -96,6 +150,12 @@ func run(log *zap.SugaredLogger, ctx context.Context) error {
 		return fmt.Errorf("parsing config: %w", err)
 	}
 
+	// The token secret signs the emailed tokens and encrypts the mfa
+	// secrets, the default is only a placeholder that everyone knows.
+	if cfg.Auth.TokenSecret == "change-me" {
+		return errors.New("auth token secret is not set: set SALES_AUTH_TOKEN_SECRET")
+	}
+
 	// -------------------------------------------------------------------------
 	// App Starting
 
@@ -131,16 +191,67 @@ func run(log *zap.SugaredLogger, ctx context.Context) error {
 		db.Close()
 	}()
 
-	// Simple keystore.
-	ks, err := keystore.NewFS(os.DirFS(cfg.Auth.KeysFolder))
-	if err != nil {
-		return fmt.Errorf("reading keys: %w", err)
+	// Simple keystore, unless we were pointed at the jwks document of
+	// another instance of the service or at a vault server. Vault doesn't
+	// provide a key set, so no jwks document is published in that case.
+	var keyLookup auth.KeyLookup
+	var keySet jwksgrp.KeySet
+	var ks *keystore.KeyStore
+
+	switch {
+	case cfg.Auth.VaultAddress != "":
+		log.Infow("startup", "status", "using vault for keys", "address", cfg.Auth.VaultAddress, "mount", cfg.Auth.VaultMountPath)
+
+		vlt, err := vault.New(vault.Config{
+			Address:   cfg.Auth.VaultAddress,
+			Token:     cfg.Auth.VaultToken,
+			MountPath: cfg.Auth.VaultMountPath,
+			CacheTTL:  cfg.Auth.KeyCacheTTL,
+		})
+		if err != nil {
+			return fmt.Errorf("constructing vault: %w", err)
+		}
+		keyLookup = vlt
+
+	case cfg.Auth.JWKSURL != "":
+		log.Infow("startup", "status", "fetching jwks", "url", cfg.Auth.JWKSURL)
+
+		js, err := fetchJWKS(ctx, cfg.Auth.JWKSURL)
+		if err != nil {
+			return fmt.Errorf("fetching jwks: %w", err)
+		}
+		keyLookup = js
+		keySet = js
+
+	default:
+		ks, err = keystore.NewFS(os.DirFS(cfg.Auth.KeysFolder))
+		if err != nil {
+			return fmt.Errorf("reading keys: %w", err)
+		}
+		keyLookup = ks
+		keySet = ks
+	}
+
+	lockout := user.Lockout{
+		MaxFailures:        cfg.Auth.LockoutMaxFailures,
+		MaxAddressFailures: cfg.Auth.LockoutMaxAddressFailures,
+		Window:             cfg.Auth.LockoutWindow,
+		Duration:           cfg.Auth.LockoutDuration,
 	}
 
+	usrCore := user.NewCore(userdb.NewStore(log, db), user.WithLockout(lockout))
+	tknCore := token.NewCore(tokendb.NewStore(log, db))
+
 	authCfg := auth.Config{
-		Log:       log,
-		KeyLookup: ks,
-		Issuer:    cfg.Auth.Issuer,
+		Log:          log,
+		KeyLookup:    keyLookup,
+		Issuer:       cfg.Auth.Issuer,
+		Denylist:     tknCore,
+		PolicyPath:   cfg.Auth.PolicyPath,
+		KeyCacheTTL:  cfg.Auth.KeyCacheTTL,
+		APIKeys:      apikey.NewCore(usrCore, apikeydb.NewStore(log, db)),
+		EnabledCheck: usrCore,
+		Roles:        role.NewCore(roledb.NewStore(log, db)),
 	}
 	fmt.Println(authCfg)
 	auth, err := auth.New(authCfg)
@@ -148,6 +259,114 @@ func run(log *zap.SugaredLogger, ctx context.Context) error {
 		return fmt.Errorf("constructing auth: %w", err)
 	}
 
+	if err := auth.ReloadRoles(ctx); err != nil {
+		return fmt.Errorf("loading roles: %w", err)
+	}
+
+	// The policies are reloaded when the files change, until the service
+	// is shutting down.
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+
+	go auth.WatchPolicies(watchCtx, cfg.Auth.PolicyPoll)
+
+	// Roles changed through another instance of the service are picked up
+	// the same way.
+	go auth.WatchRoles(watchCtx, cfg.Auth.RolePoll)
+
+	// The keys folder is watched the same way so keys can be rotated without
+	// a restart. Removed keys are evicted from the auth cache right away.
+	if ks != nil {
+		go ks.Watch(watchCtx, cfg.Auth.KeysPoll, func(removed []string, err error) {
+			if err != nil {
+				log.Errorw("keystore", "status", "key reload failed, keeping previous keys", "folder", cfg.Auth.KeysFolder, "ERROR", err)
+				return
+			}
+
+			if len(removed) > 0 {
+				log.Infow("keystore", "status", "keys removed", "kids", removed)
+				auth.EvictKeys(removed...)
+			}
+		})
+	}
+
+	// Revoked access tokens and failed logins are only needed for so long,
+	// the ones past that are removed on an interval.
+	go func() {
+		ticker := time.NewTicker(cfg.Auth.SweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-watchCtx.Done():
+				return
+
+			case <-ticker.C:
+				if err := tknCore.PurgeRevokedAccess(watchCtx); err != nil {
+					log.Errorw("sweep", "status", "purging revoked access tokens failed", "ERROR", err)
+				}
+
+				if err := usrCore.PurgeLoginFailures(watchCtx); err != nil {
+					log.Errorw("sweep", "status", "purging failed logins failed", "ERROR", err)
+				}
+			}
+		}
+	}()
+
+	// -------------------------------------------------------------------------
+	// Webhook Support
+
+	// The events written to the outbox are delivered in the background until
+	// the service is shutting down.
+	dispatcher := webhook.NewDispatcher(log, webhook.NewCore(webhookdb.NewStore(log, db)), webhook.DispatchConfig{
+		Interval:    cfg.Webhook.Interval,
+		BatchSize:   cfg.Webhook.BatchSize,
+		MaxAttempts: cfg.Webhook.MaxAttempts,
+		MinBackoff:  cfg.Webhook.MinBackoff,
+		MaxBackoff:  cfg.Webhook.MaxBackoff,
+		Timeout:     cfg.Webhook.Timeout,
+	})
+
+	go dispatcher.Run(watchCtx)
+
+	// -------------------------------------------------------------------------
+	// Change Feed Support
+
+	// The changes the database notifies are passed on to the event streams.
+	// The feed is stopped as soon as the server starts shutting down, which
+	// ends the streams so they don't hold up the shutdown.
+	chgCore := change.NewCore(log, changedb.NewStore(log, db))
+
+	feedCtx, stopFeed := context.WithCancel(ctx)
+	defer stopFeed()
+
+	go chgCore.Run(feedCtx, cfg.DB.ListenRetry)
+
+	// -------------------------------------------------------------------------
+	// Mail Support
+
+	// The emails are only logged unless we were given an smtp server.
+	var mlr verify.Mailer = mailer.NewLog(log)
+
+	if cfg.Mail.Host != "" {
+		from, err := mail.ParseAddress(cfg.Mail.From)
+		if err != nil {
+			return fmt.Errorf("parsing mail from: %w", err)
+		}
+
+		mlr, err = mailer.NewSMTP(mailer.Config{
+			Host:       cfg.Mail.Host,
+			Port:       cfg.Mail.Port,
+			Username:   cfg.Mail.Username,
+			Password:   cfg.Mail.Password,
+			From:       *from,
+			DisableTLS: cfg.Mail.DisableTLS,
+		})
+		if err != nil {
+			return fmt.Errorf("constructing mailer: %w", err)
+		}
+	}
+
 	// -------------------------------------------------------------------------
 	// Start Debug Service
 	// This creats a go that blocks on a listening serve call on whatever the IP for the debug host is
@@ -172,6 +391,18 @@ func run(log *zap.SugaredLogger, ctx context.Context) error {
 		Log:      log,
 		Auth:     auth,
 		DB:       db,
+		KeySet:   keySet,
+		Lockout:  lockout,
+		PasswordPolicy: user.PasswordPolicy{
+			MinLength:  cfg.Auth.PasswordMinLength,
+			MinClasses: cfg.Auth.PasswordMinClasses,
+			Cost:       cfg.Auth.PasswordBcryptCost,
+		},
+		Mailer:    mlr,
+		Secret:    []byte(cfg.Auth.TokenSecret),
+		MFAIssuer: cfg.Auth.MFAIssuer,
+		AdminMFA:  cfg.Auth.AdminMFA,
+		Changes:   chgCore,
 	})
 
 	api := http.Server{
@@ -182,6 +413,7 @@ func run(log *zap.SugaredLogger, ctx context.Context) error {
 		IdleTimeout:  cfg.Web.IdleTimeout,
 		ErrorLog:     zap.NewStdLog(log.Desugar()),
 	}
+	api.RegisterOnShutdown(stopFeed)
 
 	go func() {
 		log.Infow("startup", "status", "api router started", "host", api.Addr)
@@ -211,3 +443,27 @@ func run(log *zap.SugaredLogger, ctx context.Context) error {
 
 	return nil
 }
+
+// fetchJWKS retrieves the jwks document published by another instance of the
+// service so tokens can be verified without sharing the PEM files.
+func fetchJWKS(ctx context.Context, url string) (*keystore.JWKSStore, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return keystore.NewJWKS(resp.Body)
+}
@@ -0,0 +1,230 @@
+// Package apikey provides the core business API for the api keys users
+// create to call the service without minting a JWT.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("api key not found")
+	ErrInvalidKey   = errors.New("api key is not valid")
+	ErrInvalidRoles = errors.New("api key roles must be held by the user")
+	ErrUserDisabled = errors.New("user disabled")
+)
+
+// keyPrefix marks the api keys generated by the service, so they are easy
+// to spot in configuration files and logs.
+const keyPrefix = "sk_"
+
+// =============================================================================
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, key APIKey) error
+	Revoke(ctx context.Context, key APIKey) error
+	QueryByID(ctx context.Context, keyID uuid.UUID) (APIKey, error)
+	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	QueryByHash(ctx context.Context, hash string) (APIKey, error)
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+}
+
+// =============================================================================
+
+// Core manages the set of APIs for api key access.
+type Core struct {
+	usrCore  *user.Core
+	storer   Storer
+	delegate *delegate.Delegate
+}
+
+// NewCore constructs a core for api key api access.
+func NewCore(usrCore *user.Core, storer Storer, options ...Option) *Core {
+	c := Core{
+		usrCore: usrCore,
+		storer:  storer,
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	usrCore, err := c.usrCore.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		usrCore:  usrCore,
+		storer:   trS,
+		delegate: c.delegate,
+	}
+
+	return c, nil
+}
+
+// Create adds a new api key for a user. The returned string is the key for
+// the client, it can't be recovered later.
+func (c *Core) Create(ctx context.Context, nak NewAPIKey) (APIKey, string, error) {
+	usr, err := c.usrCore.QueryByID(ctx, nak.UserID)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("user.querybyid: %s: %w", nak.UserID, err)
+	}
+
+	if !usr.Enabled {
+		return APIKey{}, "", ErrUserDisabled
+	}
+
+	if len(nak.Roles) == 0 || len(intersect(nak.Roles, usr.Roles)) != len(nak.Roles) {
+		return APIKey{}, "", ErrInvalidRoles
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return APIKey{}, "", fmt.Errorf("generating key: %w", err)
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	ak := APIKey{
+		ID:          uuid.New(),
+		UserID:      nak.UserID,
+		TenantID:    usr.TenantID,
+		Name:        nak.Name,
+		Prefix:      key[:len(keyPrefix)+6],
+		Hash:        hash(key),
+		Roles:       nak.Roles,
+		DateCreated: time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, ak); err != nil {
+		return APIKey{}, "", fmt.Errorf("create: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionCreatedData(ak)); err != nil {
+		return APIKey{}, "", fmt.Errorf("create: %w", err)
+	}
+
+	return ak, key, nil
+}
+
+// Revoke revokes the specified api key. Revoking a key twice is not an
+// error.
+func (c *Core) Revoke(ctx context.Context, ak APIKey) error {
+	if !ak.DateRevoked.IsZero() {
+		return nil
+	}
+
+	before := ak
+	ak.DateRevoked = time.Now()
+
+	if err := c.storer.Revoke(ctx, ak); err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionRevokedData(before, ak)); err != nil {
+		return fmt.Errorf("revoke: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByID finds the api key by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, keyID uuid.UUID) (APIKey, error) {
+	ak, err := c.storer.QueryByID(ctx, keyID)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("query: keyID[%s]: %w", keyID, err)
+	}
+
+	return ak, nil
+}
+
+// QueryByUserID finds the api keys of the specified user, including the
+// revoked ones.
+func (c *Core) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error) {
+	keys, err := c.storer.QueryByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query: userID[%s]: %w", userID, err)
+	}
+
+	return keys, nil
+}
+
+// Authenticate finds the api key and verifies it can still be used. The
+// roles of the returned key are limited to the roles the user holds now, so
+// taking a role away from a user also takes it away from their keys.
+func (c *Core) Authenticate(ctx context.Context, key string) (APIKey, error) {
+	ak, err := c.storer.QueryByHash(ctx, hash(key))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return APIKey{}, ErrInvalidKey
+		}
+		return APIKey{}, fmt.Errorf("querybyhash: %w", err)
+	}
+
+	if !ak.DateRevoked.IsZero() {
+		return APIKey{}, ErrInvalidKey
+	}
+
+	usr, err := c.usrCore.QueryByID(ctx, ak.UserID)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("user.querybyid: %s: %w", ak.UserID, err)
+	}
+
+	if !usr.Enabled {
+		return APIKey{}, ErrUserDisabled
+	}
+
+	ak.Roles = intersect(ak.Roles, usr.Roles)
+	ak.TenantID = usr.TenantID
+
+	return ak, nil
+}
+
+// =============================================================================
+
+// hash returns the value stored for an api key. The keys are random so a
+// fast hash is enough, there is nothing to brute force.
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// intersect returns the roles that are in both sets.
+func intersect(roles []user.Role, held []user.Role) []user.Role {
+	var set []user.Role
+	for _, role := range roles {
+		for _, h := range held {
+			if role.Equal(h) {
+				set = append(set, role)
+				break
+			}
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,117 @@
+package apikey_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+	"github.com/google/go-cmp/cmp"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_APIKey(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	nak := apikey.NewAPIKey{
+		UserID: usr.ID,
+		Name:   "ci",
+		Roles:  []user.Role{user.RoleAdmin},
+	}
+
+	if _, _, err := api.APIKey.Create(ctx, nak); !errors.Is(err, apikey.ErrInvalidRoles) {
+		t.Fatalf("Should NOT be able to create a key with a role the user doesn't hold : %s.", err)
+	}
+
+	nak.Roles = []user.Role{user.RoleUser}
+
+	ak, key, err := api.APIKey.Create(ctx, nak)
+	if err != nil {
+		t.Fatalf("Should be able to create an api key : %s.", err)
+	}
+
+	keys, err := api.APIKey.QueryByUserID(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("Should be able to query the api keys of the user : %s.", err)
+	}
+
+	if len(keys) != 1 || keys[0].ID != ak.ID {
+		t.Fatalf("Should get back the api key that was created : %+v", keys)
+	}
+
+	// -------------------------------------------------------------------------
+
+	claims, err := test.Auth.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		t.Fatalf("Should be able to authenticate with the api key : %s.", err)
+	}
+
+	if claims.Subject != usr.ID.String() {
+		t.Logf("got: %v", claims.Subject)
+		t.Logf("exp: %v", usr.ID)
+		t.Errorf("Should get back the user of the api key")
+	}
+
+	if diff := cmp.Diff(claims.Roles, nak.Roles); diff != "" {
+		t.Errorf("Should get back the roles of the api key. diff:\n%s", diff)
+	}
+
+	if _, err := test.Auth.AuthenticateAPIKey(ctx, key+"x"); err == nil {
+		t.Fatalf("Should NOT be able to authenticate with an unknown api key")
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := api.APIKey.Revoke(ctx, ak); err != nil {
+		t.Fatalf("Should be able to revoke the api key : %s.", err)
+	}
+
+	if _, err := api.APIKey.Authenticate(ctx, key); !errors.Is(err, apikey.ErrInvalidKey) {
+		t.Fatalf("Should NOT be able to authenticate with a revoked api key : %s.", err)
+	}
+}
@@ -0,0 +1,72 @@
+package apikey
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// DomainName represents the name of this domain for delegate events.
+const DomainName = "apikey"
+
+// Set of delegate actions the api key domain publishes.
+const (
+	// ActionCreated is published once an api key is stored.
+	ActionCreated = "created"
+
+	// ActionRevoked is published once an api key is revoked.
+	ActionRevoked = "revoked"
+)
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithDelegate sets the delegate the events of the api key domain are
+// published to. Without it the events go nowhere.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// ActionParms represents the parameters for the actions of the api key
+// domain. Only the IDs are marshaled, Before and After are the api key
+// before and after the change for the handlers in the service.
+type ActionParms struct {
+	KeyID    uuid.UUID `json:"keyID"`
+	UserID   uuid.UUID `json:"userID"`
+	TenantID uuid.UUID `json:"tenantID"`
+	Before   *APIKey   `json:"-"`
+	After    *APIKey   `json:"-"`
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(ak APIKey) delegate.Data {
+	return actionData(ActionCreated, nil, &ak)
+}
+
+// ActionRevokedData constructs the data for the revoked action.
+func ActionRevokedData(before APIKey, ak APIKey) delegate.Data {
+	return actionData(ActionRevoked, &before, &ak)
+}
+
+// ParseActionParms returns the parameters of an action of the api key
+// domain.
+func ParseActionParms(data delegate.Data) (ActionParms, error) {
+	params, ok := data.Params.(ActionParms)
+	if !ok {
+		return ActionParms{}, fmt.Errorf("unexpected params type %T for %s.%s", data.Params, data.Domain, data.Action)
+	}
+
+	return params, nil
+}
+
+func actionData(action string, before *APIKey, after *APIKey) delegate.Data {
+	return delegate.Data{
+		Domain: DomainName,
+		Action: action,
+		Params: ActionParms{KeyID: after.ID, UserID: after.UserID, TenantID: after.TenantID, Before: before, After: after},
+	}
+}
@@ -0,0 +1,32 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/google/uuid"
+)
+
+// APIKey represents a named key a user created to call the service without
+// a JWT. Only the hash of the key is kept, the prefix helps the user tell
+// their keys apart. TenantID is the tenant of the user, it is only set by
+// Create and Authenticate.
+type APIKey struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	TenantID    uuid.UUID
+	Name        string
+	Prefix      string
+	Hash        string
+	Roles       []user.Role
+	DateCreated time.Time
+	DateRevoked time.Time
+}
+
+// NewAPIKey contains information needed to create a new api key. The roles
+// scope the key and must be a subset of the roles of the user.
+type NewAPIKey struct {
+	UserID uuid.UUID
+	Name   string
+	Roles  []user.Role
+}
@@ -0,0 +1,157 @@
+// Package apikeydb contains api key related CRUD functionality.
+package apikeydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for api key database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (apikey.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new api key into the database.
+func (s *Store) Create(ctx context.Context, ak apikey.APIKey) error {
+	const q = `
+	INSERT INTO api_keys
+		(key_id, user_id, name, prefix, key_hash, roles, date_created, date_revoked)
+	VALUES
+		(:key_id, :user_id, :name, :prefix, :key_hash, :roles, :date_created, :date_revoked)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBAPIKey(ak)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke marks the api key as revoked.
+func (s *Store) Revoke(ctx context.Context, ak apikey.APIKey) error {
+	const q = `
+	UPDATE
+		api_keys
+	SET
+		"date_revoked" = :date_revoked
+	WHERE
+		key_id = :key_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBAPIKey(ak)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByID finds the api key identified by a given ID.
+func (s *Store) QueryByID(ctx context.Context, keyID uuid.UUID) (apikey.APIKey, error) {
+	data := struct {
+		ID string `db:"key_id"`
+	}{
+		ID: keyID.String(),
+	}
+
+	const q = `
+	SELECT
+		key_id, user_id, name, prefix, key_hash, roles, date_created, date_revoked
+	FROM
+		api_keys
+	WHERE
+		key_id = :key_id`
+
+	var dbAK dbAPIKey
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbAK); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", apikey.ErrNotFound)
+		}
+		return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreAPIKey(dbAK)
+}
+
+// QueryByUserID finds the api keys of a given user.
+func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]apikey.APIKey, error) {
+	data := struct {
+		ID string `db:"user_id"`
+	}{
+		ID: userID.String(),
+	}
+
+	const q = `
+	SELECT
+		key_id, user_id, name, prefix, key_hash, roles, date_created, date_revoked
+	FROM
+		api_keys
+	WHERE
+		user_id = :user_id
+	ORDER BY
+		date_created`
+
+	var dbAKs []dbAPIKey
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbAKs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreAPIKeySlice(dbAKs)
+}
+
+// QueryByHash finds the api key with the specified hash.
+func (s *Store) QueryByHash(ctx context.Context, hash string) (apikey.APIKey, error) {
+	data := struct {
+		Hash string `db:"key_hash"`
+	}{
+		Hash: hash,
+	}
+
+	const q = `
+	SELECT
+		key_id, user_id, name, prefix, key_hash, roles, date_created, date_revoked
+	FROM
+		api_keys
+	WHERE
+		key_hash = :key_hash`
+
+	var dbAK dbAPIKey
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbAK); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", apikey.ErrNotFound)
+		}
+		return apikey.APIKey{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreAPIKey(dbAK)
+}
@@ -0,0 +1,85 @@
+package apikeydb
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/dbarray"
+	"github.com/google/uuid"
+)
+
+// dbAPIKey represents an individual api key.
+type dbAPIKey struct {
+	ID          uuid.UUID      `db:"key_id"`
+	UserID      uuid.UUID      `db:"user_id"`
+	Name        string         `db:"name"`
+	Prefix      string         `db:"prefix"`
+	Hash        string         `db:"key_hash"`
+	Roles       dbarray.String `db:"roles"`
+	DateCreated time.Time      `db:"date_created"`
+	DateRevoked sql.NullTime   `db:"date_revoked"`
+}
+
+func toDBAPIKey(ak apikey.APIKey) dbAPIKey {
+	roles := make([]string, len(ak.Roles))
+	for i, role := range ak.Roles {
+		roles[i] = role.Name()
+	}
+
+	return dbAPIKey{
+		ID:          ak.ID,
+		UserID:      ak.UserID,
+		Name:        ak.Name,
+		Prefix:      ak.Prefix,
+		Hash:        ak.Hash,
+		Roles:       roles,
+		DateCreated: ak.DateCreated.UTC(),
+		DateRevoked: sql.NullTime{
+			Time:  ak.DateRevoked.UTC(),
+			Valid: !ak.DateRevoked.IsZero(),
+		},
+	}
+}
+
+func toCoreAPIKey(dbAK dbAPIKey) (apikey.APIKey, error) {
+	roles := make([]user.Role, len(dbAK.Roles))
+	for i, value := range dbAK.Roles {
+		var err error
+		roles[i], err = user.ParseRole(value)
+		if err != nil {
+			return apikey.APIKey{}, fmt.Errorf("parse role: %w", err)
+		}
+	}
+
+	ak := apikey.APIKey{
+		ID:          dbAK.ID,
+		UserID:      dbAK.UserID,
+		Name:        dbAK.Name,
+		Prefix:      dbAK.Prefix,
+		Hash:        dbAK.Hash,
+		Roles:       roles,
+		DateCreated: dbAK.DateCreated.In(time.Local),
+	}
+
+	if dbAK.DateRevoked.Valid {
+		ak.DateRevoked = dbAK.DateRevoked.Time.In(time.Local)
+	}
+
+	return ak, nil
+}
+
+func toCoreAPIKeySlice(dbAKs []dbAPIKey) ([]apikey.APIKey, error) {
+	keys := make([]apikey.APIKey, len(dbAKs))
+	for i, dbAK := range dbAKs {
+		var err error
+		keys[i], err = toCoreAPIKey(dbAK)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
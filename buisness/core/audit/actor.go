@@ -0,0 +1,24 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type ctxKey int
+
+const actorKey ctxKey = 1
+
+// SetActor records the user as the one making the changes for the context.
+func SetActor(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, actorKey, userID)
+}
+
+// GetActor returns the user making the changes for the context. It returns
+// false when no user is set, which is the case for the calls the system
+// makes on its own.
+func GetActor(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(actorKey).(uuid.UUID)
+	return userID, ok
+}
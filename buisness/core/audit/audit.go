@@ -0,0 +1,121 @@
+// Package audit provides the core business API for recording the changes
+// made to the system, who made them and what changed.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"github.com/google/uuid"
+)
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, aud Audit) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Audit, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+}
+
+// =============================================================================
+
+// Core manages the set of APIs for audit access.
+type Core struct {
+	storer   Storer
+	delegate *delegate.Delegate
+}
+
+// NewCore constructs a core for audit api access. With a delegate the
+// changes the other domains publish are recorded, in the transaction of the
+// change.
+func NewCore(storer Storer, options ...Option) *Core {
+	c := Core{
+		storer: storer,
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	c.register()
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls. A change and its record
+// should be written in the same transaction.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		storer:   trS,
+		delegate: c.delegate,
+	}
+
+	return c, nil
+}
+
+// Record adds a record of a change. The actor is taken from the context
+// and so is the trace id of the request, so the record can be matched with
+// the logs. The entities of a context limited to a tenant can only be in
+// that tenant, so it is the tenant of a record that doesn't name one.
+func (c *Core) Record(ctx context.Context, na NewAudit) error {
+	before, after, err := diff(na.Before, na.After)
+	if err != nil {
+		return fmt.Errorf("diff: %w", err)
+	}
+
+	aud := Audit{
+		ID:          uuid.New(),
+		TenantID:    na.TenantID,
+		Action:      na.Action,
+		Entity:      na.Entity,
+		EntityID:    na.EntityID,
+		Before:      before,
+		After:       after,
+		TraceID:     web.GetTraceID(ctx),
+		DateCreated: time.Now(),
+	}
+
+	if aud.TenantID == (uuid.UUID{}) {
+		if tenantID, ok := tenant.GetScope(ctx); ok {
+			aud.TenantID = tenantID
+		}
+	}
+
+	if actorID, ok := GetActor(ctx); ok {
+		aud.ActorID = actorID
+	}
+
+	if err := c.storer.Create(ctx, aud); err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing records.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Audit, error) {
+	auds, err := c.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return auds, nil
+}
+
+// Count returns the total number of records.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	return c.storer.Count(ctx, filter)
+}
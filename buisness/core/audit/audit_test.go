@@ -0,0 +1,234 @@
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Audit(t *testing.T) {
+	t.Run("record", record)
+	t.Run("delegate", delegated)
+}
+
+// =============================================================================
+
+type widget struct {
+	Name string `json:"name"`
+	Cost int    `json:"cost"`
+}
+
+func record(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	actorID := uuid.New()
+	tenantID := uuid.New()
+	entityID := uuid.NewString()
+
+	ctx = audit.SetActor(ctx, actorID)
+
+	// -------------------------------------------------------------------------
+
+	before := widget{Name: "Comic Books", Cost: 10}
+	after := widget{Name: "Comic Books", Cost: 15}
+
+	// The create doesn't name the tenant of the widget, it is taken from the
+	// tenant the context is limited to.
+	na := audit.NewAudit{
+		Action:   audit.ActionCreate,
+		Entity:   "widget",
+		EntityID: entityID,
+		After:    before,
+	}
+
+	if err := api.Audit.Record(tenant.SetScope(ctx, tenantID), na); err != nil {
+		t.Fatalf("Should be able to record a create : %s.", err)
+	}
+
+	na = audit.NewAudit{
+		TenantID: tenantID,
+		Action:   audit.ActionUpdate,
+		Entity:   "widget",
+		EntityID: entityID,
+		Before:   before,
+		After:    after,
+	}
+
+	if err := api.Audit.Record(ctx, na); err != nil {
+		t.Fatalf("Should be able to record an update : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	var filter audit.QueryFilter
+	filter.WithEntity("widget")
+	filter.WithEntityID(entityID)
+	filter.WithActorID(actorID)
+
+	auds, err := api.Audit.Query(ctx, filter, audit.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query the records : %s.", err)
+	}
+
+	if len(auds) != 2 {
+		t.Fatalf("Should get both records, got %d", len(auds))
+	}
+
+	upd := auds[0]
+
+	if upd.Action != audit.ActionUpdate || upd.ActorID != actorID || upd.TenantID != tenantID {
+		t.Logf("got: %+v", upd)
+		t.Errorf("Should get the update first with the actor of the context and the tenant of the widget")
+	}
+
+	if diff := cmp.Diff(map[string]any{"cost": float64(10)}, upd.Before); diff != "" {
+		t.Errorf("Should only record the fields that changed before, diff:\n%s", diff)
+	}
+
+	if diff := cmp.Diff(map[string]any{"cost": float64(15)}, upd.After); diff != "" {
+		t.Errorf("Should only record the fields that changed after, diff:\n%s", diff)
+	}
+
+	if auds[1].Before != nil || len(auds[1].After) != 2 {
+		t.Logf("got: %+v", auds[1])
+		t.Errorf("Should record every field of a create and nothing before it")
+	}
+
+	// -------------------------------------------------------------------------
+
+	count, err := api.Audit.Count(tenant.SetScope(ctx, tenantID), filter)
+	if err != nil {
+		t.Fatalf("Should be able to count the records : %s.", err)
+	}
+
+	if count != 2 {
+		t.Errorf("Should see the records of the tenant of the widget, got %d", count)
+	}
+
+	count, err = api.Audit.Count(tenant.SetScope(ctx, uuid.New()), filter)
+	if err != nil {
+		t.Fatalf("Should be able to count the records : %s.", err)
+	}
+
+	if count != 0 {
+		t.Errorf("Should NOT see the records of another tenant, got %d", count)
+	}
+}
+
+func delegated(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	ctx = audit.SetActor(ctx, usr.ID)
+
+	np := product.NewProduct{
+		UserID:   usr.ID,
+		Name:     "Comic Books",
+		Cost:     10,
+		Quantity: 55,
+	}
+
+	prd, err := api.Product.Create(ctx, np)
+	if err != nil {
+		t.Fatalf("Should be able to create a product : %s.", err)
+	}
+
+	if err := api.Product.Delete(ctx, prd); err != nil {
+		t.Fatalf("Should be able to delete the product : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	var filter audit.QueryFilter
+	filter.WithEntity(product.DomainName)
+	filter.WithEntityID(prd.ID.String())
+
+	auds, err := api.Audit.Query(ctx, filter, audit.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to query the records : %s.", err)
+	}
+
+	if len(auds) != 2 {
+		t.Fatalf("Should record the create and the delete of the core, got %d", len(auds))
+	}
+
+	if auds[0].Action != audit.ActionDelete || auds[1].Action != audit.ActionCreate {
+		t.Logf("got: %s %s", auds[0].Action, auds[1].Action)
+		t.Logf("exp: %s %s", audit.ActionDelete, audit.ActionCreate)
+		t.Errorf("Should record the actions of the core in order")
+	}
+
+	if auds[0].ActorID != usr.ID {
+		t.Logf("got: %s", auds[0].ActorID)
+		t.Logf("exp: %s", usr.ID)
+		t.Errorf("Should record the actor of the context")
+	}
+
+	// The context sees every tenant, so the tenant can only come from the
+	// product.
+	if auds[0].TenantID != prd.TenantID {
+		t.Logf("got: %s", auds[0].TenantID)
+		t.Logf("exp: %s", prd.TenantID)
+		t.Errorf("Should record the tenant of the product")
+	}
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// diff returns the fields of before and after that are different. The values
+// are compared the way they are encoded to JSON, a nil value has no fields.
+func diff(before any, after any) (map[string]any, map[string]any, error) {
+	b, err := toFields(before)
+	if err != nil {
+		return nil, nil, fmt.Errorf("before: %w", err)
+	}
+
+	a, err := toFields(after)
+	if err != nil {
+		return nil, nil, fmt.Errorf("after: %w", err)
+	}
+
+	if b == nil || a == nil {
+		return b, a, nil
+	}
+
+	db := make(map[string]any)
+	da := make(map[string]any)
+
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			db[k] = v
+		}
+	}
+
+	for k, v := range a {
+		if !reflect.DeepEqual(v, b[k]) {
+			da[k] = v
+		}
+	}
+
+	return db, da, nil
+}
+
+// toFields decodes the JSON encoding of the value into its fields.
+func toFields(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,197 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	"github.com/google/uuid"
+)
+
+// The entities are recorded as these views of the core models. They leave
+// out anything secret: the password hash of a user, the hash of an api key
+// and the secret of a webhook. A nil model is recorded as nothing.
+
+type userEntity struct {
+	ID            uuid.UUID   `json:"id"`
+	TenantID      uuid.UUID   `json:"tenantID"`
+	Name          string      `json:"name"`
+	Email         string      `json:"email"`
+	Roles         []user.Role `json:"roles"`
+	Department    string      `json:"department"`
+	Enabled       bool        `json:"enabled"`
+	EmailVerified bool        `json:"emailVerified"`
+	LockedUntil   time.Time   `json:"lockedUntil"`
+	DateCreated   time.Time   `json:"dateCreated"`
+	DateUpdated   time.Time   `json:"dateUpdated"`
+}
+
+func toUserEntity(usr *user.User) any {
+	if usr == nil {
+		return nil
+	}
+
+	return userEntity{
+		ID:            usr.ID,
+		TenantID:      usr.TenantID,
+		Name:          usr.Name,
+		Email:         usr.Email.Address,
+		Roles:         usr.Roles,
+		Department:    usr.Department,
+		Enabled:       usr.Enabled,
+		EmailVerified: usr.EmailVerified,
+		LockedUntil:   usr.LockedUntil,
+		DateCreated:   usr.DateCreated,
+		DateUpdated:   usr.DateUpdated,
+	}
+}
+
+type productEntity struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Cost        float64   `json:"cost"`
+	Quantity    int       `json:"quantity"`
+	UserID      uuid.UUID `json:"userID"`
+	TenantID    uuid.UUID `json:"tenantID"`
+	DateCreated time.Time `json:"dateCreated"`
+	DateUpdated time.Time `json:"dateUpdated"`
+}
+
+func toProductEntity(prd *product.Product) any {
+	if prd == nil {
+		return nil
+	}
+
+	return productEntity{
+		ID:          prd.ID,
+		Name:        prd.Name,
+		Cost:        prd.Cost,
+		Quantity:    prd.Quantity,
+		UserID:      prd.UserID,
+		TenantID:    prd.TenantID,
+		DateCreated: prd.DateCreated,
+		DateUpdated: prd.DateUpdated,
+	}
+}
+
+// stockEntity is the part of a product a sale changes.
+type stockEntity struct {
+	Quantity int `json:"quantity"`
+}
+
+type saleEntity struct {
+	ID          uuid.UUID `json:"id"`
+	ProductID   uuid.UUID `json:"productID"`
+	UserID      uuid.UUID `json:"userID"`
+	Quantity    int       `json:"quantity"`
+	Paid        float64   `json:"paid"`
+	DateCreated time.Time `json:"dateCreated"`
+}
+
+func toSaleEntity(sl sale.Sale) any {
+	return saleEntity{
+		ID:          sl.ID,
+		ProductID:   sl.ProductID,
+		UserID:      sl.UserID,
+		Quantity:    sl.Quantity,
+		Paid:        sl.Paid,
+		DateCreated: sl.DateCreated,
+	}
+}
+
+type tenantEntity struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	DateCreated time.Time `json:"dateCreated"`
+	DateUpdated time.Time `json:"dateUpdated"`
+}
+
+func toTenantEntity(tnt *tenant.Tenant) any {
+	if tnt == nil {
+		return nil
+	}
+
+	return tenantEntity{
+		ID:          tnt.ID,
+		Name:        tnt.Name,
+		DateCreated: tnt.DateCreated,
+		DateUpdated: tnt.DateUpdated,
+	}
+}
+
+type roleEntity struct {
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"`
+	DateCreated time.Time `json:"dateCreated"`
+	DateUpdated time.Time `json:"dateUpdated"`
+}
+
+func toRoleEntity(rl *role.Role) any {
+	if rl == nil {
+		return nil
+	}
+
+	return roleEntity{
+		Name:        rl.Name,
+		Permissions: rl.Permissions,
+		DateCreated: rl.DateCreated,
+		DateUpdated: rl.DateUpdated,
+	}
+}
+
+type webhookEntity struct {
+	ID          uuid.UUID `json:"id"`
+	TenantID    uuid.UUID `json:"tenantID"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Enabled     bool      `json:"enabled"`
+	DateCreated time.Time `json:"dateCreated"`
+	DateUpdated time.Time `json:"dateUpdated"`
+}
+
+func toWebhookEntity(wh *webhook.Webhook) any {
+	if wh == nil {
+		return nil
+	}
+
+	return webhookEntity{
+		ID:          wh.ID,
+		TenantID:    wh.TenantID,
+		URL:         wh.URL,
+		Events:      wh.Events,
+		Enabled:     wh.Enabled,
+		DateCreated: wh.DateCreated,
+		DateUpdated: wh.DateUpdated,
+	}
+}
+
+type apiKeyEntity struct {
+	ID          uuid.UUID   `json:"id"`
+	UserID      uuid.UUID   `json:"userID"`
+	Name        string      `json:"name"`
+	Prefix      string      `json:"prefix"`
+	Roles       []user.Role `json:"roles"`
+	DateCreated time.Time   `json:"dateCreated"`
+	DateRevoked time.Time   `json:"dateRevoked"`
+}
+
+func toAPIKeyEntity(ak *apikey.APIKey) any {
+	if ak == nil {
+		return nil
+	}
+
+	return apiKeyEntity{
+		ID:          ak.ID,
+		UserID:      ak.UserID,
+		Name:        ak.Name,
+		Prefix:      ak.Prefix,
+		Roles:       ak.Roles,
+		DateCreated: ak.DateCreated,
+		DateRevoked: ak.DateRevoked,
+	}
+}
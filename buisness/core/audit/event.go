@@ -0,0 +1,328 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/mfa"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+)
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithDelegate sets the delegate the changes are recorded from. Without it
+// only the calls to Record are recorded.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// The actions of the other domains and the actions they are recorded as. A
+// disabled user is recorded by the update that disabled it.
+var (
+	userActions = map[string]string{
+		user.ActionCreated:          ActionCreate,
+		user.ActionUpdated:          ActionUpdate,
+		user.ActionDeleted:          ActionDelete,
+		user.ActionLocked:           ActionLock,
+		user.ActionUnlocked:         ActionUnlock,
+		user.ActionPasswordRehashed: ActionRehashPassword,
+		user.ActionPasswordReset:    ActionResetPassword,
+		user.ActionEmailVerified:    ActionVerifyEmail,
+	}
+
+	productActions = map[string]string{
+		product.ActionCreated: ActionCreate,
+		product.ActionUpdated: ActionUpdate,
+		product.ActionDeleted: ActionDelete,
+	}
+
+	tenantActions = map[string]string{
+		tenant.ActionCreated: ActionCreate,
+		tenant.ActionUpdated: ActionUpdate,
+		tenant.ActionDeleted: ActionDelete,
+	}
+
+	roleActions = map[string]string{
+		role.ActionCreated: ActionCreate,
+		role.ActionUpdated: ActionUpdate,
+		role.ActionDeleted: ActionDelete,
+	}
+
+	webhookActions = map[string]string{
+		webhook.ActionCreated: ActionCreate,
+		webhook.ActionUpdated: ActionUpdate,
+		webhook.ActionDeleted: ActionDelete,
+	}
+
+	apiKeyActions = map[string]string{
+		apikey.ActionCreated: ActionCreate,
+		apikey.ActionRevoked: ActionRevoke,
+	}
+
+	mfaActions = map[string]string{
+		mfa.ActionEnabled:  ActionEnableMFA,
+		mfa.ActionDisabled: ActionDisableMFA,
+	}
+)
+
+// register subscribes the core to the events of the changes it records.
+func (c *Core) register() {
+	for action := range userActions {
+		c.delegate.Register(user.DomainName, action, c.userChanged)
+	}
+
+	for action := range productActions {
+		c.delegate.Register(product.DomainName, action, c.productChanged)
+	}
+
+	for action := range tenantActions {
+		c.delegate.Register(tenant.DomainName, action, c.tenantChanged)
+	}
+
+	for action := range roleActions {
+		c.delegate.Register(role.DomainName, action, c.roleChanged)
+	}
+
+	for action := range webhookActions {
+		c.delegate.Register(webhook.DomainName, action, c.webhookChanged)
+	}
+
+	for action := range apiKeyActions {
+		c.delegate.Register(apikey.DomainName, action, c.apiKeyChanged)
+	}
+
+	for action := range mfaActions {
+		c.delegate.Register(mfa.DomainName, action, c.mfaChanged)
+	}
+
+	c.delegate.Register(sale.DomainName, sale.ActionCreated, c.saleCreated)
+	c.delegate.Register(token.DomainName, token.ActionRevoked, c.tokenRevoked)
+	c.delegate.Register(token.DomainName, token.ActionUserRevoked, c.tokenRevoked)
+	c.delegate.Register(token.DomainName, token.ActionAccessRevoked, c.tokenRevoked)
+}
+
+// =============================================================================
+
+func (c *Core) userChanged(ctx context.Context, data delegate.Data) error {
+	params, err := user.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	// A password is reset and an email verified with a token that was sent
+	// to the user, so the change is theirs.
+	if data.Action == user.ActionPasswordReset || data.Action == user.ActionEmailVerified {
+		if _, ok := GetActor(ctx); !ok {
+			ctx = SetActor(ctx, params.UserID)
+		}
+	}
+
+	na := NewAudit{
+		TenantID: params.TenantID,
+		Action:   userActions[data.Action],
+		Entity:   user.DomainName,
+		EntityID: params.UserID.String(),
+		Before:   toUserEntity(params.Before),
+		After:    toUserEntity(params.After),
+	}
+
+	return c.record(ctx, na)
+}
+
+func (c *Core) productChanged(ctx context.Context, data delegate.Data) error {
+	params, err := product.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	na := NewAudit{
+		TenantID: params.TenantID,
+		Action:   productActions[data.Action],
+		Entity:   product.DomainName,
+		EntityID: params.ProductID.String(),
+		Before:   toProductEntity(params.Before),
+		After:    toProductEntity(params.After),
+	}
+
+	return c.record(ctx, na)
+}
+
+func (c *Core) tenantChanged(ctx context.Context, data delegate.Data) error {
+	params, err := tenant.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	na := NewAudit{
+		TenantID: params.TenantID,
+		Action:   tenantActions[data.Action],
+		Entity:   tenant.DomainName,
+		EntityID: params.TenantID.String(),
+		Before:   toTenantEntity(params.Before),
+		After:    toTenantEntity(params.After),
+	}
+
+	return c.record(ctx, na)
+}
+
+func (c *Core) roleChanged(ctx context.Context, data delegate.Data) error {
+	params, err := role.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	na := NewAudit{
+		Action:   roleActions[data.Action],
+		Entity:   role.DomainName,
+		EntityID: params.Name,
+		Before:   toRoleEntity(params.Before),
+		After:    toRoleEntity(params.After),
+	}
+
+	return c.record(ctx, na)
+}
+
+func (c *Core) webhookChanged(ctx context.Context, data delegate.Data) error {
+	params, err := webhook.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	na := NewAudit{
+		TenantID: params.TenantID,
+		Action:   webhookActions[data.Action],
+		Entity:   webhook.DomainName,
+		EntityID: params.WebhookID.String(),
+		Before:   toWebhookEntity(params.Before),
+		After:    toWebhookEntity(params.After),
+	}
+
+	return c.record(ctx, na)
+}
+
+func (c *Core) apiKeyChanged(ctx context.Context, data delegate.Data) error {
+	params, err := apikey.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	na := NewAudit{
+		TenantID: params.TenantID,
+		Action:   apiKeyActions[data.Action],
+		Entity:   apikey.DomainName,
+		EntityID: params.KeyID.String(),
+		Before:   toAPIKeyEntity(params.Before),
+		After:    toAPIKeyEntity(params.After),
+	}
+
+	return c.record(ctx, na)
+}
+
+// mfaChanged records the changes to mfa on the user they are for.
+func (c *Core) mfaChanged(ctx context.Context, data delegate.Data) error {
+	params, err := mfa.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	na := NewAudit{
+		Action:   mfaActions[data.Action],
+		Entity:   user.DomainName,
+		EntityID: params.UserID.String(),
+	}
+
+	return c.record(ctx, na)
+}
+
+// saleCreated records the sale and the stock it took from the product.
+func (c *Core) saleCreated(ctx context.Context, data delegate.Data) error {
+	params, err := sale.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	na := NewAudit{
+		TenantID: params.TenantID,
+		Action:   ActionCreate,
+		Entity:   sale.DomainName,
+		EntityID: params.SaleID.String(),
+		After:    toSaleEntity(params.Sale),
+	}
+
+	if err := c.record(ctx, na); err != nil {
+		return err
+	}
+
+	na = NewAudit{
+		TenantID: params.TenantID,
+		Action:   ActionTakeStock,
+		Entity:   product.DomainName,
+		EntityID: params.ProductID.String(),
+		Before:   stockEntity{Quantity: params.Stock + params.Sale.Quantity},
+		After:    stockEntity{Quantity: params.Stock},
+	}
+
+	return c.record(ctx, na)
+}
+
+// tokenRevoked records a revoked refresh or access token, revoking all the
+// refresh tokens of a user is recorded on the user.
+func (c *Core) tokenRevoked(ctx context.Context, data delegate.Data) error {
+	params, err := token.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	na := NewAudit{
+		Action: ActionRevoke,
+		Entity: token.DomainName,
+	}
+
+	switch data.Action {
+	case token.ActionRevoked:
+		na.EntityID = params.TokenID.String()
+
+	case token.ActionAccessRevoked:
+		na.EntityID = params.JTI
+
+	case token.ActionUserRevoked:
+		na.Action = ActionRevokeTokens
+		na.Entity = user.DomainName
+		na.EntityID = params.UserID.String()
+	}
+
+	return c.record(ctx, na)
+}
+
+// =============================================================================
+
+// record adds the record of a change in the transaction of the change, so
+// the record is only kept if the change is committed.
+func (c *Core) record(ctx context.Context, na NewAudit) error {
+	if tx, ok := transaction.Get(ctx); ok {
+		var err error
+		c, err = c.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := c.Record(ctx, na); err != nil {
+		return fmt.Errorf("record: %s[%s]: %w", na.Entity, na.EntityID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	ActorID          *uuid.UUID `validate:"omitempty"`
+	Entity           *string    `validate:"omitempty"`
+	EntityID         *string    `validate:"omitempty"`
+	StartCreatedDate *time.Time `validate:"omitempty"`
+	EndCreatedDate   *time.Time `validate:"omitempty"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (qf *QueryFilter) Validate() error {
+	if err := validate.Check(qf); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	return nil
+}
+
+// WithActorID sets the ActorID field of the QueryFilter value.
+func (qf *QueryFilter) WithActorID(actorID uuid.UUID) {
+	qf.ActorID = &actorID
+}
+
+// WithEntity sets the Entity field of the QueryFilter value.
+func (qf *QueryFilter) WithEntity(entity string) {
+	qf.Entity = &entity
+}
+
+// WithEntityID sets the EntityID field of the QueryFilter value.
+func (qf *QueryFilter) WithEntityID(entityID string) {
+	qf.EntityID = &entityID
+}
+
+// WithStartDateCreated sets the DateCreated field of the QueryFilter value.
+func (qf *QueryFilter) WithStartDateCreated(startDate time.Time) {
+	d := startDate.UTC()
+	qf.StartCreatedDate = &d
+}
+
+// WithEndCreatedDate sets the DateCreated field of the QueryFilter value.
+func (qf *QueryFilter) WithEndCreatedDate(endDate time.Time) {
+	d := endDate.UTC()
+	qf.EndCreatedDate = &d
+}
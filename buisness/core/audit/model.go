@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of actions the changes are recorded with.
+const (
+	ActionCreate         = "create"
+	ActionUpdate         = "update"
+	ActionDelete         = "delete"
+	ActionLock           = "lock"
+	ActionUnlock         = "unlock"
+	ActionRehashPassword = "rehash_password"
+	ActionResetPassword  = "reset_password"
+	ActionVerifyEmail    = "verify_email"
+	ActionEnableMFA      = "enable_mfa"
+	ActionDisableMFA     = "disable_mfa"
+	ActionRevoke         = "revoke"
+	ActionRevokeTokens   = "revoke_tokens"
+	ActionTakeStock      = "take_stock"
+)
+
+// Audit represents a change made to an entity. Before and After only hold
+// the fields that changed, a create has no Before and a delete no After.
+// ActorID is the zero value when the change wasn't made by an authenticated
+// user. TenantID is the tenant of the entity, not of the actor, and the zero
+// value for the entities that aren't in a tenant.
+type Audit struct {
+	ID          uuid.UUID
+	ActorID     uuid.UUID
+	TenantID    uuid.UUID
+	Action      string
+	Entity      string
+	EntityID    string
+	Before      map[string]any
+	After       map[string]any
+	TraceID     string
+	DateCreated time.Time
+}
+
+// NewAudit contains information needed to record a change. Before and After
+// are the entity as the clients see it before and after the change, they
+// must not hold anything secret. Either can be nil. TenantID is the tenant of
+// the entity, when it is the zero value the tenant the context is limited to
+// is used.
+type NewAudit struct {
+	TenantID uuid.UUID
+	Action   string
+	Entity   string
+	EntityID string
+	Before   any
+	After    any
+}
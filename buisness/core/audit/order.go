@@ -0,0 +1,16 @@
+package audit
+
+import "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+
+// DefaultOrderBy represents the default way we sort, the latest changes
+// come first.
+var DefaultOrderBy = order.NewBy(OrderByDateCreated, order.DESC)
+
+// Set of fields that the results can be ordered by. These are the names
+// that should be used by the application layer.
+const (
+	OrderByID          = "audit_id"
+	OrderByActorID     = "actor_id"
+	OrderByEntity      = "entity"
+	OrderByDateCreated = "date_created"
+)
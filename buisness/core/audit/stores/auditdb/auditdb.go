@@ -0,0 +1,125 @@
+// Package auditdb contains audit related CRUD functionality.
+package auditdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for audit database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (audit.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new record into the database.
+func (s *Store) Create(ctx context.Context, aud audit.Audit) error {
+	dbAud, err := toDBAudit(aud)
+	if err != nil {
+		return fmt.Errorf("todbaudit: %w", err)
+	}
+
+	const q = `
+	INSERT INTO audits
+		(audit_id, actor_id, tenant_id, action, entity, entity_id, before, after, trace_id, date_created)
+	VALUES
+		(:audit_id, :actor_id, :tenant_id, :action, :entity, :entity_id, :before, :after, :trace_id, :date_created)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, dbAud); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing records from the database.
+func (s *Store) Query(ctx context.Context, filter audit.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]audit.Audit, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		audit_id, actor_id, tenant_id, action, entity, entity_id, before, after, trace_id, date_created
+	FROM
+		audits`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbAuds []dbAudit
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbAuds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	auds, err := toCoreAuditSlice(dbAuds)
+	if err != nil {
+		return nil, fmt.Errorf("tocoreaudit: %w", err)
+	}
+
+	return auds, nil
+}
+
+// Count returns the total number of records in the DB.
+func (s *Store) Count(ctx context.Context, filter audit.QueryFilter) (int, error) {
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		audits`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
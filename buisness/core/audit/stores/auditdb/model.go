@@ -0,0 +1,116 @@
+package auditdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/google/uuid"
+)
+
+// dbAudit represents an individual record of a change. The actor is NULL for
+// the changes the system makes on its own, the tenant for the entities that
+// aren't in a tenant, and the JSONB
+// columns are NULL when there is nothing on that side of the change.
+type dbAudit struct {
+	ID          uuid.UUID     `db:"audit_id"`
+	ActorID     uuid.NullUUID `db:"actor_id"`
+	TenantID    uuid.NullUUID `db:"tenant_id"`
+	Action      string        `db:"action"`
+	Entity      string        `db:"entity"`
+	EntityID    string        `db:"entity_id"`
+	Before      []byte        `db:"before"`
+	After       []byte        `db:"after"`
+	TraceID     string        `db:"trace_id"`
+	DateCreated time.Time     `db:"date_created"`
+}
+
+func toDBAudit(aud audit.Audit) (dbAudit, error) {
+	before, err := toJSON(aud.Before)
+	if err != nil {
+		return dbAudit{}, fmt.Errorf("before: %w", err)
+	}
+
+	after, err := toJSON(aud.After)
+	if err != nil {
+		return dbAudit{}, fmt.Errorf("after: %w", err)
+	}
+
+	dbAud := dbAudit{
+		ID:          aud.ID,
+		ActorID:     uuid.NullUUID{UUID: aud.ActorID, Valid: aud.ActorID != uuid.Nil},
+		TenantID:    uuid.NullUUID{UUID: aud.TenantID, Valid: aud.TenantID != uuid.Nil},
+		Action:      aud.Action,
+		Entity:      aud.Entity,
+		EntityID:    aud.EntityID,
+		Before:      before,
+		After:       after,
+		TraceID:     aud.TraceID,
+		DateCreated: aud.DateCreated.UTC(),
+	}
+
+	return dbAud, nil
+}
+
+func toCoreAudit(dbAud dbAudit) (audit.Audit, error) {
+	before, err := fromJSON(dbAud.Before)
+	if err != nil {
+		return audit.Audit{}, fmt.Errorf("before: %w", err)
+	}
+
+	after, err := fromJSON(dbAud.After)
+	if err != nil {
+		return audit.Audit{}, fmt.Errorf("after: %w", err)
+	}
+
+	aud := audit.Audit{
+		ID:          dbAud.ID,
+		ActorID:     dbAud.ActorID.UUID,
+		TenantID:    dbAud.TenantID.UUID,
+		Action:      dbAud.Action,
+		Entity:      dbAud.Entity,
+		EntityID:    dbAud.EntityID,
+		Before:      before,
+		After:       after,
+		TraceID:     dbAud.TraceID,
+		DateCreated: dbAud.DateCreated.In(time.Local),
+	}
+
+	return aud, nil
+}
+
+func toCoreAuditSlice(dbAuds []dbAudit) ([]audit.Audit, error) {
+	auds := make([]audit.Audit, len(dbAuds))
+	for i, dbAud := range dbAuds {
+		var err error
+		if auds[i], err = toCoreAudit(dbAud); err != nil {
+			return nil, err
+		}
+	}
+	return auds, nil
+}
+
+// toJSON encodes the fields for a JSONB column, nil fields are stored as
+// NULL.
+func toJSON(fields map[string]any) ([]byte, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(fields)
+}
+
+// fromJSON decodes the fields from a JSONB column.
+func fromJSON(data []byte) (map[string]any, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
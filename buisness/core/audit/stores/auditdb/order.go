@@ -0,0 +1,24 @@
+package auditdb
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+)
+
+var orderByFields = map[string]string{
+	audit.OrderByID:          "audit_id",
+	audit.OrderByActorID:     "actor_id",
+	audit.OrderByEntity:      "entity",
+	audit.OrderByDateCreated: "date_created",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction, nil
+}
@@ -0,0 +1,160 @@
+// Package change provides the core business API for the feed of the changes
+// made to users and products. The database notifies the feed of every
+// changed row, whichever instance of the service made the change, and the
+// feed passes the changes on to its subscribers.
+package change
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Set of entities the feed reports the changes of.
+const (
+	EntityUser    = "user"
+	EntityProduct = "product"
+)
+
+// Set of actions a change can be.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// bufferSize is how many changes a subscriber can fall behind before the
+// changes are dropped for it.
+const bufferSize = 64
+
+// =============================================================================
+
+// Storer interface declares the behavior this package needs to receive the
+// changes.
+type Storer interface {
+	// Listen must call fn for every change until the context is cancelled,
+	// which is not an error, or it can no longer receive them.
+	Listen(ctx context.Context, fn func(Change)) error
+}
+
+// =============================================================================
+
+// Subscription represents a subscriber of the feed. C is closed once the
+// feed stops.
+type Subscription struct {
+	C    <-chan Change
+	ch   chan Change
+	core *Core
+}
+
+// Close removes the subscription from the feed.
+func (s *Subscription) Close() {
+	s.core.unsubscribe(s)
+}
+
+// =============================================================================
+
+// Core manages the set of APIs for the change feed.
+type Core struct {
+	log    *zap.SugaredLogger
+	storer Storer
+
+	mu      sync.Mutex
+	subs    map[*Subscription]struct{}
+	stopped bool
+}
+
+// NewCore constructs a core for change feed access.
+func NewCore(log *zap.SugaredLogger, storer Storer) *Core {
+	return &Core{
+		log:    log,
+		storer: storer,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Run receives the changes and passes them on to the subscribers. When it
+// can't receive them anymore it tries again after the retry interval. It
+// blocks until the context is cancelled and closes the subscriptions then.
+func (c *Core) Run(ctx context.Context, retry time.Duration) {
+	defer c.stop()
+
+	for {
+		err := c.storer.Listen(ctx, c.publish)
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.log.Errorw("change", "status", "listen failed, retrying", "retry", retry, "ERROR", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// Subscribe adds a subscriber to the feed. The subscriber must close the
+// subscription once it is done with it.
+func (c *Core) Subscribe() *Subscription {
+	ch := make(chan Change, bufferSize)
+
+	s := Subscription{
+		C:    ch,
+		ch:   ch,
+		core: c,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		close(ch)
+		return &s
+	}
+
+	c.subs[&s] = struct{}{}
+
+	return &s
+}
+
+// publish passes a change on to every subscriber. A subscriber that fell
+// too far behind misses the change rather than holding up the others.
+func (c *Core) publish(chg Change) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for s := range c.subs {
+		select {
+		case s.ch <- chg:
+		default:
+			c.log.Infow("change", "status", "subscriber too slow, change dropped", "entity", chg.Entity, "id", chg.ID)
+		}
+	}
+}
+
+func (c *Core) unsubscribe(s *Subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.subs[s]; !exists {
+		return
+	}
+
+	delete(c.subs, s)
+	close(s.ch)
+}
+
+func (c *Core) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for s := range c.subs {
+		delete(c.subs, s)
+		close(s.ch)
+	}
+	c.stopped = true
+}
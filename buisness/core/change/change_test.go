@@ -0,0 +1,125 @@
+package change_test
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/change"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/change/stores/changedb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Change(t *testing.T) {
+	t.Run("feed", feed)
+}
+
+// =============================================================================
+
+func feed(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	chgCore := change.NewCore(test.Log, changedb.NewStore(test.Log, test.DB))
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		chgCore.Run(runCtx, time.Second)
+		close(done)
+	}()
+
+	sub := chgCore.Subscribe()
+	defer sub.Close()
+
+	// The feed listens in the background, give it a moment before changing
+	// anything.
+	time.Sleep(500 * time.Millisecond)
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	np := product.NewProduct{
+		UserID:   usr.ID,
+		Name:     "Comic Books",
+		Cost:     10,
+		Quantity: 55,
+	}
+
+	prd, err := api.Product.Create(ctx, np)
+	if err != nil {
+		t.Fatalf("Should be able to create a product : %s.", err)
+	}
+
+	select {
+	case chg := <-sub.C:
+		exp := change.Change{
+			Entity:   change.EntityProduct,
+			Action:   change.ActionCreated,
+			ID:       prd.ID,
+			UserID:   usr.ID,
+			TenantID: usr.TenantID,
+			Date:     chg.Date,
+		}
+
+		if chg != exp {
+			t.Logf("got: %+v", chg)
+			t.Logf("exp: %+v", exp)
+			t.Errorf("Should get the change of the product")
+		}
+
+	case <-ctx.Done():
+		t.Fatalf("Should get the change of the product before the timeout")
+	}
+
+	// -------------------------------------------------------------------------
+
+	stop()
+	<-done
+
+	if _, ok := <-sub.C; ok {
+		t.Errorf("Should close the subscriptions once the feed stops")
+	}
+}
@@ -0,0 +1,18 @@
+package change
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Change represents a change made to a user or a product. UserID is the user
+// the changed row belongs to, the user itself for a user.
+type Change struct {
+	Entity   string
+	Action   string
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	TenantID uuid.UUID
+	Date     time.Time
+}
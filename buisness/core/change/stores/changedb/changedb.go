@@ -0,0 +1,73 @@
+// Package changedb contains the database notifications of the change feed.
+package changedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/change"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// channel is the channel the notify_change trigger notifies.
+const channel = "changes"
+
+// Store manages the set of APIs for change database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// dbChange represents the payload of a notification of the trigger.
+type dbChange struct {
+	Entity   string     `json:"entity"`
+	Action   string     `json:"action"`
+	ID       uuid.UUID  `json:"id"`
+	UserID   *uuid.UUID `json:"user_id"`
+	TenantID uuid.UUID  `json:"tenant_id"`
+}
+
+// Listen calls fn for every change the database notifies until the context
+// is cancelled. A payload that can't be read is logged and skipped.
+func (s *Store) Listen(ctx context.Context, fn func(change.Change)) error {
+	f := func(n db.Notification) {
+		var dbChg dbChange
+		if err := json.Unmarshal([]byte(n.Payload), &dbChg); err != nil {
+			s.log.Errorw("changedb", "status", "unreadable notification", "payload", n.Payload, "ERROR", err)
+			return
+		}
+
+		chg := change.Change{
+			Entity:   dbChg.Entity,
+			Action:   dbChg.Action,
+			ID:       dbChg.ID,
+			TenantID: dbChg.TenantID,
+			Date:     time.Now(),
+		}
+
+		if dbChg.UserID != nil {
+			chg.UserID = *dbChg.UserID
+		}
+
+		fn(chg)
+	}
+
+	if err := db.Listen(ctx, s.log, s.db, channel, f); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package mfa
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// DomainName represents the name of this domain for delegate events.
+const DomainName = "mfa"
+
+// Set of delegate actions the mfa domain publishes.
+const (
+	// ActionEnabled is published once a user confirmed their enrollment.
+	ActionEnabled = "enabled"
+
+	// ActionDisabled is published once the enrollment of a user is removed.
+	ActionDisabled = "disabled"
+)
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithDelegate sets the delegate the events of the mfa domain are published
+// to. Without it the events go nowhere.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// ActionParms represents the parameters for the actions of the mfa domain.
+type ActionParms struct {
+	UserID uuid.UUID `json:"userID"`
+}
+
+// ActionEnabledData constructs the data for the enabled action.
+func ActionEnabledData(userID uuid.UUID) delegate.Data {
+	return actionData(ActionEnabled, userID)
+}
+
+// ActionDisabledData constructs the data for the disabled action.
+func ActionDisabledData(userID uuid.UUID) delegate.Data {
+	return actionData(ActionDisabled, userID)
+}
+
+// ParseActionParms returns the parameters of an action of the mfa domain.
+func ParseActionParms(data delegate.Data) (ActionParms, error) {
+	params, ok := data.Params.(ActionParms)
+	if !ok {
+		return ActionParms{}, fmt.Errorf("unexpected params type %T for %s.%s", data.Params, data.Domain, data.Action)
+	}
+
+	return params, nil
+}
+
+func actionData(action string, userID uuid.UUID) delegate.Data {
+	return delegate.Data{
+		Domain: DomainName,
+		Action: action,
+		Params: ActionParms{UserID: userID},
+	}
+}
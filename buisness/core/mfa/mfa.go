@@ -0,0 +1,380 @@
+// Package mfa provides the core business API for multi-factor authentication
+// with time-based one-time passwords and recovery codes.
+package mfa
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/totp"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound        = errors.New("mfa not enrolled")
+	ErrAlreadyEnrolled = errors.New("mfa already enabled")
+	ErrInvalidCode     = errors.New("mfa code is not valid")
+	ErrTooManyAttempts = errors.New("too many failed mfa attempts")
+)
+
+// Set of values for checking codes. A factor is locked for lockDuration once
+// maxFailures codes in a row were wrong, a code can be off by skew steps.
+const (
+	skew          = 1
+	maxFailures   = 5
+	lockDuration  = 15 * time.Minute
+	recoveryCount = 10
+)
+
+// =============================================================================
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, f Factor) error
+	Update(ctx context.Context, f Factor) error
+	Delete(ctx context.Context, userID uuid.UUID) error
+	QueryByUserID(ctx context.Context, userID uuid.UUID) (Factor, error)
+	// Attempt must add one to the failures of a confirmed factor that is not
+	// locked and return it, so concurrent requests can't check more codes
+	// than allowed.
+	Attempt(ctx context.Context, userID uuid.UUID, now time.Time) (Factor, error)
+	// UseStep must only record the step when it is after the last one used,
+	// returning ErrNotFound otherwise. This keeps a code from being used
+	// twice.
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+	CreateRecoveryCode(ctx context.Context, rc RecoveryCode) error
+	// UseRecoveryCode must only mark an unused code as used, returning
+	// ErrNotFound otherwise.
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string, now time.Time) error
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+}
+
+// =============================================================================
+
+// Core manages the set of APIs for mfa access.
+type Core struct {
+	storer   Storer
+	issuer   string
+	key      [32]byte
+	delegate *delegate.Delegate
+}
+
+// NewCore constructs a core for mfa api access. The issuer names the service
+// in authenticator apps. The secret encrypts the TOTP secrets and must be the
+// same for every instance of the service.
+func NewCore(storer Storer, issuer string, secret []byte, options ...Option) *Core {
+	c := Core{
+		storer: storer,
+		issuer: issuer,
+		key:    sha256.Sum256(secret),
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		storer:   trS,
+		issuer:   c.issuer,
+		key:      c.key,
+		delegate: c.delegate,
+	}
+
+	return c, nil
+}
+
+// Enroll generates a new TOTP secret for the user. It replaces a previous
+// enrollment that was never confirmed.
+func (c *Core) Enroll(ctx context.Context, usr user.User) (Enrollment, error) {
+	f, err := c.storer.QueryByUserID(ctx, usr.ID)
+	switch {
+	case err == nil:
+		if !f.DateConfirmed.IsZero() {
+			return Enrollment{}, ErrAlreadyEnrolled
+		}
+		if err := c.storer.Delete(ctx, usr.ID); err != nil {
+			return Enrollment{}, fmt.Errorf("delete: userID[%s]: %w", usr.ID, err)
+		}
+
+	case !errors.Is(err, ErrNotFound):
+		return Enrollment{}, fmt.Errorf("querybyuserid: userID[%s]: %w", usr.ID, err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	sealed, err := c.seal(secret)
+	if err != nil {
+		return Enrollment{}, err
+	}
+
+	f = Factor{
+		UserID:       usr.ID,
+		SealedSecret: sealed,
+		DateCreated:  time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, f); err != nil {
+		return Enrollment{}, fmt.Errorf("create: %w", err)
+	}
+
+	enr := Enrollment{
+		Secret: secret,
+		URI:    totp.URI(c.issuer, usr.Email.Address, secret),
+	}
+
+	return enr, nil
+}
+
+// Confirm enables the enrollment of the user once the code proves the secret
+// was added to an authenticator app. It returns the recovery codes, they
+// can't be recovered later.
+func (c *Core) Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	f, err := c.storer.QueryByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("querybyuserid: userID[%s]: %w", userID, err)
+	}
+
+	if !f.DateConfirmed.IsZero() {
+		return nil, ErrAlreadyEnrolled
+	}
+
+	secret, err := c.open(f.SealedSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	step, ok := totp.Validate(secret, normalize(code), now, skew)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	f.LastStep = step
+	f.DateConfirmed = now
+
+	if err := c.storer.Update(ctx, f); err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+
+	codes := make([]string, recoveryCount)
+	for i := range codes {
+		code, err := recoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		rc := RecoveryCode{
+			ID:          uuid.New(),
+			UserID:      userID,
+			Hash:        hash(code),
+			DateCreated: now,
+		}
+
+		if err := c.storer.CreateRecoveryCode(ctx, rc); err != nil {
+			return nil, fmt.Errorf("createrecoverycode: %w", err)
+		}
+
+		codes[i] = code
+	}
+
+	if err := c.delegate.Call(ctx, ActionEnabledData(userID)); err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+
+	return codes, nil
+}
+
+// IsEnabled reports if the user confirmed an enrollment, a code is then
+// required to authenticate.
+func (c *Core) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	f, err := c.storer.QueryByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("querybyuserid: userID[%s]: %w", userID, err)
+	}
+
+	return !f.DateConfirmed.IsZero(), nil
+}
+
+// Verify checks the TOTP code or recovery code of the user. A code can only
+// be used once, and too many wrong codes lock the factor for a while.
+func (c *Core) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	now := time.Now()
+
+	// The attempt is counted before the code is checked, so a wrong code
+	// has been paid for whatever happens next.
+	f, err := c.storer.Attempt(ctx, userID, now)
+	if err != nil {
+		return fmt.Errorf("attempt: userID[%s]: %w", userID, err)
+	}
+
+	if f.LockedUntil.After(now) {
+		return ErrTooManyAttempts
+	}
+
+	if f.Failures > maxFailures {
+		f.Failures = 0
+		f.LockedUntil = now.Add(lockDuration)
+
+		if err := c.storer.Update(ctx, f); err != nil {
+			return fmt.Errorf("update: %w", err)
+		}
+
+		return ErrTooManyAttempts
+	}
+
+	code = normalize(code)
+
+	if len(code) == totp.Digits {
+		secret, err := c.open(f.SealedSecret)
+		if err != nil {
+			return err
+		}
+
+		step, ok := totp.Validate(secret, code, now, skew)
+		if !ok {
+			return ErrInvalidCode
+		}
+
+		if err := c.storer.UseStep(ctx, userID, step); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return ErrInvalidCode
+			}
+			return fmt.Errorf("usestep: userID[%s]: %w", userID, err)
+		}
+
+		return nil
+	}
+
+	if err := c.storer.UseRecoveryCode(ctx, userID, hash(code), now); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrInvalidCode
+		}
+		return fmt.Errorf("userecoverycode: userID[%s]: %w", userID, err)
+	}
+
+	f.Failures = 0
+
+	if err := c.storer.Update(ctx, f); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+
+	return nil
+}
+
+// Disable removes the enrollment and the recovery codes of the user.
+func (c *Core) Disable(ctx context.Context, userID uuid.UUID) error {
+	if err := c.storer.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("delete: userID[%s]: %w", userID, err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionDisabledData(userID)); err != nil {
+		return fmt.Errorf("delete: userID[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// seal encrypts the secret with AES-GCM, the nonce is stored in front.
+func (c *Core) seal(secret string) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, []byte(secret), nil), nil
+}
+
+// open decrypts a secret encrypted by seal.
+func (c *Core) open(sealed []byte) (string, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed sealed secret")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	secret, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("opening secret: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+func (c *Core) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.key[:])
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// recoveryCode returns a random code formatted as two groups of five
+// characters so it is easy to type.
+func recoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating recovery code: %w", err)
+	}
+
+	s := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)[:10]
+
+	return s[:5] + "-" + s[5:], nil
+}
+
+// normalize removes the separators users type in codes, so a recovery code
+// matches with or without the dash.
+func normalize(code string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// hash returns the value stored for a recovery code. The codes are random so
+// a fast hash is enough.
+func hash(code string) string {
+	sum := sha256.Sum256([]byte(normalize(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,147 @@
+package mfa_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/mfa"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/mfa/stores/mfadb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/totp"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_MFA(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	core := mfa.NewCore(mfadb.NewStore(test.Log, test.DB), "Sales API", []byte("secret"))
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := test.CoreAPIs.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	enr, err := core.Enroll(ctx, usr)
+	if err != nil {
+		t.Fatalf("Should be able to enroll : %s.", err)
+	}
+
+	enabled, err := core.IsEnabled(ctx, usr.ID)
+	if err != nil || enabled {
+		t.Fatalf("Should NOT have mfa enabled before the enrollment is confirmed : %v : %s.", enabled, err)
+	}
+
+	if err := core.Verify(ctx, usr.ID, "000000"); !errors.Is(err, mfa.ErrNotFound) {
+		t.Fatalf("Should NOT be able to verify a code before the enrollment is confirmed : %s.", err)
+	}
+
+	now := time.Now()
+
+	code, err := totp.Code(enr.Secret, now)
+	if err != nil {
+		t.Fatalf("Should be able to generate a code : %s.", err)
+	}
+
+	codes, err := core.Confirm(ctx, usr.ID, code)
+	if err != nil {
+		t.Fatalf("Should be able to confirm the enrollment : %s.", err)
+	}
+
+	if len(codes) != 10 {
+		t.Logf("got: %v", len(codes))
+		t.Logf("exp: %v", 10)
+		t.Errorf("Should get the recovery codes")
+	}
+
+	enabled, err = core.IsEnabled(ctx, usr.ID)
+	if err != nil || !enabled {
+		t.Fatalf("Should have mfa enabled once the enrollment is confirmed : %v : %s.", enabled, err)
+	}
+
+	if _, err := core.Enroll(ctx, usr); !errors.Is(err, mfa.ErrAlreadyEnrolled) {
+		t.Fatalf("Should NOT be able to enroll twice : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := core.Verify(ctx, usr.ID, code); !errors.Is(err, mfa.ErrInvalidCode) {
+		t.Fatalf("Should NOT be able to use the code of the confirmation again : %s.", err)
+	}
+
+	next, err := totp.Code(enr.Secret, now.Add(totp.Period))
+	if err != nil {
+		t.Fatalf("Should be able to generate a code : %s.", err)
+	}
+
+	if err := core.Verify(ctx, usr.ID, next); err != nil {
+		t.Fatalf("Should be able to verify a new code : %s.", err)
+	}
+
+	if err := core.Verify(ctx, usr.ID, codes[0]); err != nil {
+		t.Fatalf("Should be able to verify a recovery code : %s.", err)
+	}
+
+	if err := core.Verify(ctx, usr.ID, codes[0]); !errors.Is(err, mfa.ErrInvalidCode) {
+		t.Fatalf("Should NOT be able to use a recovery code twice : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	for i := 0; i < 4; i++ {
+		if err := core.Verify(ctx, usr.ID, "ABCDE-FGHIJ"); !errors.Is(err, mfa.ErrInvalidCode) {
+			t.Fatalf("Should NOT be able to verify a wrong code : %s.", err)
+		}
+	}
+
+	if err := core.Verify(ctx, usr.ID, codes[1]); !errors.Is(err, mfa.ErrTooManyAttempts) {
+		t.Fatalf("Should lock mfa after too many wrong codes : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := core.Disable(ctx, usr.ID); err != nil {
+		t.Fatalf("Should be able to disable mfa : %s.", err)
+	}
+
+	enabled, err = core.IsEnabled(ctx, usr.ID)
+	if err != nil || enabled {
+		t.Fatalf("Should NOT have mfa enabled once it is disabled : %v : %s.", enabled, err)
+	}
+}
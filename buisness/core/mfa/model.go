@@ -0,0 +1,37 @@
+package mfa
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Factor represents the TOTP secret a user enrolled. The secret is only kept
+// encrypted. A factor is not used to authenticate until the user confirmed
+// it with a code.
+type Factor struct {
+	UserID        uuid.UUID
+	SealedSecret  []byte
+	LastStep      int64
+	Failures      int
+	LockedUntil   time.Time
+	DateCreated   time.Time
+	DateConfirmed time.Time
+}
+
+// RecoveryCode represents a single-use code that can replace a TOTP code
+// when the user lost their device. Only the hash of the code is kept.
+type RecoveryCode struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Hash        string
+	DateCreated time.Time
+	DateUsed    time.Time
+}
+
+// Enrollment contains what the user needs to add the secret to an
+// authenticator app.
+type Enrollment struct {
+	Secret string
+	URI    string
+}
@@ -0,0 +1,244 @@
+// Package mfadb contains mfa related CRUD functionality.
+package mfadb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/mfa"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for mfa database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (mfa.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new enrollment into the database.
+func (s *Store) Create(ctx context.Context, f mfa.Factor) error {
+	const q = `
+	INSERT INTO mfa_factors
+		(user_id, secret, last_step, failures, locked_until, date_created, date_confirmed)
+	VALUES
+		(:user_id, :secret, :last_step, :failures, :locked_until, :date_created, :date_confirmed)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBFactor(f)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces the state of an enrollment in the database. The last step
+// never moves back, so a stale value can't make a used code valid again.
+func (s *Store) Update(ctx context.Context, f mfa.Factor) error {
+	const q = `
+	UPDATE
+		mfa_factors
+	SET
+		"last_step" = GREATEST(last_step, :last_step),
+		"failures" = :failures,
+		"locked_until" = :locked_until,
+		"date_confirmed" = :date_confirmed
+	WHERE
+		user_id = :user_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBFactor(f)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the enrollment of a user, the recovery codes go with it.
+func (s *Store) Delete(ctx context.Context, userID uuid.UUID) error {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		mfa_factors
+	WHERE
+		user_id = :user_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryByUserID finds the enrollment of a user.
+func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) (mfa.Factor, error) {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	SELECT
+		user_id, secret, last_step, failures, locked_until, date_created, date_confirmed
+	FROM
+		mfa_factors
+	WHERE
+		user_id = :user_id`
+
+	var dbF dbFactor
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbF); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return mfa.Factor{}, fmt.Errorf("namedquerystruct: %w", mfa.ErrNotFound)
+		}
+		return mfa.Factor{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreFactor(dbF), nil
+}
+
+// Attempt adds one to the failures of a confirmed enrollment and returns it.
+// Attempts made while the enrollment is locked are not counted.
+func (s *Store) Attempt(ctx context.Context, userID uuid.UUID, now time.Time) (mfa.Factor, error) {
+	data := struct {
+		UserID string    `db:"user_id"`
+		Now    time.Time `db:"now"`
+	}{
+		UserID: userID.String(),
+		Now:    now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		mfa_factors
+	SET
+		"failures" = CASE WHEN locked_until > :now THEN failures ELSE failures + 1 END
+	WHERE
+		user_id = :user_id AND date_confirmed IS NOT NULL
+	RETURNING
+		user_id, secret, last_step, failures, locked_until, date_created, date_confirmed`
+
+	var dbF dbFactor
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbF); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return mfa.Factor{}, fmt.Errorf("namedquerystruct: %w", mfa.ErrNotFound)
+		}
+		return mfa.Factor{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreFactor(dbF), nil
+}
+
+// UseStep records the step of a code that was accepted and clears the
+// failures. The update only matches when the step is after the last one.
+func (s *Store) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	data := struct {
+		UserID string `db:"user_id"`
+		Step   int64  `db:"step"`
+	}{
+		UserID: userID.String(),
+		Step:   step,
+	}
+
+	const q = `
+	UPDATE
+		mfa_factors
+	SET
+		"last_step" = :step,
+		"failures" = 0
+	WHERE
+		user_id = :user_id AND last_step < :step
+	RETURNING
+		user_id, secret, last_step, failures, locked_until, date_created, date_confirmed`
+
+	var dbF dbFactor
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbF); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", mfa.ErrNotFound)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// CreateRecoveryCode inserts a new recovery code into the database.
+func (s *Store) CreateRecoveryCode(ctx context.Context, rc mfa.RecoveryCode) error {
+	const q = `
+	INSERT INTO mfa_recovery_codes
+		(code_id, user_id, code_hash, date_created, date_used)
+	VALUES
+		(:code_id, :user_id, :code_hash, :date_created, :date_used)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBRecoveryCode(rc)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks the recovery code of the user as used. The update
+// only matches a code that is not used.
+func (s *Store) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string, now time.Time) error {
+	data := struct {
+		UserID string    `db:"user_id"`
+		Hash   string    `db:"code_hash"`
+		Now    time.Time `db:"now"`
+	}{
+		UserID: userID.String(),
+		Hash:   hash,
+		Now:    now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		mfa_recovery_codes
+	SET
+		"date_used" = :now
+	WHERE
+		user_id = :user_id AND code_hash = :code_hash AND date_used IS NULL
+	RETURNING
+		code_id, user_id, code_hash, date_created, date_used`
+
+	var dbRC dbRecoveryCode
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbRC); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", mfa.ErrNotFound)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
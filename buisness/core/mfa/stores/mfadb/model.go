@@ -0,0 +1,82 @@
+package mfadb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/mfa"
+	"github.com/google/uuid"
+)
+
+// dbFactor represents the TOTP enrollment of a user.
+type dbFactor struct {
+	UserID        uuid.UUID    `db:"user_id"`
+	Secret        []byte       `db:"secret"`
+	LastStep      int64        `db:"last_step"`
+	Failures      int          `db:"failures"`
+	LockedUntil   sql.NullTime `db:"locked_until"`
+	DateCreated   time.Time    `db:"date_created"`
+	DateConfirmed sql.NullTime `db:"date_confirmed"`
+}
+
+func toDBFactor(f mfa.Factor) dbFactor {
+	return dbFactor{
+		UserID:   f.UserID,
+		Secret:   f.SealedSecret,
+		LastStep: f.LastStep,
+		Failures: f.Failures,
+		LockedUntil: sql.NullTime{
+			Time:  f.LockedUntil.UTC(),
+			Valid: !f.LockedUntil.IsZero(),
+		},
+		DateCreated: f.DateCreated.UTC(),
+		DateConfirmed: sql.NullTime{
+			Time:  f.DateConfirmed.UTC(),
+			Valid: !f.DateConfirmed.IsZero(),
+		},
+	}
+}
+
+func toCoreFactor(dbF dbFactor) mfa.Factor {
+	f := mfa.Factor{
+		UserID:       dbF.UserID,
+		SealedSecret: dbF.Secret,
+		LastStep:     dbF.LastStep,
+		Failures:     dbF.Failures,
+		DateCreated:  dbF.DateCreated.In(time.Local),
+	}
+
+	if dbF.LockedUntil.Valid {
+		f.LockedUntil = dbF.LockedUntil.Time.In(time.Local)
+	}
+
+	if dbF.DateConfirmed.Valid {
+		f.DateConfirmed = dbF.DateConfirmed.Time.In(time.Local)
+	}
+
+	return f
+}
+
+// =============================================================================
+
+// dbRecoveryCode represents an individual recovery code.
+type dbRecoveryCode struct {
+	ID          uuid.UUID    `db:"code_id"`
+	UserID      uuid.UUID    `db:"user_id"`
+	Hash        string       `db:"code_hash"`
+	DateCreated time.Time    `db:"date_created"`
+	DateUsed    sql.NullTime `db:"date_used"`
+}
+
+func toDBRecoveryCode(rc mfa.RecoveryCode) dbRecoveryCode {
+	return dbRecoveryCode{
+		ID:          rc.ID,
+		UserID:      rc.UserID,
+		Hash:        rc.Hash,
+		DateCreated: rc.DateCreated.UTC(),
+		DateUsed: sql.NullTime{
+			Time:  rc.DateUsed.UTC(),
+			Valid: !rc.DateUsed.IsZero(),
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package product
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// DomainName represents the name of this domain for delegate events.
+const DomainName = "product"
+
+// Set of delegate actions the product domain publishes.
+const (
+	// ActionCreated is published once a product is stored.
+	ActionCreated = "created"
+
+	// ActionUpdated is published once a product is updated.
+	ActionUpdated = "updated"
+
+	// ActionDeleted is published once a product is removed.
+	ActionDeleted = "deleted"
+)
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithDelegate sets the delegate the events of the product domain are
+// published to and the events of the other domains are handled from.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// ActionParms represents the parameters for the actions of the product
+// domain. They are marshaled when an event leaves the service, so only the
+// IDs are marshaled. Before and After are the product before and after the
+// change for the handlers in the service, Before is nil for a created
+// product and After for a deleted one.
+type ActionParms struct {
+	ProductID uuid.UUID `json:"productID"`
+	UserID    uuid.UUID `json:"userID"`
+	TenantID  uuid.UUID `json:"tenantID"`
+	Before    *Product  `json:"-"`
+	After     *Product  `json:"-"`
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(prd Product) delegate.Data {
+	return actionData(ActionCreated, nil, &prd)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(before Product, prd Product) delegate.Data {
+	return actionData(ActionUpdated, &before, &prd)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(prd Product) delegate.Data {
+	return actionData(ActionDeleted, &prd, nil)
+}
+
+// ParseActionParms returns the parameters of an action of the product
+// domain.
+func ParseActionParms(data delegate.Data) (ActionParms, error) {
+	params, ok := data.Params.(ActionParms)
+	if !ok {
+		return ActionParms{}, fmt.Errorf("unexpected params type %T for %s.%s", data.Params, data.Domain, data.Action)
+	}
+
+	return params, nil
+}
+
+func actionData(action string, before *Product, after *Product) delegate.Data {
+	prd := after
+	if prd == nil {
+		prd = before
+	}
+
+	return delegate.Data{
+		Domain: DomainName,
+		Action: action,
+		Params: ActionParms{ProductID: prd.ID, UserID: prd.UserID, TenantID: prd.TenantID, Before: before, After: after},
+	}
+}
+
+// =============================================================================
+
+// userDeleted removes the products of a user that is about to be deleted.
+func (c *Core) userDeleted(ctx context.Context, data delegate.Data) error {
+	params, err := user.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	if tx, ok := transaction.Get(ctx); ok {
+		c, err = c.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	prds, err := c.storer.QueryByUserID(ctx, params.UserID)
+	if err != nil {
+		return fmt.Errorf("querybyuserid: userID[%s]: %w", params.UserID, err)
+	}
+
+	for _, prd := range prds {
+		if err := c.Delete(ctx, prd); err != nil {
+			return fmt.Errorf("delete: productID[%s]: %w", prd.ID, err)
+		}
+	}
+
+	return nil
+}
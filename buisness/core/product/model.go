@@ -10,17 +10,20 @@ import (
 // A piece of wisdom :)
 // Sold & Revenue field in the products will cause us pain
 // because of the aggregation that can get very easily get out of sync in this table
-// Would be better if we introduced a sales domain
+// That's why they are not stored with the product, they are computed from
+// the sales domain every time a product is read.
 type Product struct {
 	ID       uuid.UUID
 	Name     string
 	Cost     float64
 	Quantity int
 	Sold     int
-	Revenue  int
+	Revenue  float64
 	// We are recording here what user in the system has entered this product
 	// This a relationship a product has a user
+	// The product belongs to the tenant of that user.
 	UserID      uuid.UUID
+	TenantID    uuid.UUID
 	DateCreated time.Time
 	DateUpdated time.Time
 }
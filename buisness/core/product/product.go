@@ -11,6 +11,8 @@ import (
 
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
 	"github.com/google/uuid"
 )
 
@@ -19,6 +21,7 @@ var (
 	ErrNotFound     = errors.New("product not found")
 	ErrUserDisabled = errors.New("user disabled")
 	ErrInvalidCost  = errors.New("cost not valid")
+	ErrHasSales     = errors.New("product has sales")
 )
 
 // =============================================================================
@@ -28,11 +31,13 @@ var (
 type Storer interface {
 	Create(ctx context.Context, prd Product) error
 	Update(ctx context.Context, prd Product) error
+	UpdateQuantity(ctx context.Context, prd Product) error
 	Delete(ctx context.Context, prd Product) error
 	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Product, error)
 	Count(ctx context.Context, filter QueryFilter) (int, error)
 	QueryByID(ctx context.Context, productID uuid.UUID) (Product, error)
 	QueryByUserID(ctx context.Context, userID uuid.UUID) ([]Product, error)
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
 }
 
 // =============================================================================
@@ -43,21 +48,49 @@ type Core struct {
 	// because a Product has a user id "Product is related to a user"
 	// We can't do that in user to bring the Product core because
 	// it doesn't have a product ID
-	usrCore *user.Core
-	storer  Storer
+	usrCore  *user.Core
+	storer   Storer
+	delegate *delegate.Delegate
 }
 
 // NewCore constructs a core for product api access.
-func NewCore(usrCore *user.Core, storer Storer) *Core {
+func NewCore(usrCore *user.Core, storer Storer, options ...Option) *Core {
 	c := Core{
-
 		usrCore: usrCore,
 		storer:  storer,
 	}
 
+	for _, option := range options {
+		option(&c)
+	}
+
+	c.delegate.Register(user.DomainName, user.ActionDeleted, c.userDeleted)
+
 	return &c
 }
 
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	usrCore, err := c.usrCore.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		usrCore:  usrCore,
+		storer:   trS,
+		delegate: c.delegate,
+	}
+
+	return c, nil
+}
+
 // Create adds a new product to the system.
 func (c *Core) Create(ctx context.Context, np NewProduct) (Product, error) {
 	usr, err := c.usrCore.QueryByID(ctx, np.UserID)
@@ -81,6 +114,7 @@ func (c *Core) Create(ctx context.Context, np NewProduct) (Product, error) {
 		Cost:        np.Cost,
 		Quantity:    np.Quantity,
 		UserID:      np.UserID,
+		TenantID:    usr.TenantID,
 		DateCreated: now,
 		DateUpdated: now,
 	}
@@ -89,38 +123,78 @@ func (c *Core) Create(ctx context.Context, np NewProduct) (Product, error) {
 		return Product{}, fmt.Errorf("create: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionCreatedData(prd)); err != nil {
+		return Product{}, fmt.Errorf("create: %w", err)
+	}
+
 	return prd, nil
 }
 
 // Update modifies information about a product.
 func (c *Core) Update(ctx context.Context, prd Product, up UpdateProduct) (Product, error) {
+	before := prd
+
 	if up.Name != nil {
 		prd.Name = *up.Name
 	}
 
 	if up.Cost != nil {
+		if *up.Cost < 0 {
+			return Product{}, ErrInvalidCost
+		}
 		prd.Cost = *up.Cost
 	}
 
-	if up.Quantity != nil {
-		prd.Quantity = *up.Quantity
-	}
-
 	prd.DateUpdated = time.Now()
 
 	if err := c.storer.Update(ctx, prd); err != nil {
 		return Product{}, fmt.Errorf("update: %w", err)
 	}
 
+	// The product was read before the update began and a sale may have
+	// taken stock since, so the quantity is only written when it is set and
+	// read back otherwise.
+	switch {
+	case up.Quantity != nil:
+		prd.Quantity = *up.Quantity
+		if err := c.storer.UpdateQuantity(ctx, prd); err != nil {
+			return Product{}, fmt.Errorf("updatequantity: %w", err)
+		}
+
+	default:
+		cur, err := c.storer.QueryByID(ctx, prd.ID)
+		if err != nil {
+			return Product{}, fmt.Errorf("query: productID[%s]: %w", prd.ID, err)
+		}
+		prd.Quantity = cur.Quantity
+		prd.Sold = cur.Sold
+		prd.Revenue = cur.Revenue
+
+		// What changed since the product was read is not part of this
+		// update.
+		before.Quantity = cur.Quantity
+		before.Sold = cur.Sold
+		before.Revenue = cur.Revenue
+	}
+
+	if err := c.delegate.Call(ctx, ActionUpdatedData(before, prd)); err != nil {
+		return Product{}, fmt.Errorf("update: %w", err)
+	}
+
 	return prd, nil
 }
 
-// Delete removes the specified product.
+// Delete removes the specified product. A product that was sold is kept for
+// the sales, ErrHasSales is returned for it.
 func (c *Core) Delete(ctx context.Context, prd Product) error {
 	if err := c.storer.Delete(ctx, prd); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionDeletedData(prd)); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,292 @@
+package product_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+	"github.com/google/go-cmp/cmp"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Product(t *testing.T) {
+	t.Run("crud", crud)
+	t.Run("paging", paging)
+	t.Run("userDeleted", userDeleted)
+}
+
+// =============================================================================
+
+func crud(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	np := product.NewProduct{
+		UserID:   usr.ID,
+		Name:     "Comic Books",
+		Cost:     10,
+		Quantity: 55,
+	}
+
+	prd, err := api.Product.Create(ctx, np)
+	if err != nil {
+		t.Fatalf("Should be able to create a product : %s.", err)
+	}
+
+	saved, err := api.Product.QueryByID(ctx, prd.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if prd.DateCreated.UnixMilli() != saved.DateCreated.UnixMilli() {
+		t.Logf("got: %v", saved.DateCreated)
+		t.Logf("exp: %v", prd.DateCreated)
+		t.Errorf("Should get back the same date created")
+	}
+
+	prd.DateCreated = time.Time{}
+	prd.DateUpdated = time.Time{}
+	saved.DateCreated = time.Time{}
+	saved.DateUpdated = time.Time{}
+
+	if diff := cmp.Diff(prd, saved); diff != "" {
+		t.Fatalf("Should get back the same product. diff:\n%s", diff)
+	}
+
+	// -------------------------------------------------------------------------
+
+	upd := product.UpdateProduct{
+		Name:     dbtest.StringPointer("Comics"),
+		Cost:     dbtest.FloatPointer(50),
+		Quantity: dbtest.IntPointer(40),
+	}
+
+	if _, err := api.Product.Update(ctx, saved, upd); err != nil {
+		t.Fatalf("Should be able to update product : %s.", err)
+	}
+
+	prds, err := api.Product.QueryByUserID(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve products by user : %s.", err)
+	}
+
+	if len(prds) != 1 {
+		t.Fatalf("Should get back a single product for the user, got %d", len(prds))
+	}
+
+	if prds[0].Name != *upd.Name || prds[0].Cost != *upd.Cost || prds[0].Quantity != *upd.Quantity {
+		t.Logf("got: %+v", prds[0])
+		t.Logf("exp: %+v", upd)
+		t.Errorf("Should be able to see updates to the product")
+	}
+
+	// saved still holds the quantity from before the update, like a product
+	// read before a sale took stock.
+	stale, err := api.Product.Update(ctx, saved, product.UpdateProduct{Name: dbtest.StringPointer("Graphic Novels")})
+	if err != nil {
+		t.Fatalf("Should be able to update product : %s.", err)
+	}
+
+	if stale.Quantity != *upd.Quantity {
+		t.Logf("got: %v", stale.Quantity)
+		t.Logf("exp: %v", *upd.Quantity)
+		t.Errorf("Should NOT overwrite the quantity when it is not updated")
+	}
+
+	if _, err := api.Product.Update(ctx, saved, product.UpdateProduct{Cost: dbtest.FloatPointer(-1)}); !errors.Is(err, product.ErrInvalidCost) {
+		t.Errorf("Should NOT be able to set a negative cost : %s.", err)
+	}
+
+	if err := api.Product.Delete(ctx, saved); err != nil {
+		t.Fatalf("Should be able to delete product : %s.", err)
+	}
+
+	_, err = api.Product.QueryByID(ctx, saved.ID)
+	if !errors.Is(err, product.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve product : %s.", err)
+	}
+}
+
+func paging(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("admin@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		np := product.NewProduct{
+			UserID:   usr.ID,
+			Name:     fmt.Sprintf("Product %d", i),
+			Cost:     float64(10 * (i + 1)),
+			Quantity: i + 1,
+		}
+
+		if _, err := api.Product.Create(ctx, np); err != nil {
+			t.Fatalf("Should be able to create a product : %s.", err)
+		}
+	}
+
+	prds, err := api.Product.Query(ctx, product.QueryFilter{}, product.DefaultOrderBy, 1, 2)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve products for page 1 : %s.", err)
+	}
+
+	if len(prds) != 2 {
+		t.Logf("got: %v", len(prds))
+		t.Logf("exp: %v", 2)
+		t.Errorf("Should have 2 products for page 1")
+	}
+
+	n, err := api.Product.Count(ctx, product.QueryFilter{})
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product count : %s.", err)
+	}
+
+	if n != 3 {
+		t.Logf("got: %v", n)
+		t.Logf("exp: %v", 3)
+		t.Errorf("Should have 3 products in total")
+	}
+
+	name := "Product 1"
+	prds, err = api.Product.Query(ctx, product.QueryFilter{Name: &name}, product.DefaultOrderBy, 1, 10)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product %q : %s.", name, err)
+	}
+
+	if len(prds) != 1 || prds[0].Name != name {
+		t.Errorf("Should have a single product for %q", name)
+	}
+}
+
+func userDeleted(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	admin, err := mail.ParseAddress("admin@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	seeded, err := api.User.QueryByEmail(ctx, *admin)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	email, err := mail.ParseAddress("seller@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	nu := user.NewUser{
+		TenantID: seeded.TenantID,
+		Name:     "Seller Gopher",
+		Email:    *email,
+		Roles:    []user.Role{user.RoleUser},
+		Password: "Seller-Gopher-42",
+	}
+
+	usr, err := api.User.Create(ctx, nu)
+	if err != nil {
+		t.Fatalf("Should be able to create a user : %s.", err)
+	}
+
+	np := product.NewProduct{
+		UserID:   usr.ID,
+		Name:     "Comic Books",
+		Cost:     10,
+		Quantity: 55,
+	}
+
+	prd, err := api.Product.Create(ctx, np)
+	if err != nil {
+		t.Fatalf("Should be able to create a product : %s.", err)
+	}
+
+	if err := api.User.Delete(ctx, usr); err != nil {
+		t.Fatalf("Should be able to delete the user : %s.", err)
+	}
+
+	if _, err := api.Product.QueryByID(ctx, prd.ID); !errors.Is(err, product.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve the product of a deleted user : %s.", err)
+	}
+}
@@ -0,0 +1,63 @@
+package productdb
+
+import (
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/google/uuid"
+)
+
+// dbProduct represents an individual product. Sold and Revenue are only
+// read from the product_sales view, they are never written.
+type dbProduct struct {
+	ID          uuid.UUID `db:"product_id"`
+	UserID      uuid.UUID `db:"user_id"`
+	TenantID    uuid.UUID `db:"tenant_id"`
+	Name        string    `db:"name"`
+	Cost        float64   `db:"cost"`
+	Quantity    int       `db:"quantity"`
+	Sold        int       `db:"sold"`
+	Revenue     float64   `db:"revenue"`
+	DateCreated time.Time `db:"date_created"`
+	DateUpdated time.Time `db:"date_updated"`
+}
+
+func toDBProduct(prd product.Product) dbProduct {
+	prdDB := dbProduct{
+		ID:          prd.ID,
+		UserID:      prd.UserID,
+		TenantID:    prd.TenantID,
+		Name:        prd.Name,
+		Cost:        prd.Cost,
+		Quantity:    prd.Quantity,
+		DateCreated: prd.DateCreated.UTC(),
+		DateUpdated: prd.DateUpdated.UTC(),
+	}
+
+	return prdDB
+}
+
+func toCoreProduct(dbPrd dbProduct) product.Product {
+	prd := product.Product{
+		ID:          dbPrd.ID,
+		UserID:      dbPrd.UserID,
+		TenantID:    dbPrd.TenantID,
+		Name:        dbPrd.Name,
+		Cost:        dbPrd.Cost,
+		Quantity:    dbPrd.Quantity,
+		Sold:        dbPrd.Sold,
+		Revenue:     dbPrd.Revenue,
+		DateCreated: dbPrd.DateCreated.In(time.Local),
+		DateUpdated: dbPrd.DateUpdated.In(time.Local),
+	}
+
+	return prd
+}
+
+func toCoreProductSlice(dbProducts []dbProduct) []product.Product {
+	prds := make([]product.Product, len(dbProducts))
+	for i, dbPrd := range dbProducts {
+		prds[i] = toCoreProduct(dbPrd)
+	}
+	return prds
+}
@@ -0,0 +1,236 @@
+// Package productdb contains product related CRUD functionality.
+package productdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for product database access. Products are
+// written to the products table and read from the product_sales view so the
+// sold and revenue figures are always computed from the sales data. Reads
+// are limited to the tenant set on the context with tenant.SetScope.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (product.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create adds a Product to the database.
+func (s *Store) Create(ctx context.Context, prd product.Product) error {
+	const q = `
+	INSERT INTO products
+		(product_id, user_id, tenant_id, name, cost, quantity, date_created, date_updated)
+	VALUES
+		(:product_id, :user_id, :tenant_id, :name, :cost, :quantity, :date_created, :date_updated)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update modifies data about a Product. It will error if the specified ID is
+// invalid or does not reference an existing Product. The quantity is left
+// alone, sales change it concurrently and it is set with UpdateQuantity.
+func (s *Store) Update(ctx context.Context, prd product.Product) error {
+	const q = `
+	UPDATE
+		products
+	SET
+		"name" = :name,
+		"cost" = :cost,
+		"date_updated" = :date_updated
+	WHERE
+		product_id = :product_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateQuantity sets the quantity of a Product.
+func (s *Store) UpdateQuantity(ctx context.Context, prd product.Product) error {
+	const q = `
+	UPDATE
+		products
+	SET
+		"quantity" = :quantity,
+		"date_updated" = :date_updated
+	WHERE
+		product_id = :product_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBProduct(prd)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the product identified by a given ID.
+func (s *Store) Delete(ctx context.Context, prd product.Product) error {
+	data := struct {
+		ID string `db:"product_id"`
+	}{
+		ID: prd.ID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		products
+	WHERE
+		product_id = :product_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		if errors.Is(err, db.ErrDBForeignKey) {
+			return fmt.Errorf("namedexeccontext: %w", product.ErrHasSales)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query gets all Products from the database.
+func (s *Store) Query(ctx context.Context, filter product.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]product.Product, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		product_id, user_id, tenant_id, name, cost, quantity, sold, revenue, date_created, date_updated
+	FROM
+		product_sales`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbPrds []dbProduct
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPrds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreProductSlice(dbPrds), nil
+}
+
+// Count returns the total number of products in the DB.
+func (s *Store) Count(ctx context.Context, filter product.QueryFilter) (int, error) {
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		product_sales`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID finds the product identified by a given ID.
+func (s *Store) QueryByID(ctx context.Context, productID uuid.UUID) (product.Product, error) {
+	data := map[string]interface{}{
+		"product_id": productID.String(),
+	}
+
+	const q = `
+	SELECT
+		product_id, user_id, tenant_id, name, cost, quantity, sold, revenue, date_created, date_updated
+	FROM
+		product_sales
+	WHERE
+		product_id = :product_id`
+
+	buf := bytes.NewBufferString(q)
+	applyScope(ctx, data, buf)
+
+	var dbPrd dbProduct
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbPrd); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return product.Product{}, fmt.Errorf("namedquerystruct: %w", product.ErrNotFound)
+		}
+		return product.Product{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreProduct(dbPrd), nil
+}
+
+// QueryByUserID finds the products identified by a given User ID.
+func (s *Store) QueryByUserID(ctx context.Context, userID uuid.UUID) ([]product.Product, error) {
+	data := map[string]interface{}{
+		"user_id": userID.String(),
+	}
+
+	const q = `
+	SELECT
+		product_id, user_id, tenant_id, name, cost, quantity, sold, revenue, date_created, date_updated
+	FROM
+		product_sales
+	WHERE
+		user_id = :user_id`
+
+	buf := bytes.NewBufferString(q)
+	applyScope(ctx, data, buf)
+
+	var dbPrds []dbProduct
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbPrds); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreProductSlice(dbPrds), nil
+}
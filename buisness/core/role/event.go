@@ -0,0 +1,81 @@
+package role
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+)
+
+// DomainName represents the name of this domain for delegate events.
+const DomainName = "role"
+
+// Set of delegate actions the role domain publishes.
+const (
+	// ActionCreated is published once a role is stored.
+	ActionCreated = "created"
+
+	// ActionUpdated is published once a role is updated.
+	ActionUpdated = "updated"
+
+	// ActionDeleted is published once a role is removed.
+	ActionDeleted = "deleted"
+)
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithDelegate sets the delegate the events of the role domain are published
+// to. Without it the events go nowhere.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// ActionParms represents the parameters for the actions of the role domain.
+// Only the name is marshaled, Before and After are the role before and after
+// the change for the handlers in the service.
+type ActionParms struct {
+	Name   string `json:"name"`
+	Before *Role  `json:"-"`
+	After  *Role  `json:"-"`
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(rl Role) delegate.Data {
+	return actionData(ActionCreated, nil, &rl)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(before Role, rl Role) delegate.Data {
+	return actionData(ActionUpdated, &before, &rl)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(rl Role) delegate.Data {
+	return actionData(ActionDeleted, &rl, nil)
+}
+
+// ParseActionParms returns the parameters of an action of the role domain.
+func ParseActionParms(data delegate.Data) (ActionParms, error) {
+	params, ok := data.Params.(ActionParms)
+	if !ok {
+		return ActionParms{}, fmt.Errorf("unexpected params type %T for %s.%s", data.Params, data.Domain, data.Action)
+	}
+
+	return params, nil
+}
+
+func actionData(action string, before *Role, after *Role) delegate.Data {
+	rl := after
+	if rl == nil {
+		rl = before
+	}
+
+	return delegate.Data{
+		Domain: DomainName,
+		Action: action,
+		Params: ActionParms{Name: rl.Name, Before: before, After: after},
+	}
+}
@@ -0,0 +1,23 @@
+package role
+
+import "time"
+
+// Role represents a role users can be granted and the permissions that come
+// with it. Permissions are written like product:write.
+type Role struct {
+	Name        string
+	Permissions []string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewRole contains information needed to create a new role.
+type NewRole struct {
+	Name        string
+	Permissions []string
+}
+
+// UpdateRole contains information needed to update a role.
+type UpdateRole struct {
+	Permissions []string
+}
@@ -0,0 +1,160 @@
+// Package role provides the core business API for the roles users can be
+// granted and the permissions each role holds.
+package role
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound   = errors.New("role not found")
+	ErrUniqueName = errors.New("name is not unique")
+	ErrInUse      = errors.New("role is still granted")
+	ErrBuiltIn    = errors.New("role is built in")
+)
+
+// =============================================================================
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, rl Role) error
+	Update(ctx context.Context, rl Role) error
+	// Delete must only remove a role no user or api key holds, returning
+	// ErrInUse otherwise.
+	Delete(ctx context.Context, rl Role) error
+	QueryAll(ctx context.Context) ([]Role, error)
+	QueryByName(ctx context.Context, name string) (Role, error)
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+}
+
+// =============================================================================
+
+// Core manages the set of APIs for role access.
+type Core struct {
+	storer   Storer
+	delegate *delegate.Delegate
+}
+
+// NewCore constructs a core for role api access.
+func NewCore(storer Storer, options ...Option) *Core {
+	c := Core{
+		storer: storer,
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		storer:   trS,
+		delegate: c.delegate,
+	}
+
+	return c, nil
+}
+
+// Create adds a new role to the system.
+func (c *Core) Create(ctx context.Context, nr NewRole) (Role, error) {
+	now := time.Now()
+
+	rl := Role{
+		Name:        nr.Name,
+		Permissions: nr.Permissions,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if rl.Permissions == nil {
+		rl.Permissions = []string{}
+	}
+
+	if err := c.storer.Create(ctx, rl); err != nil {
+		return Role{}, fmt.Errorf("create: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionCreatedData(rl)); err != nil {
+		return Role{}, fmt.Errorf("create: %w", err)
+	}
+
+	return rl, nil
+}
+
+// Update replaces the permissions of a role.
+func (c *Core) Update(ctx context.Context, rl Role, ur UpdateRole) (Role, error) {
+	before := rl
+
+	if ur.Permissions != nil {
+		rl.Permissions = ur.Permissions
+	}
+
+	rl.DateUpdated = time.Now()
+
+	if err := c.storer.Update(ctx, rl); err != nil {
+		return Role{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionUpdatedData(before, rl)); err != nil {
+		return Role{}, fmt.Errorf("update: %w", err)
+	}
+
+	return rl, nil
+}
+
+// Delete removes the specified role. The built in roles and roles that are
+// still granted can't be removed.
+func (c *Core) Delete(ctx context.Context, rl Role) error {
+	if user.IsBuiltIn(rl.Name) {
+		return ErrBuiltIn
+	}
+
+	if err := c.storer.Delete(ctx, rl); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionDeletedData(rl)); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// QueryAll retrieves every role. The set of roles is small so there is no
+// paging.
+func (c *Core) QueryAll(ctx context.Context) ([]Role, error) {
+	rls, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return rls, nil
+}
+
+// QueryByName finds the role by the specified name.
+func (c *Core) QueryByName(ctx context.Context, name string) (Role, error) {
+	rl, err := c.storer.QueryByName(ctx, name)
+	if err != nil {
+		return Role{}, fmt.Errorf("query: name[%s]: %w", name, err)
+	}
+
+	return rl, nil
+}
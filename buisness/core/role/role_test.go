@@ -0,0 +1,139 @@
+package role_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+	"github.com/google/go-cmp/cmp"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Role(t *testing.T) {
+	t.Run("crud", crud)
+}
+
+// =============================================================================
+
+func crud(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	rl, err := api.Role.Create(ctx, role.NewRole{Name: "AUDITOR", Permissions: []string{"product:read"}})
+	if err != nil {
+		t.Fatalf("Should be able to create a role : %s.", err)
+	}
+
+	if _, err := api.Role.Create(ctx, role.NewRole{Name: "AUDITOR"}); !errors.Is(err, role.ErrUniqueName) {
+		t.Fatalf("Should NOT be able to create a role with the same name : %s.", err)
+	}
+
+	perms := []string{"product:read", "sale:read"}
+	if _, err := api.Role.Update(ctx, rl, role.UpdateRole{Permissions: perms}); err != nil {
+		t.Fatalf("Should be able to update a role : %s.", err)
+	}
+
+	saved, err := api.Role.QueryByName(ctx, rl.Name)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve role by name : %s.", err)
+	}
+
+	if diff := cmp.Diff(perms, saved.Permissions); diff != "" {
+		t.Errorf("Should be able to see updates to Permissions, diff:\n%s", diff)
+	}
+
+	rls, err := api.Role.QueryAll(ctx)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the roles : %s.", err)
+	}
+
+	if len(rls) != 4 {
+		t.Errorf("Should get the built in roles and the new one, got %d", len(rls))
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := test.Auth.ReloadRoles(ctx); err != nil {
+		t.Fatalf("Should be able to reload the roles : %s.", err)
+	}
+	defer user.SetRoles(nil)
+
+	auditor, err := user.ParseRole("AUDITOR")
+	if err != nil {
+		t.Fatalf("Should be able to parse a role once it's loaded : %s.", err)
+	}
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	if _, err := api.User.Update(ctx, usr, user.UpdateUser{Roles: []user.Role{user.RoleUser, auditor}}); err != nil {
+		t.Fatalf("Should be able to grant the role to a user : %s.", err)
+	}
+
+	if err := api.Role.Delete(ctx, saved); !errors.Is(err, role.ErrInUse) {
+		t.Fatalf("Should NOT be able to delete a role a user holds : %s.", err)
+	}
+
+	if _, err := api.User.Update(ctx, usr, user.UpdateUser{Roles: []user.Role{user.RoleUser}}); err != nil {
+		t.Fatalf("Should be able to take the role from a user : %s.", err)
+	}
+
+	builtIn, err := api.Role.QueryByName(ctx, user.RoleUser.Name())
+	if err != nil {
+		t.Fatalf("Should be able to retrieve a built in role : %s.", err)
+	}
+
+	if err := api.Role.Delete(ctx, builtIn); !errors.Is(err, role.ErrBuiltIn) {
+		t.Fatalf("Should NOT be able to delete a built in role : %s.", err)
+	}
+
+	if err := api.Role.Delete(ctx, saved); err != nil {
+		t.Fatalf("Should be able to delete a role no one holds : %s.", err)
+	}
+
+	if _, err := api.Role.QueryByName(ctx, rl.Name); !errors.Is(err, role.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve a deleted role : %s.", err)
+	}
+}
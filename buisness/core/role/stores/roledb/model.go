@@ -0,0 +1,48 @@
+package roledb
+
+import (
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/dbarray"
+)
+
+// dbRole represents an individual role.
+type dbRole struct {
+	Name        string         `db:"name"`
+	Permissions dbarray.String `db:"permissions"`
+	DateCreated time.Time      `db:"date_created"`
+	DateUpdated time.Time      `db:"date_updated"`
+}
+
+func toDBRole(rl role.Role) dbRole {
+	perms := make([]string, len(rl.Permissions))
+	copy(perms, rl.Permissions)
+
+	return dbRole{
+		Name:        rl.Name,
+		Permissions: perms,
+		DateCreated: rl.DateCreated.UTC(),
+		DateUpdated: rl.DateUpdated.UTC(),
+	}
+}
+
+func toCoreRole(dbRl dbRole) role.Role {
+	perms := make([]string, len(dbRl.Permissions))
+	copy(perms, dbRl.Permissions)
+
+	return role.Role{
+		Name:        dbRl.Name,
+		Permissions: perms,
+		DateCreated: dbRl.DateCreated.In(time.Local),
+		DateUpdated: dbRl.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreRoleSlice(dbRls []dbRole) []role.Role {
+	rls := make([]role.Role, len(dbRls))
+	for i, dbRl := range dbRls {
+		rls[i] = toCoreRole(dbRl)
+	}
+	return rls
+}
@@ -0,0 +1,158 @@
+// Package roledb contains role related CRUD functionality.
+package roledb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for role database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (role.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new role into the database.
+func (s *Store) Create(ctx context.Context, rl role.Role) error {
+	const q = `
+	INSERT INTO roles
+		(name, permissions, date_created, date_updated)
+	VALUES
+		(:name, :permissions, :date_created, :date_updated)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBRole(rl)); err != nil {
+		if errors.Is(err, db.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", role.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a role document in the database.
+func (s *Store) Update(ctx context.Context, rl role.Role) error {
+	const q = `
+	UPDATE
+		roles
+	SET
+		"permissions" = :permissions,
+		"date_updated" = :date_updated
+	WHERE
+		name = :name`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBRole(rl)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a role no user or api key holds from the database. The
+// check and the delete are one statement so the role can't be granted in
+// between.
+func (s *Store) Delete(ctx context.Context, rl role.Role) error {
+	data := struct {
+		Name string `db:"name"`
+	}{
+		Name: rl.Name,
+	}
+
+	const q = `
+	DELETE FROM
+		roles
+	WHERE
+		name = :name AND
+		NOT EXISTS (SELECT 1 FROM users WHERE :name = ANY(roles)) AND
+		NOT EXISTS (SELECT 1 FROM api_keys WHERE :name = ANY(roles))
+	RETURNING
+		name`
+
+	var deleted struct {
+		Name string `db:"name"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &deleted); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", role.ErrInUse)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// QueryAll retrieves every role from the database.
+func (s *Store) QueryAll(ctx context.Context) ([]role.Role, error) {
+	const q = `
+	SELECT
+		name, permissions, date_created, date_updated
+	FROM
+		roles
+	ORDER BY
+		name`
+
+	var dbRls []dbRole
+	if err := db.QuerySlice(ctx, s.log, s.db, q, &dbRls); err != nil {
+		return nil, fmt.Errorf("queryslice: %w", err)
+	}
+
+	return toCoreRoleSlice(dbRls), nil
+}
+
+// QueryByName gets the specified role from the database.
+func (s *Store) QueryByName(ctx context.Context, name string) (role.Role, error) {
+	data := struct {
+		Name string `db:"name"`
+	}{
+		Name: name,
+	}
+
+	const q = `
+	SELECT
+		name, permissions, date_created, date_updated
+	FROM
+		roles
+	WHERE
+		name = :name`
+
+	var dbRl dbRole
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbRl); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return role.Role{}, fmt.Errorf("namedquerystruct: %w", role.ErrNotFound)
+		}
+		return role.Role{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreRole(dbRl), nil
+}
@@ -0,0 +1,62 @@
+package sale
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// DomainName represents the name of this domain for delegate events.
+const DomainName = "sale"
+
+// Set of delegate actions the sale domain publishes.
+const (
+	// ActionCreated is published once a sale is stored and the stock of
+	// the product is taken.
+	ActionCreated = "created"
+)
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithDelegate sets the delegate the events of the sale domain are published
+// to. Without it the events go nowhere.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// ActionParms represents the parameters for the actions of the sale domain.
+// Only the IDs are marshaled, Sale and Stock are the sale and the quantity
+// of the product left after it for the handlers in the service.
+type ActionParms struct {
+	SaleID    uuid.UUID `json:"saleID"`
+	ProductID uuid.UUID `json:"productID"`
+	UserID    uuid.UUID `json:"userID"`
+	TenantID  uuid.UUID `json:"tenantID"`
+	Sale      Sale      `json:"-"`
+	Stock     int       `json:"-"`
+}
+
+// ActionCreatedData constructs the data for the created action, the tenant is
+// the tenant of the product.
+func ActionCreatedData(sl Sale, tenantID uuid.UUID, stock int) delegate.Data {
+	return delegate.Data{
+		Domain: DomainName,
+		Action: ActionCreated,
+		Params: ActionParms{SaleID: sl.ID, ProductID: sl.ProductID, UserID: sl.UserID, TenantID: tenantID, Sale: sl, Stock: stock},
+	}
+}
+
+// ParseActionParms returns the parameters of an action of the sale domain.
+func ParseActionParms(data delegate.Data) (ActionParms, error) {
+	params, ok := data.Params.(ActionParms)
+	if !ok {
+		return ActionParms{}, fmt.Errorf("unexpected params type %T for %s.%s", data.Params, data.Domain, data.Action)
+	}
+
+	return params, nil
+}
@@ -0,0 +1,26 @@
+package sale
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Sale represents a single sale of a product to a user. The amount paid is
+// captured at the time of the sale so later changes to the product cost
+// don't change the history.
+type Sale struct {
+	ID          uuid.UUID
+	ProductID   uuid.UUID
+	UserID      uuid.UUID
+	Quantity    int
+	Paid        float64
+	DateCreated time.Time
+}
+
+// NewSale is what we require from clients when recording a Sale.
+type NewSale struct {
+	ProductID uuid.UUID
+	UserID    uuid.UUID
+	Quantity  int
+}
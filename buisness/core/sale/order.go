@@ -0,0 +1,17 @@
+package sale
+
+import "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+
+// DefaultOrderBy represents the default way we sort.
+var DefaultOrderBy = order.NewBy(OrderByDateCreated, order.DESC)
+
+// Set of fields that the results can be ordered by. These are the names
+// that should be used by the application layer.
+const (
+	OrderBySaleID      = "sale_id"
+	OrderByProductID   = "product_id"
+	OrderByUserID      = "user_id"
+	OrderByQuantity    = "quantity"
+	OrderByPaid        = "paid"
+	OrderByDateCreated = "date_created"
+)
@@ -0,0 +1,154 @@
+// Package sale provides the core business API for recording the sale of
+// products. This is the sales domain the product model was asking for, the
+// sold and revenue figures of a product are computed from this data.
+package sale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound          = errors.New("sale not found")
+	ErrInvalidQuantity   = errors.New("quantity not valid")
+	ErrInsufficientStock = errors.New("insufficient stock")
+)
+
+// =============================================================================
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	// Create must decrement the quantity of the product and record the sale
+	// atomically. If the product doesn't have enough quantity left the sale
+	// must not be recorded and ErrInsufficientStock is returned.
+	Create(ctx context.Context, sl Sale) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Sale, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, saleID uuid.UUID) (Sale, error)
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+}
+
+// =============================================================================
+
+// Core manages the set of APIs for sale access.
+type Core struct {
+	// A sale is related to a product, the same way a product is related
+	// to a user, so this core can depend on the product core.
+	prdCore  *product.Core
+	storer   Storer
+	delegate *delegate.Delegate
+}
+
+// NewCore constructs a core for sale api access.
+func NewCore(prdCore *product.Core, storer Storer, options ...Option) *Core {
+	c := Core{
+		prdCore: prdCore,
+		storer:  storer,
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	prdCore, err := c.prdCore.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		prdCore:  prdCore,
+		storer:   trS,
+		delegate: c.delegate,
+	}
+
+	return c, nil
+}
+
+// Create records a new sale of a product. The amount paid is calculated from
+// the current cost of the product.
+func (c *Core) Create(ctx context.Context, ns NewSale) (Sale, error) {
+	if ns.Quantity <= 0 {
+		return Sale{}, ErrInvalidQuantity
+	}
+
+	prd, err := c.prdCore.QueryByID(ctx, ns.ProductID)
+	if err != nil {
+		return Sale{}, fmt.Errorf("product.querybyid: %s: %w", ns.ProductID, err)
+	}
+
+	if prd.Quantity < ns.Quantity {
+		return Sale{}, ErrInsufficientStock
+	}
+
+	sl := Sale{
+		ID:          uuid.New(),
+		ProductID:   prd.ID,
+		UserID:      ns.UserID,
+		Quantity:    ns.Quantity,
+		Paid:        prd.Cost * float64(ns.Quantity),
+		DateCreated: time.Now(),
+	}
+
+	if err := c.storer.Create(ctx, sl); err != nil {
+		return Sale{}, fmt.Errorf("create: %w", err)
+	}
+
+	// The stock was taken by the store, under a transaction the product
+	// stays locked until it commits so this is what the sale left.
+	prd, err = c.prdCore.QueryByID(ctx, prd.ID)
+	if err != nil {
+		return Sale{}, fmt.Errorf("product.querybyid: %s: %w", sl.ProductID, err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionCreatedData(sl, prd.TenantID, prd.Quantity)); err != nil {
+		return Sale{}, fmt.Errorf("create: %w", err)
+	}
+
+	return sl, nil
+}
+
+// Query retrieves a list of existing sales.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Sale, error) {
+	sls, err := c.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return sls, nil
+}
+
+// Count returns the total number of sales.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	return c.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the sale by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, saleID uuid.UUID) (Sale, error) {
+	sl, err := c.storer.QueryByID(ctx, saleID)
+	if err != nil {
+		return Sale{}, fmt.Errorf("query: saleID[%s]: %w", saleID, err)
+	}
+
+	return sl, nil
+}
@@ -0,0 +1,184 @@
+package sale_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Sale(t *testing.T) {
+	t.Run("stock", stock)
+	t.Run("history", history)
+}
+
+// =============================================================================
+
+func stock(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	prd, err := api.Product.Create(ctx, product.NewProduct{
+		UserID:   usr.ID,
+		Name:     "Comic Books",
+		Cost:     10,
+		Quantity: 5,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create a product : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	sl, err := api.Sale.Create(ctx, sale.NewSale{ProductID: prd.ID, UserID: usr.ID, Quantity: 3})
+	if err != nil {
+		t.Fatalf("Should be able to record a sale : %s.", err)
+	}
+
+	if sl.Paid != 30 {
+		t.Logf("got: %v", sl.Paid)
+		t.Logf("exp: %v", 30)
+		t.Errorf("Should pay the cost of the product for each unit")
+	}
+
+	if _, err := api.Sale.Create(ctx, sale.NewSale{ProductID: prd.ID, UserID: usr.ID, Quantity: 3}); !errors.Is(err, sale.ErrInsufficientStock) {
+		t.Fatalf("Should NOT be able to sell more than the stock : %s.", err)
+	}
+
+	saved, err := api.Product.QueryByID(ctx, prd.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve product by ID: %s.", err)
+	}
+
+	if saved.Quantity != 2 || saved.Sold != 3 || saved.Revenue != 30 {
+		t.Logf("got: quantity[%d] sold[%d] revenue[%v]", saved.Quantity, saved.Sold, saved.Revenue)
+		t.Logf("exp: quantity[%d] sold[%d] revenue[%v]", 2, 3, 30)
+		t.Errorf("Should see the sale reflected in the product")
+	}
+
+	got, err := api.Sale.QueryByID(ctx, sl.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve sale by ID: %s.", err)
+	}
+
+	if got.Quantity != sl.Quantity || got.ProductID != sl.ProductID {
+		t.Errorf("Should get back the same sale")
+	}
+
+	n, err := api.Sale.Count(ctx, sale.QueryFilter{ProductID: &prd.ID})
+	if err != nil {
+		t.Fatalf("Should be able to count sales : %s.", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Should only have recorded a single sale, got %d", n)
+	}
+}
+
+func history(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	prd, err := api.Product.Create(ctx, product.NewProduct{
+		UserID:   usr.ID,
+		Name:     "Comic Books",
+		Cost:     10,
+		Quantity: 5,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to create a product : %s.", err)
+	}
+
+	if _, err := api.Sale.Create(ctx, sale.NewSale{ProductID: prd.ID, UserID: usr.ID, Quantity: 2}); err != nil {
+		t.Fatalf("Should be able to record a sale : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := api.Product.Delete(ctx, prd); !errors.Is(err, product.ErrHasSales) {
+		t.Fatalf("Should NOT be able to delete a product that was sold : %v.", err)
+	}
+
+	if err := api.User.Delete(ctx, usr); !errors.Is(err, product.ErrHasSales) && !errors.Is(err, user.ErrHasSales) {
+		t.Fatalf("Should NOT be able to delete a user with sales : %v.", err)
+	}
+
+	n, err := api.Sale.Count(ctx, sale.QueryFilter{ProductID: &prd.ID})
+	if err != nil {
+		t.Fatalf("Should be able to count sales : %s.", err)
+	}
+
+	if n != 1 {
+		t.Errorf("Should keep the sale of the product, got %d", n)
+	}
+}
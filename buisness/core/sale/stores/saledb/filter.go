@@ -0,0 +1,64 @@
+package saledb
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+)
+
+// scopeClause limits sales to the ones of products of the tenant.
+const scopeClause = "product_id IN (SELECT product_id FROM products WHERE tenant_id = :scope_tenant_id)"
+
+// applyFilter adds the filter to the query. A context limited to a tenant
+// only sees the sales of the products of that tenant.
+func (s *Store) applyFilter(ctx context.Context, filter sale.QueryFilter, data map[string]interface{}, buf *bytes.Buffer) {
+	var wc []string
+
+	if tenantID, ok := tenant.GetScope(ctx); ok {
+		data["scope_tenant_id"] = tenantID
+		wc = append(wc, scopeClause)
+	}
+
+	if filter.ID != nil {
+		data["sale_id"] = *filter.ID
+		wc = append(wc, "sale_id = :sale_id")
+	}
+
+	if filter.ProductID != nil {
+		data["product_id"] = *filter.ProductID
+		wc = append(wc, "product_id = :product_id")
+	}
+
+	if filter.UserID != nil {
+		data["user_id"] = *filter.UserID
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	if filter.StartCreatedDate != nil {
+		data["start_date_created"] = *filter.StartCreatedDate
+		wc = append(wc, "date_created >= :start_date_created")
+	}
+
+	if filter.EndCreatedDate != nil {
+		data["end_date_created"] = *filter.EndCreatedDate
+		wc = append(wc, "date_created <= :end_date_created")
+	}
+
+	// Add string "WHERE" if wc is not empty
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
+
+// applyScope limits a query that already has a WHERE clause to the tenant
+// of the context.
+func applyScope(ctx context.Context, data map[string]interface{}, buf *bytes.Buffer) {
+	if tenantID, ok := tenant.GetScope(ctx); ok {
+		data["scope_tenant_id"] = tenantID
+		buf.WriteString(" AND " + scopeClause)
+	}
+}
@@ -0,0 +1,48 @@
+package saledb
+
+import (
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/google/uuid"
+)
+
+// dbSale represents an individual sale.
+type dbSale struct {
+	ID          uuid.UUID `db:"sale_id"`
+	ProductID   uuid.UUID `db:"product_id"`
+	UserID      uuid.UUID `db:"user_id"`
+	Quantity    int       `db:"quantity"`
+	Paid        float64   `db:"paid"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+func toDBSale(sl sale.Sale) dbSale {
+	return dbSale{
+		ID:          sl.ID,
+		ProductID:   sl.ProductID,
+		UserID:      sl.UserID,
+		Quantity:    sl.Quantity,
+		Paid:        sl.Paid,
+		DateCreated: sl.DateCreated.UTC(),
+	}
+}
+
+func toCoreSale(dbSl dbSale) sale.Sale {
+	return sale.Sale{
+		ID:          dbSl.ID,
+		ProductID:   dbSl.ProductID,
+		UserID:      dbSl.UserID,
+		Quantity:    dbSl.Quantity,
+		Paid:        dbSl.Paid,
+		DateCreated: dbSl.DateCreated.In(time.Local),
+	}
+}
+
+func toCoreSaleSlice(dbSales []dbSale) []sale.Sale {
+	sls := make([]sale.Sale, len(dbSales))
+	for i, dbSl := range dbSales {
+		sls[i] = toCoreSale(dbSl)
+	}
+	return sls
+}
@@ -0,0 +1,26 @@
+package saledb
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+)
+
+var orderByFields = map[string]string{
+	sale.OrderBySaleID:      "sale_id",
+	sale.OrderByProductID:   "product_id",
+	sale.OrderByUserID:      "user_id",
+	sale.OrderByQuantity:    "quantity",
+	sale.OrderByPaid:        "paid",
+	sale.OrderByDateCreated: "date_created",
+}
+
+func orderByClause(orderBy order.By) (string, error) {
+	by, exists := orderByFields[orderBy.Field]
+	if !exists {
+		return "", fmt.Errorf("field %q does not exist", orderBy.Field)
+	}
+
+	return " ORDER BY " + by + " " + orderBy.Direction, nil
+}
@@ -0,0 +1,207 @@
+// Package saledb contains sale related CRUD functionality.
+package saledb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for sale database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+
+	// sqlxDB is only set when the store is not already executing inside a
+	// transaction, so Create knows if it needs to begin its own.
+	sqlxDB *sqlx.DB
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log:    log,
+		db:     db,
+		sqlxDB: db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (sale.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create decrements the quantity of the product and inserts the sale in a
+// single database transaction. The decrement only happens when the product
+// has enough quantity left, so two concurrent sales can't oversell it. If the
+// store is already executing inside a transaction, that one is used.
+func (s *Store) Create(ctx context.Context, sl sale.Sale) (err error) {
+	if s.sqlxDB == nil {
+		return s.create(ctx, s.db, sl)
+	}
+
+	tx, err := s.sqlxDB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begintxx: %w", err)
+	}
+
+	defer func() {
+		if errTx := tx.Rollback(); errTx != nil {
+			if errors.Is(errTx, sql.ErrTxDone) {
+				return
+			}
+			err = fmt.Errorf("rollback: %w", errTx)
+		}
+	}()
+
+	if err := s.create(ctx, tx, sl); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) create(ctx context.Context, tx sqlx.ExtContext, sl sale.Sale) error {
+	dbSl := toDBSale(sl)
+
+	const qStock = `
+	UPDATE
+		products
+	SET
+		"quantity" = quantity - :quantity,
+		"date_updated" = :date_created
+	WHERE
+		product_id = :product_id AND quantity >= :quantity
+	RETURNING
+		quantity`
+
+	var stock struct {
+		Quantity int `db:"quantity"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, tx, qStock, dbSl, &stock); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", sale.ErrInsufficientStock)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	const qSale = `
+	INSERT INTO sales
+		(sale_id, product_id, user_id, quantity, paid, date_created)
+	VALUES
+		(:sale_id, :product_id, :user_id, :quantity, :paid, :date_created)`
+
+	if err := db.NamedExecContext(ctx, s.log, tx, qSale, dbSl); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing sales from the database.
+func (s *Store) Query(ctx context.Context, filter sale.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]sale.Sale, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		sale_id, product_id, user_id, quantity, paid, date_created
+	FROM
+		sales`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbSls []dbSale
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbSls); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreSaleSlice(dbSls), nil
+}
+
+// Count returns the total number of sales in the DB.
+func (s *Store) Count(ctx context.Context, filter sale.QueryFilter) (int, error) {
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		sales`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID finds the sale identified by a given ID.
+func (s *Store) QueryByID(ctx context.Context, saleID uuid.UUID) (sale.Sale, error) {
+	data := map[string]interface{}{
+		"sale_id": saleID.String(),
+	}
+
+	const q = `
+	SELECT
+		sale_id, product_id, user_id, quantity, paid, date_created
+	FROM
+		sales
+	WHERE
+		sale_id = :sale_id`
+
+	buf := bytes.NewBufferString(q)
+	applyScope(ctx, data, buf)
+
+	var dbSl dbSale
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbSl); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return sale.Sale{}, fmt.Errorf("namedquerystruct: %w", sale.ErrNotFound)
+		}
+		return sale.Sale{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreSale(dbSl), nil
+}
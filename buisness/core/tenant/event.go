@@ -0,0 +1,83 @@
+package tenant
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// DomainName represents the name of this domain for delegate events.
+const DomainName = "tenant"
+
+// Set of delegate actions the tenant domain publishes.
+const (
+	// ActionCreated is published once a tenant is stored.
+	ActionCreated = "created"
+
+	// ActionUpdated is published once a tenant is updated.
+	ActionUpdated = "updated"
+
+	// ActionDeleted is published once a tenant is removed.
+	ActionDeleted = "deleted"
+)
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithDelegate sets the delegate the events of the tenant domain are
+// published to. Without it the events go nowhere.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// ActionParms represents the parameters for the actions of the tenant
+// domain. Only the ID is marshaled, Before and After are the tenant before
+// and after the change for the handlers in the service.
+type ActionParms struct {
+	TenantID uuid.UUID `json:"tenantID"`
+	Before   *Tenant   `json:"-"`
+	After    *Tenant   `json:"-"`
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(tnt Tenant) delegate.Data {
+	return actionData(ActionCreated, nil, &tnt)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(before Tenant, tnt Tenant) delegate.Data {
+	return actionData(ActionUpdated, &before, &tnt)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(tnt Tenant) delegate.Data {
+	return actionData(ActionDeleted, &tnt, nil)
+}
+
+// ParseActionParms returns the parameters of an action of the tenant
+// domain.
+func ParseActionParms(data delegate.Data) (ActionParms, error) {
+	params, ok := data.Params.(ActionParms)
+	if !ok {
+		return ActionParms{}, fmt.Errorf("unexpected params type %T for %s.%s", data.Params, data.Domain, data.Action)
+	}
+
+	return params, nil
+}
+
+func actionData(action string, before *Tenant, after *Tenant) delegate.Data {
+	tnt := after
+	if tnt == nil {
+		tnt = before
+	}
+
+	return delegate.Data{
+		Domain: DomainName,
+		Action: action,
+		Params: ActionParms{TenantID: tnt.ID, Before: before, After: after},
+	}
+}
@@ -0,0 +1,32 @@
+package tenant
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"github.com/google/uuid"
+)
+
+// QueryFilter holds the available fields a query can be filtered on.
+type QueryFilter struct {
+	ID   *uuid.UUID `validate:"omitempty"`
+	Name *string    `validate:"omitempty,min=3"`
+}
+
+// Validate checks the data in the model is considered clean.
+func (qf *QueryFilter) Validate() error {
+	if err := validate.Check(qf); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	return nil
+}
+
+// WithTenantID sets the ID field of the QueryFilter value.
+func (qf *QueryFilter) WithTenantID(tenantID uuid.UUID) {
+	qf.ID = &tenantID
+}
+
+// WithName sets the Name field of the QueryFilter value.
+func (qf *QueryFilter) WithName(name string) {
+	qf.Name = &name
+}
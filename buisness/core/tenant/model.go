@@ -0,0 +1,26 @@
+package tenant
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant represents an organization. Every user is a member of exactly one
+// tenant and the data of a tenant is kept apart from the others.
+type Tenant struct {
+	ID          uuid.UUID
+	Name        string
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewTenant contains information needed to create a new tenant.
+type NewTenant struct {
+	Name string
+}
+
+// UpdateTenant contains information needed to update a tenant.
+type UpdateTenant struct {
+	Name *string
+}
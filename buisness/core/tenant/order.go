@@ -0,0 +1,13 @@
+package tenant
+
+import "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+
+// DefaultOrderBy represents the default way we sort.
+var DefaultOrderBy = order.NewBy(OrderByID, order.ASC)
+
+// Set of fields that the results can be ordered by. These are the names
+// that should be used by the application layer.
+const (
+	OrderByID   = "tenant_id"
+	OrderByName = "name"
+)
@@ -0,0 +1,48 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// scope is the tenant a context is limited to.
+type scope struct {
+	tenantID uuid.UUID
+	all      bool
+}
+
+type ctxKey int
+
+const scopeKey ctxKey = 1
+
+// SetScope limits the data the stores return for the context to the
+// specified tenant.
+func SetScope(ctx context.Context, tenantID uuid.UUID) context.Context {
+	return context.WithValue(ctx, scopeKey, scope{tenantID: tenantID})
+}
+
+// SetScopeAll lets the context see the data of every tenant. It is meant for
+// super admins.
+func SetScopeAll(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey, scope{all: true})
+}
+
+// GetScope returns the tenant the context is limited to. It only returns
+// false when the context was set with SetScopeAll, which is the case for
+// super admins, for the calls the system makes before a user is
+// authenticated, like logging in, and for the work done in the background.
+// A context without a scope is limited to the zero tenant, which has no data,
+// so a path that forgot to set the scope sees nothing.
+func GetScope(ctx context.Context) (uuid.UUID, bool) {
+	s, ok := ctx.Value(scopeKey).(scope)
+	if !ok {
+		return uuid.UUID{}, true
+	}
+
+	if s.all {
+		return uuid.UUID{}, false
+	}
+
+	return s.tenantID, true
+}
@@ -0,0 +1,46 @@
+package tenantdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+)
+
+// applyFilter adds the filter to the query. A context limited to a tenant
+// only sees that tenant.
+func (s *Store) applyFilter(ctx context.Context, filter tenant.QueryFilter, data map[string]interface{}, buf *bytes.Buffer) {
+	var wc []string
+
+	if tenantID, ok := tenant.GetScope(ctx); ok {
+		data["scope_tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :scope_tenant_id")
+	}
+
+	if filter.ID != nil {
+		data["tenant_id"] = *filter.ID
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
+	if filter.Name != nil {
+		data["name"] = fmt.Sprintf("%%%s%%", *filter.Name)
+		wc = append(wc, "name LIKE :name")
+	}
+
+	// Add string "WHERE" if wc is not empty
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
+
+// applyScope limits a query that already has a WHERE clause to the tenant
+// of the context.
+func applyScope(ctx context.Context, data map[string]interface{}, buf *bytes.Buffer) {
+	if tenantID, ok := tenant.GetScope(ctx); ok {
+		data["scope_tenant_id"] = tenantID
+		buf.WriteString(" AND tenant_id = :scope_tenant_id")
+	}
+}
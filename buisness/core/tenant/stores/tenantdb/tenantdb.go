@@ -0,0 +1,200 @@
+// Package tenantdb contains tenant related CRUD functionality.
+package tenantdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for tenant database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (tenant.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new tenant into the database.
+func (s *Store) Create(ctx context.Context, tnt tenant.Tenant) error {
+	const q = `
+	INSERT INTO tenants
+		(tenant_id, name, date_created, date_updated)
+	VALUES
+		(:tenant_id, :name, :date_created, :date_updated)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBTenant(tnt)); err != nil {
+		if errors.Is(err, db.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", tenant.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a tenant document in the database.
+func (s *Store) Update(ctx context.Context, tnt tenant.Tenant) error {
+	const q = `
+	UPDATE
+		tenants
+	SET
+		"name" = :name,
+		"date_updated" = :date_updated
+	WHERE
+		tenant_id = :tenant_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBTenant(tnt)); err != nil {
+		if errors.Is(err, db.ErrDBDuplicatedEntry) {
+			return fmt.Errorf("namedexeccontext: %w", tenant.ErrUniqueName)
+		}
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a tenant without users from the database. The check and the
+// delete are one statement so a user can't be added in between.
+func (s *Store) Delete(ctx context.Context, tnt tenant.Tenant) error {
+	data := struct {
+		ID string `db:"tenant_id"`
+	}{
+		ID: tnt.ID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		tenants
+	WHERE
+		tenant_id = :tenant_id AND
+		NOT EXISTS (SELECT 1 FROM users WHERE tenant_id = :tenant_id)
+	RETURNING
+		tenant_id`
+
+	var deleted struct {
+		ID string `db:"tenant_id"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &deleted); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return fmt.Errorf("namedquerystruct: %w", tenant.ErrNotEmpty)
+		}
+		return fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing tenants from the database.
+func (s *Store) Query(ctx context.Context, filter tenant.QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]tenant.Tenant, error) {
+	data := map[string]interface{}{
+		"offset":        (pageNumber - 1) * rowsPerPage,
+		"rows_per_page": rowsPerPage,
+	}
+
+	const q = `
+	SELECT
+		tenant_id, name, date_created, date_updated
+	FROM
+		tenants`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	buf.WriteString(orderByClause)
+	buf.WriteString(" OFFSET :offset ROWS FETCH NEXT :rows_per_page ROWS ONLY")
+
+	var dbTnts []dbTenant
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbTnts); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreTenantSlice(dbTnts), nil
+}
+
+// Count returns the total number of tenants in the DB.
+func (s *Store) Count(ctx context.Context, filter tenant.QueryFilter) (int, error) {
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		tenants`
+
+	buf := bytes.NewBufferString(q)
+	s.applyFilter(ctx, filter, data, buf)
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// QueryByID gets the specified tenant from the database.
+func (s *Store) QueryByID(ctx context.Context, tenantID uuid.UUID) (tenant.Tenant, error) {
+	data := map[string]interface{}{
+		"tenant_id": tenantID.String(),
+	}
+
+	const q = `
+	SELECT
+		tenant_id, name, date_created, date_updated
+	FROM
+		tenants
+	WHERE
+		tenant_id = :tenant_id`
+
+	buf := bytes.NewBufferString(q)
+	applyScope(ctx, data, buf)
+
+	var dbTnt dbTenant
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbTnt); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return tenant.Tenant{}, fmt.Errorf("namedquerystruct: %w", tenant.ErrNotFound)
+		}
+		return tenant.Tenant{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreTenant(dbTnt), nil
+}
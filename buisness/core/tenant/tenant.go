@@ -0,0 +1,157 @@
+// Package tenant provides the core business API for the organizations users
+// are members of, and the scope that keeps the data of each one apart.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound   = errors.New("tenant not found")
+	ErrUniqueName = errors.New("name is not unique")
+	ErrNotEmpty   = errors.New("tenant still has users")
+)
+
+// =============================================================================
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, tnt Tenant) error
+	Update(ctx context.Context, tnt Tenant) error
+	// Delete must only remove a tenant without users, returning ErrNotEmpty
+	// otherwise.
+	Delete(ctx context.Context, tnt Tenant) error
+	Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Tenant, error)
+	Count(ctx context.Context, filter QueryFilter) (int, error)
+	QueryByID(ctx context.Context, tenantID uuid.UUID) (Tenant, error)
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+}
+
+// =============================================================================
+
+// Core manages the set of APIs for tenant access.
+type Core struct {
+	storer   Storer
+	delegate *delegate.Delegate
+}
+
+// NewCore constructs a core for tenant api access.
+func NewCore(storer Storer, options ...Option) *Core {
+	c := Core{
+		storer: storer,
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		storer:   trS,
+		delegate: c.delegate,
+	}
+
+	return c, nil
+}
+
+// Create adds a new tenant to the system.
+func (c *Core) Create(ctx context.Context, nt NewTenant) (Tenant, error) {
+	now := time.Now()
+
+	tnt := Tenant{
+		ID:          uuid.New(),
+		Name:        nt.Name,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.storer.Create(ctx, tnt); err != nil {
+		return Tenant{}, fmt.Errorf("create: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionCreatedData(tnt)); err != nil {
+		return Tenant{}, fmt.Errorf("create: %w", err)
+	}
+
+	return tnt, nil
+}
+
+// Update modifies information about a tenant.
+func (c *Core) Update(ctx context.Context, tnt Tenant, ut UpdateTenant) (Tenant, error) {
+	before := tnt
+
+	if ut.Name != nil {
+		tnt.Name = *ut.Name
+	}
+
+	tnt.DateUpdated = time.Now()
+
+	if err := c.storer.Update(ctx, tnt); err != nil {
+		return Tenant{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionUpdatedData(before, tnt)); err != nil {
+		return Tenant{}, fmt.Errorf("update: %w", err)
+	}
+
+	return tnt, nil
+}
+
+// Delete removes the specified tenant. A tenant can only be removed once it
+// has no users left.
+func (c *Core) Delete(ctx context.Context, tnt Tenant) error {
+	if err := c.storer.Delete(ctx, tnt); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionDeletedData(tnt)); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// Query retrieves a list of existing tenants.
+func (c *Core) Query(ctx context.Context, filter QueryFilter, orderBy order.By, pageNumber int, rowsPerPage int) ([]Tenant, error) {
+	tnts, err := c.storer.Query(ctx, filter, orderBy, pageNumber, rowsPerPage)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return tnts, nil
+}
+
+// Count returns the total number of tenants.
+func (c *Core) Count(ctx context.Context, filter QueryFilter) (int, error) {
+	return c.storer.Count(ctx, filter)
+}
+
+// QueryByID finds the tenant by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, tenantID uuid.UUID) (Tenant, error) {
+	tnt, err := c.storer.QueryByID(ctx, tenantID)
+	if err != nil {
+		return Tenant{}, fmt.Errorf("query: tenantID[%s]: %w", tenantID, err)
+	}
+
+	return tnt, nil
+}
@@ -0,0 +1,254 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Tenant(t *testing.T) {
+	t.Run("crud", crud)
+	t.Run("scope", scope)
+}
+
+// =============================================================================
+
+func crud(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	tnt, err := api.Tenant.Create(ctx, tenant.NewTenant{Name: "Acme"})
+	if err != nil {
+		t.Fatalf("Should be able to create a tenant : %s.", err)
+	}
+
+	if _, err := api.Tenant.Create(ctx, tenant.NewTenant{Name: "Acme"}); !errors.Is(err, tenant.ErrUniqueName) {
+		t.Fatalf("Should NOT be able to create a tenant with the same name : %s.", err)
+	}
+
+	name := "Acme Corp"
+	tnt, err = api.Tenant.Update(ctx, tnt, tenant.UpdateTenant{Name: &name})
+	if err != nil {
+		t.Fatalf("Should be able to update a tenant : %s.", err)
+	}
+
+	saved, err := api.Tenant.QueryByID(ctx, tnt.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve tenant by ID : %s.", err)
+	}
+
+	if saved.Name != name {
+		t.Logf("got: %v", saved.Name)
+		t.Logf("exp: %v", name)
+		t.Errorf("Should be able to see updates to Name")
+	}
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	seeded, err := api.Tenant.QueryByID(ctx, usr.TenantID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the tenant of the seeded user : %s.", err)
+	}
+
+	if err := api.Tenant.Delete(ctx, seeded); !errors.Is(err, tenant.ErrNotEmpty) {
+		t.Fatalf("Should NOT be able to delete a tenant with users : %s.", err)
+	}
+
+	if err := api.Tenant.Delete(ctx, tnt); err != nil {
+		t.Fatalf("Should be able to delete a tenant without users : %s.", err)
+	}
+
+	if _, err := api.Tenant.QueryByID(ctx, tnt.ID); !errors.Is(err, tenant.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve a deleted tenant : %s.", err)
+	}
+}
+
+func scope(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	member, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	other, err := api.Tenant.Create(ctx, tenant.NewTenant{Name: "Other"})
+	if err != nil {
+		t.Fatalf("Should be able to create a tenant : %s.", err)
+	}
+
+	otherEmail, err := mail.ParseAddress("other@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	nu := user.NewUser{
+		TenantID: other.ID,
+		Name:     "Other Gopher",
+		Email:    *otherEmail,
+		Roles:    []user.Role{user.RoleAdmin},
+		Password: "Other-Gopher-42",
+	}
+
+	otherUsr, err := api.User.Create(ctx, nu)
+	if err != nil {
+		t.Fatalf("Should be able to create a user in the other tenant : %s.", err)
+	}
+
+	np := product.NewProduct{
+		UserID:   otherUsr.ID,
+		Name:     "Other Product",
+		Cost:     10,
+		Quantity: 5,
+	}
+
+	otherPrd, err := api.Product.Create(ctx, np)
+	if err != nil {
+		t.Fatalf("Should be able to create a product in the other tenant : %s.", err)
+	}
+
+	if otherPrd.TenantID != other.ID {
+		t.Logf("got: %v", otherPrd.TenantID)
+		t.Logf("exp: %v", other.ID)
+		t.Errorf("Should create the product in the tenant of its user")
+	}
+
+	// -------------------------------------------------------------------------
+
+	scoped := tenant.SetScope(ctx, member.TenantID)
+
+	if _, err := api.User.QueryByID(scoped, otherUsr.ID); !errors.Is(err, user.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve a user of another tenant : %s.", err)
+	}
+
+	if _, err := api.User.QueryByEmail(scoped, *otherEmail); !errors.Is(err, user.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve a user of another tenant by email : %s.", err)
+	}
+
+	if _, err := api.Product.QueryByID(scoped, otherPrd.ID); !errors.Is(err, product.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve a product of another tenant : %s.", err)
+	}
+
+	usrs, err := api.User.Query(scoped, user.QueryFilter{}, user.DefaultOrderBy, 1, 100)
+	if err != nil {
+		t.Fatalf("Should be able to query users : %s.", err)
+	}
+
+	for _, usr := range usrs {
+		if usr.TenantID != member.TenantID {
+			t.Fatalf("Should only retrieve users of the tenant, got %s", usr.TenantID)
+		}
+	}
+
+	n, err := api.Product.Count(scoped, product.QueryFilter{})
+	if err != nil {
+		t.Fatalf("Should be able to count products : %s.", err)
+	}
+
+	if n != 0 {
+		t.Logf("got: %v", n)
+		t.Logf("exp: %v", 0)
+		t.Errorf("Should not count the products of another tenant")
+	}
+
+	if _, err := api.Tenant.QueryByID(scoped, other.ID); !errors.Is(err, tenant.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve another tenant : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	// A context nobody set the scope on sees nothing.
+	unscoped := context.Background()
+
+	if _, err := api.User.QueryByID(unscoped, otherUsr.ID); !errors.Is(err, user.ErrNotFound) {
+		t.Fatalf("Should NOT be able to retrieve a user without a scope : %s.", err)
+	}
+
+	n, err = api.Product.Count(unscoped, product.QueryFilter{})
+	if err != nil {
+		t.Fatalf("Should be able to count products : %s.", err)
+	}
+
+	if n != 0 {
+		t.Logf("got: %v", n)
+		t.Logf("exp: %v", 0)
+		t.Errorf("Should not count any product without a scope")
+	}
+
+	// -------------------------------------------------------------------------
+
+	all := tenant.SetScopeAll(ctx)
+
+	if _, err := api.User.QueryByID(all, otherUsr.ID); err != nil {
+		t.Fatalf("Should be able to retrieve a user of any tenant : %s.", err)
+	}
+
+	if _, err := api.Product.QueryByID(all, otherPrd.ID); err != nil {
+		t.Fatalf("Should be able to retrieve a product of any tenant : %s.", err)
+	}
+}
@@ -0,0 +1,102 @@
+package token
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// DomainName represents the name of this domain for delegate events.
+const DomainName = "token"
+
+// Set of delegate actions the token domain publishes.
+const (
+	// ActionRevoked is published once a refresh token is revoked.
+	ActionRevoked = "revoked"
+
+	// ActionUserRevoked is published once all the refresh tokens of a user
+	// are revoked.
+	ActionUserRevoked = "user_revoked"
+
+	// ActionAccessRevoked is published once an access token is added to the
+	// denylist.
+	ActionAccessRevoked = "access_revoked"
+)
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithDelegate sets the delegate the events of the token domain are
+// published to and the events of the other domains are handled from.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// ActionParms represents the parameters for the actions of the token
+// domain. TokenID is only set for a refresh token and JTI for an access
+// token.
+type ActionParms struct {
+	TokenID uuid.UUID `json:"tokenID"`
+	UserID  uuid.UUID `json:"userID"`
+	JTI     string    `json:"jti"`
+}
+
+// ActionRevokedData constructs the data for the revoked action.
+func ActionRevokedData(rt RefreshToken) delegate.Data {
+	return actionData(ActionRevoked, ActionParms{TokenID: rt.ID, UserID: rt.UserID})
+}
+
+// ActionUserRevokedData constructs the data for the user revoked action.
+func ActionUserRevokedData(userID uuid.UUID) delegate.Data {
+	return actionData(ActionUserRevoked, ActionParms{UserID: userID})
+}
+
+// ActionAccessRevokedData constructs the data for the access revoked action.
+func ActionAccessRevokedData(ra RevokedAccess) delegate.Data {
+	return actionData(ActionAccessRevoked, ActionParms{JTI: ra.JTI})
+}
+
+// ParseActionParms returns the parameters of an action of the token domain.
+func ParseActionParms(data delegate.Data) (ActionParms, error) {
+	params, ok := data.Params.(ActionParms)
+	if !ok {
+		return ActionParms{}, fmt.Errorf("unexpected params type %T for %s.%s", data.Params, data.Domain, data.Action)
+	}
+
+	return params, nil
+}
+
+func actionData(action string, params ActionParms) delegate.Data {
+	return delegate.Data{
+		Domain: DomainName,
+		Action: action,
+		Params: params,
+	}
+}
+
+// =============================================================================
+
+// userDisabled revokes the refresh tokens of a user that was disabled, so
+// the user can't get new access tokens.
+func (c *Core) userDisabled(ctx context.Context, data delegate.Data) error {
+	params, err := user.ParseActionParms(data)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+
+	if tx, ok := transaction.Get(ctx); ok {
+		c, err = c.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.RevokeByUserID(ctx, params.UserID)
+}
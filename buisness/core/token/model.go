@@ -0,0 +1,28 @@
+package token
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken represents a long-lived refresh token issued to a user. Only
+// the hash of the token is kept, the token itself is given to the client once.
+// AMR holds the methods the user authenticated with, the access tokens issued
+// with the refresh token carry them.
+type RefreshToken struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Hash        string
+	AMR         []string
+	DateExpires time.Time
+	DateCreated time.Time
+	DateRevoked time.Time
+}
+
+// RevokedAccess represents an access token that was revoked before it
+// expired. It only needs to be remembered until the token expires.
+type RevokedAccess struct {
+	JTI         string
+	DateExpires time.Time
+}
@@ -0,0 +1,81 @@
+package tokendb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/dbarray"
+	"github.com/google/uuid"
+)
+
+// dbRefreshToken represents an individual refresh token.
+type dbRefreshToken struct {
+	ID          uuid.UUID      `db:"token_id"`
+	UserID      uuid.UUID      `db:"user_id"`
+	Hash        string         `db:"token_hash"`
+	AMR         dbarray.String `db:"amr"`
+	DateExpires time.Time      `db:"date_expires"`
+	DateCreated time.Time      `db:"date_created"`
+	DateRevoked sql.NullTime   `db:"date_revoked"`
+}
+
+func toDBRefreshToken(rt token.RefreshToken) dbRefreshToken {
+	// A nil slice would be stored as NULL.
+	amr := rt.AMR
+	if amr == nil {
+		amr = []string{}
+	}
+
+	return dbRefreshToken{
+		ID:          rt.ID,
+		UserID:      rt.UserID,
+		Hash:        rt.Hash,
+		AMR:         amr,
+		DateExpires: rt.DateExpires.UTC(),
+		DateCreated: rt.DateCreated.UTC(),
+		DateRevoked: sql.NullTime{
+			Time:  rt.DateRevoked.UTC(),
+			Valid: !rt.DateRevoked.IsZero(),
+		},
+	}
+}
+
+func toCoreRefreshToken(dbRT dbRefreshToken) token.RefreshToken {
+	rt := token.RefreshToken{
+		ID:          dbRT.ID,
+		UserID:      dbRT.UserID,
+		Hash:        dbRT.Hash,
+		AMR:         dbRT.AMR,
+		DateExpires: dbRT.DateExpires.In(time.Local),
+		DateCreated: dbRT.DateCreated.In(time.Local),
+	}
+
+	if dbRT.DateRevoked.Valid {
+		rt.DateRevoked = dbRT.DateRevoked.Time.In(time.Local)
+	}
+
+	return rt
+}
+
+// =============================================================================
+
+// dbRevokedAccess represents an access token in the denylist.
+type dbRevokedAccess struct {
+	JTI         string    `db:"jti"`
+	DateExpires time.Time `db:"date_expires"`
+}
+
+func toDBRevokedAccess(ra token.RevokedAccess) dbRevokedAccess {
+	return dbRevokedAccess{
+		JTI:         ra.JTI,
+		DateExpires: ra.DateExpires.UTC(),
+	}
+}
+
+func toCoreRevokedAccess(dbRA dbRevokedAccess) token.RevokedAccess {
+	return token.RevokedAccess{
+		JTI:         dbRA.JTI,
+		DateExpires: dbRA.DateExpires.In(time.Local),
+	}
+}
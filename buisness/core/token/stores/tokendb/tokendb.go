@@ -0,0 +1,185 @@
+// Package tokendb contains refresh token and denylist related CRUD
+// functionality.
+package tokendb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for token database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (token.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new refresh token into the database.
+func (s *Store) Create(ctx context.Context, rt token.RefreshToken) error {
+	const q = `
+	INSERT INTO refresh_tokens
+		(token_id, user_id, token_hash, amr, date_expires, date_created, date_revoked)
+	VALUES
+		(:token_id, :user_id, :token_hash, :amr, :date_expires, :date_created, :date_revoked)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBRefreshToken(rt)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke marks the refresh token with the specified hash as revoked. The
+// update only matches a token that is not revoked and has not expired.
+func (s *Store) Revoke(ctx context.Context, hash string, now time.Time) (token.RefreshToken, error) {
+	data := struct {
+		Hash string    `db:"token_hash"`
+		Now  time.Time `db:"now"`
+	}{
+		Hash: hash,
+		Now:  now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		"date_revoked" = :now
+	WHERE
+		token_hash = :token_hash AND date_revoked IS NULL AND date_expires > :now
+	RETURNING
+		token_id, user_id, token_hash, amr, date_expires, date_created, date_revoked`
+
+	var dbRT dbRefreshToken
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbRT); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return token.RefreshToken{}, fmt.Errorf("namedquerystruct: %w", token.ErrNotFound)
+		}
+		return token.RefreshToken{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreRefreshToken(dbRT), nil
+}
+
+// RevokeByUserID marks all the active refresh tokens of a user as revoked.
+func (s *Store) RevokeByUserID(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	data := struct {
+		UserID string    `db:"user_id"`
+		Now    time.Time `db:"now"`
+	}{
+		UserID: userID.String(),
+		Now:    now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		refresh_tokens
+	SET
+		"date_revoked" = :now
+	WHERE
+		user_id = :user_id AND date_revoked IS NULL`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// CreateRevokedAccess adds an access token to the denylist. Revoking the
+// same token twice is not an error.
+func (s *Store) CreateRevokedAccess(ctx context.Context, ra token.RevokedAccess) error {
+	const q = `
+	INSERT INTO revoked_tokens
+		(jti, date_expires)
+	VALUES
+		(:jti, :date_expires)
+	ON CONFLICT (jti) DO NOTHING`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBRevokedAccess(ra)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryRevokedAccess finds the access token in the denylist.
+func (s *Store) QueryRevokedAccess(ctx context.Context, jti string) (token.RevokedAccess, error) {
+	data := struct {
+		JTI string `db:"jti"`
+	}{
+		JTI: jti,
+	}
+
+	const q = `
+	SELECT
+		jti, date_expires
+	FROM
+		revoked_tokens
+	WHERE
+		jti = :jti`
+
+	var dbRA dbRevokedAccess
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbRA); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return token.RevokedAccess{}, fmt.Errorf("namedquerystruct: %w", token.ErrNotFound)
+		}
+		return token.RevokedAccess{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreRevokedAccess(dbRA), nil
+}
+
+// DeleteRevokedAccess removes the access tokens from the denylist that
+// expired before the specified time.
+func (s *Store) DeleteRevokedAccess(ctx context.Context, before time.Time) error {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		revoked_tokens
+	WHERE
+		date_expires < :before`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
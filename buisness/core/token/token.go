@@ -0,0 +1,206 @@
+// Package token provides the core business API for refresh tokens and for
+// revoking access tokens before they expire.
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("token not found")
+	ErrInvalidToken = errors.New("refresh token is not valid")
+)
+
+// =============================================================================
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, rt RefreshToken) error
+	// Revoke must only revoke the token when it is not already revoked and
+	// has not expired, returning ErrNotFound otherwise. This keeps a refresh
+	// token from being used twice by concurrent requests.
+	Revoke(ctx context.Context, hash string, now time.Time) (RefreshToken, error)
+	RevokeByUserID(ctx context.Context, userID uuid.UUID, now time.Time) error
+	CreateRevokedAccess(ctx context.Context, ra RevokedAccess) error
+	QueryRevokedAccess(ctx context.Context, jti string) (RevokedAccess, error)
+	DeleteRevokedAccess(ctx context.Context, before time.Time) error
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+}
+
+// =============================================================================
+
+// Core manages the set of APIs for token access.
+type Core struct {
+	storer   Storer
+	delegate *delegate.Delegate
+}
+
+// NewCore constructs a core for token api access.
+func NewCore(storer Storer, options ...Option) *Core {
+	c := Core{
+		storer: storer,
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	c.delegate.Register(user.DomainName, user.ActionDisabled, c.userDisabled)
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		storer:   trS,
+		delegate: c.delegate,
+	}
+
+	return c, nil
+}
+
+// Create issues a new refresh token for the specified user that remembers
+// the methods the user authenticated with. The returned string is the opaque
+// token for the client, it can't be recovered later.
+func (c *Core) Create(ctx context.Context, userID uuid.UUID, amr []string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+	tkn := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+
+	rt := RefreshToken{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Hash:        hash(tkn),
+		AMR:         amr,
+		DateExpires: now.Add(ttl),
+		DateCreated: now,
+	}
+
+	if err := c.storer.Create(ctx, rt); err != nil {
+		return "", fmt.Errorf("create: %w", err)
+	}
+
+	return tkn, nil
+}
+
+// Exchange revokes the specified refresh token and issues a new one for the
+// same user and authentication methods. The revoked token is returned with
+// the new one. A refresh token can only be exchanged once.
+func (c *Core) Exchange(ctx context.Context, tkn string, ttl time.Duration) (RefreshToken, string, error) {
+	rt, err := c.Revoke(ctx, tkn)
+	if err != nil {
+		return RefreshToken{}, "", err
+	}
+
+	newTkn, err := c.Create(ctx, rt.UserID, rt.AMR, ttl)
+	if err != nil {
+		return RefreshToken{}, "", err
+	}
+
+	return rt, newTkn, nil
+}
+
+// Revoke revokes the specified refresh token and returns it.
+func (c *Core) Revoke(ctx context.Context, tkn string) (RefreshToken, error) {
+	rt, err := c.storer.Revoke(ctx, hash(tkn), time.Now())
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return RefreshToken{}, ErrInvalidToken
+		}
+		return RefreshToken{}, fmt.Errorf("revoke: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionRevokedData(rt)); err != nil {
+		return RefreshToken{}, fmt.Errorf("revoke: %w", err)
+	}
+
+	return rt, nil
+}
+
+// RevokeByUserID revokes all the refresh tokens of the specified user.
+func (c *Core) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	if err := c.storer.RevokeByUserID(ctx, userID, time.Now()); err != nil {
+		return fmt.Errorf("revokebyuserid: userID[%s]: %w", userID, err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionUserRevokedData(userID)); err != nil {
+		return fmt.Errorf("revokebyuserid: userID[%s]: %w", userID, err)
+	}
+
+	return nil
+}
+
+// RevokeAccess adds the access token identified by the jti to the denylist
+// until the token expires.
+func (c *Core) RevokeAccess(ctx context.Context, jti string, expires time.Time) error {
+	ra := RevokedAccess{
+		JTI:         jti,
+		DateExpires: expires,
+	}
+
+	if err := c.storer.CreateRevokedAccess(ctx, ra); err != nil {
+		return fmt.Errorf("createrevokedaccess: jti[%s]: %w", jti, err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionAccessRevokedData(ra)); err != nil {
+		return fmt.Errorf("createrevokedaccess: jti[%s]: %w", jti, err)
+	}
+
+	return nil
+}
+
+// IsRevoked reports if the access token identified by the jti was revoked.
+func (c *Core) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if _, err := c.storer.QueryRevokedAccess(ctx, jti); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("queryrevokedaccess: jti[%s]: %w", jti, err)
+	}
+
+	return true, nil
+}
+
+// PurgeRevokedAccess removes the access tokens from the denylist that have
+// expired, they are rejected for being expired by then.
+func (c *Core) PurgeRevokedAccess(ctx context.Context) error {
+	if err := c.storer.DeleteRevokedAccess(ctx, time.Now()); err != nil {
+		return fmt.Errorf("deleterevokedaccess: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// hash returns the value stored for a refresh token. The tokens are random
+// so a fast hash is enough, there is nothing to brute force.
+func hash(tkn string) string {
+	sum := sha256.Sum256([]byte(tkn))
+	return hex.EncodeToString(sum[:])
+}
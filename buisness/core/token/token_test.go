@@ -0,0 +1,204 @@
+package token_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Token(t *testing.T) {
+	t.Run("refresh", refresh)
+	t.Run("denylist", denylist)
+}
+
+// =============================================================================
+
+func refresh(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	tkn, err := api.Token.Create(ctx, usr.ID, []string{"pwd", "otp", "mfa"}, time.Hour)
+	if err != nil {
+		t.Fatalf("Should be able to create a refresh token : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	rt, newTkn, err := api.Token.Exchange(ctx, tkn, time.Hour)
+	if err != nil {
+		t.Fatalf("Should be able to exchange the refresh token : %s.", err)
+	}
+
+	if rt.UserID != usr.ID {
+		t.Logf("got: %v", rt.UserID)
+		t.Logf("exp: %v", usr.ID)
+		t.Errorf("Should get back the user of the refresh token")
+	}
+
+	if len(rt.AMR) != 3 || rt.AMR[2] != "mfa" {
+		t.Logf("got: %v", rt.AMR)
+		t.Logf("exp: %v", []string{"pwd", "otp", "mfa"})
+		t.Errorf("Should get back the authentication methods of the refresh token")
+	}
+
+	if _, _, err := api.Token.Exchange(ctx, tkn, time.Hour); !errors.Is(err, token.ErrInvalidToken) {
+		t.Fatalf("Should NOT be able to exchange a refresh token twice : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := api.Token.RevokeByUserID(ctx, usr.ID); err != nil {
+		t.Fatalf("Should be able to revoke the refresh tokens of the user : %s.", err)
+	}
+
+	if _, _, err := api.Token.Exchange(ctx, newTkn, time.Hour); !errors.Is(err, token.ErrInvalidToken) {
+		t.Fatalf("Should NOT be able to exchange a revoked refresh token : %s.", err)
+	}
+
+	expTkn, err := api.Token.Create(ctx, usr.ID, nil, -time.Minute)
+	if err != nil {
+		t.Fatalf("Should be able to create a refresh token : %s.", err)
+	}
+
+	if _, _, err := api.Token.Exchange(ctx, expTkn, time.Hour); !errors.Is(err, token.ErrInvalidToken) {
+		t.Fatalf("Should NOT be able to exchange an expired refresh token : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	disTkn, err := api.Token.Create(ctx, usr.ID, nil, time.Hour)
+	if err != nil {
+		t.Fatalf("Should be able to create a refresh token : %s.", err)
+	}
+
+	if _, err := api.User.Update(ctx, usr, user.UpdateUser{Enabled: dbtest.BoolPointer(false)}); err != nil {
+		t.Fatalf("Should be able to disable the user : %s.", err)
+	}
+
+	if _, _, err := api.Token.Exchange(ctx, disTkn, time.Hour); !errors.Is(err, token.ErrInvalidToken) {
+		t.Fatalf("Should NOT be able to exchange a refresh token of a disabled user : %s.", err)
+	}
+}
+
+func denylist(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	const jti = "1a8f7c1e-6d0e-4b6a-9a57-0f5f2d8a8d3b"
+
+	revoked, err := api.Token.IsRevoked(ctx, jti)
+	if err != nil {
+		t.Fatalf("Should be able to check the denylist : %s.", err)
+	}
+
+	if revoked {
+		t.Fatalf("Should NOT have the token in the denylist")
+	}
+
+	if err := api.Token.RevokeAccess(ctx, jti, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Should be able to revoke the access token : %s.", err)
+	}
+
+	if err := api.Token.RevokeAccess(ctx, jti, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Should be able to revoke the access token twice : %s.", err)
+	}
+
+	revoked, err = api.Token.IsRevoked(ctx, jti)
+	if err != nil {
+		t.Fatalf("Should be able to check the denylist : %s.", err)
+	}
+
+	if !revoked {
+		t.Fatalf("Should have the token in the denylist")
+	}
+
+	// -------------------------------------------------------------------------
+
+	const expiredJTI = "5b0c3f0e-2f4c-4c1e-8a53-6f1e9b7d2c40"
+
+	if err := api.Token.RevokeAccess(ctx, expiredJTI, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Should be able to revoke the access token : %s.", err)
+	}
+
+	if err := api.Token.PurgeRevokedAccess(ctx); err != nil {
+		t.Fatalf("Should be able to purge the denylist : %s.", err)
+	}
+
+	revoked, err = api.Token.IsRevoked(ctx, expiredJTI)
+	if err != nil {
+		t.Fatalf("Should be able to check the denylist : %s.", err)
+	}
+
+	if revoked {
+		t.Errorf("Should remove the expired token from the denylist")
+	}
+
+	revoked, err = api.Token.IsRevoked(ctx, jti)
+	if err != nil {
+		t.Fatalf("Should be able to check the denylist : %s.", err)
+	}
+
+	if !revoked {
+		t.Errorf("Should keep the token that has not expired in the denylist")
+	}
+}
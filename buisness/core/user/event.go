@@ -0,0 +1,134 @@
+package user
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// DomainName represents the name of this domain for delegate events.
+const DomainName = "user"
+
+// Set of delegate actions the user domain publishes.
+const (
+	// ActionCreated is published once a user is stored.
+	ActionCreated = "created"
+
+	// ActionUpdated is published once a user is updated.
+	ActionUpdated = "updated"
+
+	// ActionDeleted is published before a user is removed, so the handlers
+	// can still see and clean up what belongs to the user.
+	ActionDeleted = "deleted"
+
+	// ActionDisabled is published once a user that was enabled is disabled.
+	ActionDisabled = "disabled"
+
+	// ActionLocked is published once a user is locked by failed logins.
+	ActionLocked = "locked"
+
+	// ActionUnlocked is published once the lock of a user is removed.
+	ActionUnlocked = "unlocked"
+
+	// ActionPasswordRehashed is published once the password of a user is
+	// hashed again with the current cost.
+	ActionPasswordRehashed = "password_rehashed"
+
+	// ActionPasswordReset is published once the password of a user is
+	// reset.
+	ActionPasswordReset = "password_reset"
+
+	// ActionEmailVerified is published once a user verified their email
+	// address.
+	ActionEmailVerified = "email_verified"
+)
+
+// WithDelegate sets the delegate the events of the user domain are published
+// to. Without it the events go nowhere.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// ActionParms represents the parameters for the actions of the user domain.
+// They are marshaled when an event leaves the service, so only the IDs are
+// marshaled. Before and After are the user before and after the change for
+// the handlers in the service, Before is nil for a created user and After
+// for a deleted one.
+type ActionParms struct {
+	UserID   uuid.UUID `json:"userID"`
+	TenantID uuid.UUID `json:"tenantID"`
+	Before   *User     `json:"-"`
+	After    *User     `json:"-"`
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(usr User) delegate.Data {
+	return actionData(ActionCreated, nil, &usr)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(before User, usr User) delegate.Data {
+	return actionData(ActionUpdated, &before, &usr)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(usr User) delegate.Data {
+	return actionData(ActionDeleted, &usr, nil)
+}
+
+// ActionDisabledData constructs the data for the disabled action.
+func ActionDisabledData(before User, usr User) delegate.Data {
+	return actionData(ActionDisabled, &before, &usr)
+}
+
+// ActionLockedData constructs the data for the locked action.
+func ActionLockedData(before User, usr User) delegate.Data {
+	return actionData(ActionLocked, &before, &usr)
+}
+
+// ActionUnlockedData constructs the data for the unlocked action.
+func ActionUnlockedData(before User, usr User) delegate.Data {
+	return actionData(ActionUnlocked, &before, &usr)
+}
+
+// ActionPasswordRehashedData constructs the data for the password rehashed
+// action.
+func ActionPasswordRehashedData(before User, usr User) delegate.Data {
+	return actionData(ActionPasswordRehashed, &before, &usr)
+}
+
+// ActionPasswordResetData constructs the data for the password reset action.
+func ActionPasswordResetData(before User, usr User) delegate.Data {
+	return actionData(ActionPasswordReset, &before, &usr)
+}
+
+// ActionEmailVerifiedData constructs the data for the email verified action.
+func ActionEmailVerifiedData(before User, usr User) delegate.Data {
+	return actionData(ActionEmailVerified, &before, &usr)
+}
+
+// ParseActionParms returns the parameters of an action of the user domain.
+func ParseActionParms(data delegate.Data) (ActionParms, error) {
+	params, ok := data.Params.(ActionParms)
+	if !ok {
+		return ActionParms{}, fmt.Errorf("unexpected params type %T for %s.%s", data.Params, data.Domain, data.Action)
+	}
+
+	return params, nil
+}
+
+func actionData(action string, before *User, after *User) delegate.Data {
+	usr := after
+	if usr == nil {
+		usr = before
+	}
+
+	return delegate.Data{
+		Domain: DomainName,
+		Action: action,
+		Params: ActionParms{UserID: usr.ID, TenantID: usr.TenantID, Before: before, After: after},
+	}
+}
@@ -12,6 +12,7 @@ import (
 // QueryFilter holds the available fields a query can be filtered on.
 type QueryFilter struct {
 	ID               *uuid.UUID    `validate:"omitempty"`
+	TenantID         *uuid.UUID    `validate:"omitempty"`
 	Name             *string       `validate:"omitempty,min=3"`
 	Email            *mail.Address `validate:"omitempty"`
 	StartCreatedDate *time.Time    `validate:"omitempty"`
@@ -33,6 +34,11 @@ func (qf *QueryFilter) WithUserID(userID uuid.UUID) {
 	qf.ID = &userID
 }
 
+// WithTenantID sets the TenantID field of the QueryFilter value.
+func (qf *QueryFilter) WithTenantID(tenantID uuid.UUID) {
+	qf.TenantID = &tenantID
+}
+
 // WithName sets the Name field of the QueryFilter value.
 func (qf *QueryFilter) WithName(name string) {
 	qf.Name = &name
@@ -0,0 +1,44 @@
+package user
+
+import "time"
+
+// Lockout represents the settings for throttling failed logins. A user is
+// locked for Duration once MaxFailures logins failed within Window. An
+// address is refused once MaxAddressFailures logins from it failed within
+// Window, whatever the email. Zero values are replaced by the defaults.
+type Lockout struct {
+	MaxFailures        int
+	MaxAddressFailures int
+	Window             time.Duration
+	Duration           time.Duration
+}
+
+// defaultLockout is used when the core is constructed without a lockout.
+var defaultLockout = Lockout{
+	MaxFailures:        5,
+	MaxAddressFailures: 20,
+	Window:             15 * time.Minute,
+	Duration:           15 * time.Minute,
+}
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithLockout sets the settings for throttling failed logins.
+func WithLockout(lo Lockout) Option {
+	return func(c *Core) {
+		if lo.MaxFailures > 0 {
+			c.lockout.MaxFailures = lo.MaxFailures
+		}
+		if lo.MaxAddressFailures > 0 {
+			c.lockout.MaxAddressFailures = lo.MaxAddressFailures
+		}
+		if lo.Window > 0 {
+			c.lockout.Window = lo.Window
+		}
+		if lo.Duration > 0 {
+			c.lockout.Duration = lo.Duration
+		}
+	}
+}
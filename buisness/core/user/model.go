@@ -9,20 +9,36 @@ import (
 
 // Data model name should match the package name
 // User represents information about an individual user
+// TenantID is the organization the user is a member of.
 type User struct {
-	ID           uuid.UUID
-	Name         string
-	Email        mail.Address
-	Roles        []Role
-	PasswordHash []byte
-	Department   string
-	Enabled      bool
-	DateCreated  time.Time
-	DateUpdated  time.Time
+	ID            uuid.UUID
+	TenantID      uuid.UUID
+	Name          string
+	Email         mail.Address
+	Roles         []Role
+	PasswordHash  []byte
+	Department    string
+	Enabled       bool
+	EmailVerified bool
+	LockedUntil   time.Time
+	DateCreated   time.Time
+	DateUpdated   time.Time
+}
+
+// HasRole reports if the user holds the specified role.
+func (u User) HasRole(role Role) bool {
+	for _, r := range u.Roles {
+		if r.Equal(role) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // NewUser contains information needed to create a new user.
 type NewUser struct {
+	TenantID        uuid.UUID
 	Name            string
 	Email           mail.Address
 	Roles           []Role
@@ -46,3 +62,12 @@ type UpdateUser struct {
 	PasswordConfirm *string
 	Enabled         *bool
 }
+
+// LoginFailure represents a failed login attempt. The UserID is the zero
+// value when the email didn't match a user.
+type LoginFailure struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Address     string
+	DateCreated time.Time
+}
@@ -0,0 +1,156 @@
+package user
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordPolicy represents the rules a new password must follow and the
+// bcrypt cost it is hashed with. A password must have MinLength characters
+// and MinClasses of the lower case, upper case, digit and symbol classes,
+// and at most 72 bytes, which is all bcrypt hashes.
+// Common passwords and passwords holding the email or the name of the user
+// are always refused. Zero values are replaced by the defaults.
+type PasswordPolicy struct {
+	MinLength  int
+	MinClasses int
+	Cost       int
+}
+
+// maxPasswordBytes is the longest password bcrypt can hash, longer ones are
+// refused rather than silently cut.
+const maxPasswordBytes = 72
+
+// defaultPasswordPolicy is used when the core is constructed without a
+// password policy.
+var defaultPasswordPolicy = PasswordPolicy{
+	MinLength:  8,
+	MinClasses: 2,
+	Cost:       bcrypt.DefaultCost,
+}
+
+// WithPasswordPolicy sets the rules new passwords must follow.
+func WithPasswordPolicy(pp PasswordPolicy) Option {
+	return func(c *Core) {
+		if pp.MinLength > 0 {
+			c.password.MinLength = pp.MinLength
+		}
+		if pp.MinClasses > 0 {
+			c.password.MinClasses = min(pp.MinClasses, 4)
+		}
+		if pp.Cost > 0 {
+			c.password.Cost = min(max(pp.Cost, bcrypt.MinCost), bcrypt.MaxCost)
+		}
+	}
+}
+
+// =============================================================================
+
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+// commonPasswords holds the passwords that are refused whatever the policy,
+// in lower case.
+var commonPasswords = func() map[string]struct{} {
+	m := make(map[string]struct{})
+
+	s := bufio.NewScanner(strings.NewReader(commonPasswordsList))
+	for s.Scan() {
+		if pw := strings.TrimSpace(s.Text()); pw != "" && !strings.HasPrefix(pw, "#") {
+			m[strings.ToLower(pw)] = struct{}{}
+		}
+	}
+
+	return m
+}()
+
+// checkPassword returns the violations of the policy as a field error for
+// the password. The name and email are the ones the user will have.
+func (pp PasswordPolicy) checkPassword(password string, name string, email mail.Address) error {
+	var problems []string
+
+	if n := len([]rune(password)); n < pp.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters", pp.MinLength))
+	}
+
+	if len(password) > maxPasswordBytes {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes", maxPasswordBytes))
+	}
+
+	if n := classes(password); n < pp.MinClasses {
+		problems = append(problems, fmt.Sprintf("must mix at least %d of lower case, upper case, digits and symbols", pp.MinClasses))
+	}
+
+	lower := strings.ToLower(password)
+
+	if _, exists := commonPasswords[lower]; exists {
+		problems = append(problems, "is too common")
+	}
+
+	if containsPersonal(lower, name, email) {
+		problems = append(problems, "must not contain your name or email")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return validate.NewFieldsError("password", errors.New("password "+strings.Join(problems, ", ")))
+}
+
+// hash returns the bcrypt hash of the password with the cost of the policy.
+func (pp PasswordPolicy) hash(password string) ([]byte, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), pp.Cost)
+	if err != nil {
+		return nil, fmt.Errorf("generatefrompassword: %w", err)
+	}
+
+	return hash, nil
+}
+
+// classes counts the character classes used in the password.
+func classes(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
+
+// containsPersonal reports if the lower case password holds the email, the
+// part of the email before the @ or a word of the name. Words shorter than
+// three letters are too likely to appear by chance to count.
+func containsPersonal(password string, name string, email mail.Address) bool {
+	words := strings.Fields(strings.ToLower(name))
+
+	if addr := strings.ToLower(email.Address); addr != "" {
+		local, _, _ := strings.Cut(addr, "@")
+		words = append(words, addr, local)
+	}
+
+	for _, w := range words {
+		if len(w) >= 3 && strings.Contains(password, w) {
+			return true
+		}
+	}
+
+	return false
+}
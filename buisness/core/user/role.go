@@ -1,17 +1,62 @@
 package user
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
+
+// Set of possible roles for a user. An admin manages the users of their own
+// tenant, a super admin can act on every tenant.
+var (
+	RoleAdmin      = Role{"ADMIN"}
+	RoleUser       = Role{"USER"}
+	RoleSuperAdmin = Role{"SUPER_ADMIN"}
+)
+
+// builtIn is the set of roles the service depends on. They are always known,
+// even before the roles are loaded from the database.
+var builtIn = []Role{RoleAdmin, RoleUser, RoleSuperAdmin}
 
-// Set of possible roles for a user.
+// Set of known roles. It starts with the built in roles and is replaced
+// with SetRoles when the roles are loaded from the database.
 var (
-	RoleAdmin = Role{"ADMIN"}
-	RoleUser  = Role{"USER"}
+	rolesMu sync.RWMutex
+	roles   = makeRoles(nil)
 )
 
-// Set of known roles.
-var roles = map[string]Role{
-	RoleAdmin.name: RoleAdmin,
-	RoleUser.name:  RoleUser,
+// SetRoles replaces the set of known roles with the built in roles and the
+// roles named. It's safe to call while roles are being parsed.
+func SetRoles(names []string) {
+	m := makeRoles(names)
+
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+
+	roles = m
+}
+
+// IsBuiltIn reports if the role is one the service depends on, these roles
+// can't be removed.
+func IsBuiltIn(name string) bool {
+	for _, role := range builtIn {
+		if role.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func makeRoles(names []string) map[string]Role {
+	m := make(map[string]Role, len(builtIn)+len(names))
+	for _, role := range builtIn {
+		m[role.name] = role
+	}
+	for _, name := range names {
+		m[name] = Role{name}
+	}
+
+	return m
 }
 
 // Role represents a role in the system.
@@ -23,7 +68,10 @@ type Role struct {
 // This is a validation function that should only be called in the application layer
 // This is an idiom that the standard package is using
 func ParseRole(value string) (Role, error) {
+	rolesMu.RLock()
 	role, exists := roles[value]
+	rolesMu.RUnlock()
+
 	if !exists {
 		return Role{}, fmt.Errorf("invalid role %q", value)
 	}
@@ -2,20 +2,34 @@ package userdb
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"strings"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
 )
 
-func (s *Store) applyFilter(filter user.QueryFilter, data map[string]interface{}, buf *bytes.Buffer) {
+// applyFilter adds the filter to the query. A context limited to a tenant
+// only sees the users of that tenant, whatever the filter says.
+func (s *Store) applyFilter(ctx context.Context, filter user.QueryFilter, data map[string]interface{}, buf *bytes.Buffer) {
 	var wc []string
 
+	if tenantID, ok := tenant.GetScope(ctx); ok {
+		data["scope_tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :scope_tenant_id")
+	}
+
 	if filter.ID != nil {
 		data["user_id"] = *filter.ID
 		wc = append(wc, "user_id = :user_id")
 	}
 
+	if filter.TenantID != nil {
+		data["tenant_id"] = *filter.TenantID
+		wc = append(wc, "tenant_id = :tenant_id")
+	}
+
 	if filter.Name != nil {
 		data["name"] = fmt.Sprintf("%%%s%%", *filter.Name)
 		wc = append(wc, "name LIKE :name")
@@ -42,3 +56,12 @@ func (s *Store) applyFilter(filter user.QueryFilter, data map[string]interface{}
 		buf.WriteString(strings.Join(wc, " AND "))
 	}
 }
+
+// applyScope limits a query that already has a WHERE clause to the tenant
+// of the context.
+func applyScope(ctx context.Context, data map[string]interface{}, buf *bytes.Buffer) {
+	if tenantID, ok := tenant.GetScope(ctx); ok {
+		data["scope_tenant_id"] = tenantID
+		buf.WriteString(" AND tenant_id = :scope_tenant_id")
+	}
+}
@@ -18,11 +18,14 @@ import (
 // and it uses the tagging system, since this what sqlx use
 type dbUser struct {
 	ID           uuid.UUID      `db:"user_id"`
+	TenantID     uuid.UUID      `db:"tenant_id"`
 	Name         string         `db:"name"`
 	Email        string         `db:"email"`
 	Roles        dbarray.String `db:"roles"`
 	PasswordHash []byte         `db:"password_hash"`
 	Enabled      bool           `db:"enabled"`
+	Verified     bool           `db:"email_verified"`
+	LockedUntil  sql.NullTime   `db:"locked_until"`
 	Department   sql.NullString `db:"department"`
 	DateCreated  time.Time      `db:"date_created"`
 	DateUpdated  time.Time      `db:"date_updated"`
@@ -38,6 +41,7 @@ func toDBUser(usr user.User) dbUser {
 
 	return dbUser{
 		ID:           usr.ID,
+		TenantID:     usr.TenantID,
 		Name:         usr.Name,
 		Email:        usr.Email.Address,
 		Roles:        roles,
@@ -46,7 +50,12 @@ func toDBUser(usr user.User) dbUser {
 			String: usr.Department,
 			Valid:  usr.Department != "",
 		},
-		Enabled:     usr.Enabled,
+		Enabled:  usr.Enabled,
+		Verified: usr.EmailVerified,
+		LockedUntil: sql.NullTime{
+			Time:  usr.LockedUntil.UTC(),
+			Valid: !usr.LockedUntil.IsZero(),
+		},
 		DateCreated: usr.DateCreated.UTC(),
 		DateUpdated: usr.DateUpdated.UTC(),
 	}
@@ -68,15 +77,21 @@ func toCoreUser(dbUsr dbUser) (user.User, error) {
 	}
 
 	usr := user.User{
-		ID:           dbUsr.ID,
-		Name:         dbUsr.Name,
-		Email:        addr,
-		Roles:        roles,
-		PasswordHash: dbUsr.PasswordHash,
-		Enabled:      dbUsr.Enabled,
-		Department:   dbUsr.Department.String,
-		DateCreated:  dbUsr.DateCreated.In(time.Local),
-		DateUpdated:  dbUsr.DateUpdated.In(time.Local),
+		ID:            dbUsr.ID,
+		TenantID:      dbUsr.TenantID,
+		Name:          dbUsr.Name,
+		Email:         addr,
+		Roles:         roles,
+		PasswordHash:  dbUsr.PasswordHash,
+		Enabled:       dbUsr.Enabled,
+		EmailVerified: dbUsr.Verified,
+		Department:    dbUsr.Department.String,
+		DateCreated:   dbUsr.DateCreated.In(time.Local),
+		DateUpdated:   dbUsr.DateUpdated.In(time.Local),
+	}
+
+	if dbUsr.LockedUntil.Valid {
+		usr.LockedUntil = dbUsr.LockedUntil.Time.In(time.Local)
 	}
 
 	return usr, nil
@@ -93,3 +108,25 @@ func toCoreUserSlice(dbUsers []dbUser) ([]user.User, error) {
 	}
 	return usrs, nil
 }
+
+// =============================================================================
+
+// dbLoginFailure represents a failed login attempt.
+type dbLoginFailure struct {
+	ID          uuid.UUID     `db:"failure_id"`
+	UserID      uuid.NullUUID `db:"user_id"`
+	Address     string        `db:"address"`
+	DateCreated time.Time     `db:"date_created"`
+}
+
+func toDBLoginFailure(lf user.LoginFailure) dbLoginFailure {
+	return dbLoginFailure{
+		ID: lf.ID,
+		UserID: uuid.NullUUID{
+			UUID:  lf.UserID,
+			Valid: lf.UserID != uuid.UUID{},
+		},
+		Address:     lf.Address,
+		DateCreated: lf.DateCreated.UTC(),
+	}
+}
@@ -4,27 +4,30 @@ package userdb
 import (
 	"bytes"
 	"context"
-	"database/sql"
-	"database/sql/driver"
 	"errors"
 	"fmt"
 	"net/mail"
+	"time"
 
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/dbarray"
 	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
 	"go.uber.org/zap"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
-// Store manages the set of APIs for user database access.
+// Store manages the set of APIs for user database access. Reads are limited
+// to the tenant set on the context with tenant.SetScope, writes act on a
+// user that was read first.
 type Store struct {
 	log *zap.SugaredLogger
-	// We are representing here the database connection
-	db *sqlx.DB
+	// We are representing here the database connection, it is an interface
+	// so the same store can run against the database or inside a transaction.
+	db sqlx.ExtContext
 }
 
 // NewStore constructs the api for data access.
@@ -35,17 +38,36 @@ func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
 	}
 }
 
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (user.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
 // Create inserts a new user into the database.
 func (s *Store) Create(ctx context.Context, usr user.User) error {
 	const q = `
 	INSERT INTO users
-		(user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated)
+		(user_id, tenant_id, name, email, password_hash, roles, enabled, email_verified, department, date_created, date_updated)
 	VALUES
-		(:user_id, :name, :email, :password_hash, :roles, :enabled, :department, :date_created, :date_updated)`
+		(:user_id, :tenant_id, :name, :email, :password_hash, :roles, :enabled, :email_verified, :department, :date_created, :date_updated)`
 
 	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
-		if errors.Is(err, db.ErrDBDuplicatedEntry) {
+		switch {
+		case errors.Is(err, db.ErrDBDuplicatedEntry):
 			return fmt.Errorf("namedexeccontext: %w", user.ErrUniqueEmail)
+		case errors.Is(err, db.ErrDBForeignKey):
+			return fmt.Errorf("namedexeccontext: %w", user.ErrUnknownTenant)
 		}
 		return fmt.Errorf("namedexeccontext: %w", err)
 	}
@@ -64,6 +86,8 @@ func (s *Store) Update(ctx context.Context, usr user.User) error {
 		"roles" = :roles,
 		"password_hash" = :password_hash,
 		"department" = :department,
+		"enabled" = :enabled,
+		"email_verified" = :email_verified,
 		"date_updated" = :date_updated
 	WHERE
 		user_id = :user_id`
@@ -93,6 +117,9 @@ func (s *Store) Delete(ctx context.Context, usr user.User) error {
 		user_id = :user_id`
 
 	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		if errors.Is(err, db.ErrDBForeignKey) {
+			return fmt.Errorf("namedexeccontext: %w", user.ErrHasSales)
+		}
 		return fmt.Errorf("namedexeccontext: %w", err)
 	}
 
@@ -108,12 +135,12 @@ func (s *Store) Query(ctx context.Context, filter user.QueryFilter, orderBy orde
 
 	const q = `
 	SELECT
-		user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+		user_id, tenant_id, name, email, password_hash, roles, enabled, email_verified, locked_until, department, date_created, date_updated
 	FROM
 		users`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(ctx, filter, data, buf)
 
 	orderByClause, err := orderByClause(orderBy)
 	if err != nil {
@@ -150,7 +177,7 @@ func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error)
 		users`
 
 	buf := bytes.NewBufferString(q)
-	s.applyFilter(filter, data, buf)
+	s.applyFilter(ctx, filter, data, buf)
 
 	var count struct {
 		Count int `db:"count"`
@@ -164,22 +191,23 @@ func (s *Store) Count(ctx context.Context, filter user.QueryFilter) (int, error)
 
 // QueryByID gets the specified user from the database.
 func (s *Store) QueryByID(ctx context.Context, userID uuid.UUID) (user.User, error) {
-	data := struct {
-		ID string `db:"user_id"`
-	}{
-		ID: userID.String(),
+	data := map[string]interface{}{
+		"user_id": userID.String(),
 	}
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+        user_id, tenant_id, name, email, password_hash, roles, enabled, email_verified, locked_until, department, date_created, date_updated
 	FROM
 		users
 	WHERE 
 		user_id = :user_id`
 
+	buf := bytes.NewBufferString(q)
+	applyScope(ctx, data, buf)
+
 	var dbUsr dbUser
-	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbUsr); err != nil {
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbUsr); err != nil {
 		if errors.Is(err, db.ErrDBNotFound) {
 			return user.User{}, fmt.Errorf("namedquerystruct: %w", user.ErrNotFound)
 		}
@@ -201,25 +229,23 @@ func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.Use
 		ids[i] = userID.String()
 	}
 
-	data := struct {
-		UserID interface {
-			driver.Valuer
-			sql.Scanner
-		} `db:"user_id"`
-	}{
-		UserID: dbarray.Array(ids),
+	data := map[string]interface{}{
+		"user_id": dbarray.Array(ids),
 	}
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+        user_id, tenant_id, name, email, password_hash, roles, enabled, email_verified, locked_until, department, date_created, date_updated
 	FROM
 		users
 	WHERE
 		user_id = ANY(:user_id)`
 
+	buf := bytes.NewBufferString(q)
+	applyScope(ctx, data, buf)
+
 	var dbUsrs []dbUser
-	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbUsrs); err != nil {
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbUsrs); err != nil {
 		if errors.Is(err, db.ErrDBNotFound) {
 			return nil, user.ErrNotFound
 		}
@@ -236,22 +262,23 @@ func (s *Store) QueryByIDs(ctx context.Context, userIDs []uuid.UUID) ([]user.Use
 
 // QueryByEmail gets the specified user from the database by email.
 func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User, error) {
-	data := struct {
-		Email string `db:"email"`
-	}{
-		Email: email.Address,
+	data := map[string]interface{}{
+		"email": email.Address,
 	}
 
 	const q = `
 	SELECT
-        user_id, name, email, password_hash, roles, enabled, department, date_created, date_updated
+        user_id, tenant_id, name, email, password_hash, roles, enabled, email_verified, locked_until, department, date_created, date_updated
 	FROM
 		users
 	WHERE
 		email = :email`
 
+	buf := bytes.NewBufferString(q)
+	applyScope(ctx, data, buf)
+
 	var dbUsr dbUser
-	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbUsr); err != nil {
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbUsr); err != nil {
 		if errors.Is(err, db.ErrDBNotFound) {
 			return user.User{}, fmt.Errorf("namedquerystruct: %w", user.ErrNotFound)
 		}
@@ -265,3 +292,137 @@ func (s *Store) QueryByEmail(ctx context.Context, email mail.Address) (user.User
 
 	return usr, nil
 }
+
+// Lock sets the time until which the user is locked out. A zero time
+// unlocks the user.
+func (s *Store) Lock(ctx context.Context, usr user.User) error {
+	const q = `
+	UPDATE
+		users
+	SET
+		"locked_until" = :locked_until
+	WHERE
+		user_id = :user_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBUser(usr)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// CreateLoginFailure records a failed login attempt.
+func (s *Store) CreateLoginFailure(ctx context.Context, lf user.LoginFailure) error {
+	const q = `
+	INSERT INTO login_failures
+		(failure_id, user_id, address, date_created)
+	VALUES
+		(:failure_id, :user_id, :address, :date_created)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBLoginFailure(lf)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// CountLoginFailuresByUserID returns the number of failed logins of the user
+// since the specified time.
+func (s *Store) CountLoginFailuresByUserID(ctx context.Context, userID uuid.UUID, since time.Time) (int, error) {
+	data := struct {
+		UserID string    `db:"user_id"`
+		Since  time.Time `db:"since"`
+	}{
+		UserID: userID.String(),
+		Since:  since.UTC(),
+	}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		login_failures
+	WHERE
+		user_id = :user_id AND date_created > :since`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// CountLoginFailuresByAddress returns the number of failed logins from the
+// address since the specified time.
+func (s *Store) CountLoginFailuresByAddress(ctx context.Context, address string, since time.Time) (int, error) {
+	data := struct {
+		Address string    `db:"address"`
+		Since   time.Time `db:"since"`
+	}{
+		Address: address,
+		Since:   since.UTC(),
+	}
+
+	const q = `
+	SELECT
+		count(1)
+	FROM
+		login_failures
+	WHERE
+		address = :address AND date_created > :since`
+
+	var count struct {
+		Count int `db:"count"`
+	}
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &count); err != nil {
+		return 0, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return count.Count, nil
+}
+
+// DeleteLoginFailuresBefore removes the failed logins recorded before the
+// specified time.
+func (s *Store) DeleteLoginFailuresBefore(ctx context.Context, before time.Time) error {
+	data := struct {
+		Before time.Time `db:"before"`
+	}{
+		Before: before.UTC(),
+	}
+
+	const q = `
+	DELETE FROM
+		login_failures
+	WHERE
+		date_created < :before`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteLoginFailures removes the failed logins recorded for the user.
+func (s *Store) DeleteLoginFailures(ctx context.Context, userID uuid.UUID) error {
+	data := struct {
+		UserID string `db:"user_id"`
+	}{
+		UserID: userID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		login_failures
+	WHERE
+		user_id = :user_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -20,6 +22,11 @@ var (
 	ErrNotFound              = errors.New("user not found")
 	ErrUniqueEmail           = errors.New("email is not unique")
 	ErrAuthenticationFailure = errors.New("authentication failed")
+	ErrUserDisabled          = errors.New("user disabled")
+	ErrAccountLocked         = errors.New("account locked")
+	ErrTooManyAttempts       = errors.New("too many failed login attempts")
+	ErrUnknownTenant         = errors.New("tenant does not exist")
+	ErrHasSales              = errors.New("user has sales")
 )
 
 // =============================================================================
@@ -40,18 +47,54 @@ type Storer interface {
 	QueryByID(ctx context.Context, userID uuid.UUID) (User, error)
 	QueryByIDs(ctx context.Context, userID []uuid.UUID) ([]User, error)
 	QueryByEmail(ctx context.Context, email mail.Address) (User, error)
+	Lock(ctx context.Context, usr User) error
+	CreateLoginFailure(ctx context.Context, lf LoginFailure) error
+	CountLoginFailuresByUserID(ctx context.Context, userID uuid.UUID, since time.Time) (int, error)
+	CountLoginFailuresByAddress(ctx context.Context, address string, since time.Time) (int, error)
+	DeleteLoginFailures(ctx context.Context, userID uuid.UUID) error
+	DeleteLoginFailuresBefore(ctx context.Context, before time.Time) error
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
 }
 
 // Core manages the set of APIs for user access.
 type Core struct {
-	storer Storer
+	storer   Storer
+	lockout  Lockout
+	password PasswordPolicy
+	delegate *delegate.Delegate
 }
 
 // NewCore constructs a core for user api access.
-func NewCore(storer Storer) *Core {
-	return &Core{
-		storer: storer,
+func NewCore(storer Storer, options ...Option) *Core {
+	c := Core{
+		storer:   storer,
+		lockout:  defaultLockout,
+		password: defaultPasswordPolicy,
 	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		storer:   trS,
+		lockout:  c.lockout,
+		password: c.password,
+		delegate: c.delegate,
+	}
+
+	return c, nil
 }
 
 // Create adds a new user to the system.
@@ -59,16 +102,23 @@ func NewCore(storer Storer) *Core {
 // We are using value sematics for the NewUser because it represents data
 // We are using value semantics on the return type because it also represnets data
 // Context is an interface that's why you don't see pointer semantics here
+// The password must follow the password policy, the violations are returned
+// as field errors.
 func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(nu.Password), bcrypt.DefaultCost)
+	if err := c.password.checkPassword(nu.Password, nu.Name, nu.Email); err != nil {
+		return User{}, err
+	}
+
+	hash, err := c.password.hash(nu.Password)
 	if err != nil {
-		return User{}, fmt.Errorf("generatefrompassword: %w", err)
+		return User{}, err
 	}
 
 	now := time.Now()
 
 	usr := User{
 		ID:           uuid.New(),
+		TenantID:     nu.TenantID,
 		Name:         nu.Name,
 		Email:        nu.Email,
 		PasswordHash: hash,
@@ -83,6 +133,10 @@ func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
 		return User{}, fmt.Errorf("Create: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionCreatedData(usr)); err != nil {
+		return User{}, fmt.Errorf("Create: %w", err)
+	}
+
 	return usr, nil
 }
 
@@ -93,11 +147,17 @@ func (c *Core) Create(ctx context.Context, nu NewUser) (User, error) {
 // also if it is a buisness to buisness package, the caller could have the user in hand
 // so it will be inefficient to get the current user here
 func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error) {
+	before := usr
+
 	if uu.Name != nil {
 		usr.Name = *uu.Name
 	}
 
 	if uu.Email != nil {
+		// A new email address has to be verified again.
+		if uu.Email.Address != usr.Email.Address {
+			usr.EmailVerified = false
+		}
 		usr.Email = *uu.Email
 	}
 
@@ -105,18 +165,25 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 		usr.Roles = uu.Roles
 	}
 
+	if uu.Department != nil {
+		usr.Department = *uu.Department
+	}
+
+	// The password is checked against the name and email the user will have
+	// once the update is done.
 	if uu.Password != nil {
-		pw, err := bcrypt.GenerateFromPassword([]byte(*uu.Password), bcrypt.DefaultCost)
+		if err := c.password.checkPassword(*uu.Password, usr.Name, usr.Email); err != nil {
+			return User{}, err
+		}
+
+		pw, err := c.password.hash(*uu.Password)
 		if err != nil {
-			return User{}, fmt.Errorf("generatefrompassword: %w", err)
+			return User{}, err
 		}
 		usr.PasswordHash = pw
 	}
 
-	if uu.Department != nil {
-		usr.Department = *uu.Department
-	}
-
+	wasEnabled := usr.Enabled
 	if uu.Enabled != nil {
 		usr.Enabled = *uu.Enabled
 	}
@@ -126,11 +193,26 @@ func (c *Core) Update(ctx context.Context, usr User, uu UpdateUser) (User, error
 		return User{}, fmt.Errorf("update: %w", err)
 	}
 
+	if err := c.delegate.Call(ctx, ActionUpdatedData(before, usr)); err != nil {
+		return User{}, fmt.Errorf("update: %w", err)
+	}
+
+	if wasEnabled && !usr.Enabled {
+		if err := c.delegate.Call(ctx, ActionDisabledData(before, usr)); err != nil {
+			return User{}, fmt.Errorf("update: %w", err)
+		}
+	}
+
 	return usr, nil
 }
 
-// Delete removes the specified user.
+// Delete removes the specified user. The other domains are told first, in
+// the same transaction, so they can remove what belongs to the user.
 func (c *Core) Delete(ctx context.Context, usr User) error {
+	if err := c.delegate.Call(ctx, ActionDeletedData(usr)); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
 	if err := c.storer.Delete(ctx, usr); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
@@ -191,18 +273,214 @@ func (c *Core) QueryByEmail(ctx context.Context, email mail.Address) (User, erro
 
 // Authenticate finds a user by their email and verifies their password. On
 // success it returns a Claims User representing this user. The claims can be
-// used to generate a token for future authentication.
-func (c *Core) Authenticate(ctx context.Context, email mail.Address, password string) (User, error) {
+// used to generate a token for future authentication. A disabled user can't
+// authenticate.
+// Failed attempts are recorded for the user and the address the request came
+// from. Too many failures lock the user for a while, and too many failures
+// from an address refuse any login from it.
+// A password hashed with a lower bcrypt cost than the policy is hashed again
+// with the current cost, while the password is known.
+func (c *Core) Authenticate(ctx context.Context, email mail.Address, password string, address string) (User, error) {
+	now := time.Now()
+	since := now.Add(-c.lockout.Window)
+
+	n, err := c.storer.CountLoginFailuresByAddress(ctx, address, since)
+	if err != nil {
+		return User{}, fmt.Errorf("countloginfailuresbyaddress: address[%s]: %w", address, err)
+	}
+
+	if n >= c.lockout.MaxAddressFailures {
+		return User{}, ErrTooManyAttempts
+	}
+
 	// c.storer.QueryByEmail -> this call is wrong you shouldn't use storer here
 	// when a core API calls another core API avoid using storer since there might be buisness logic in core API
 	usr, err := c.QueryByEmail(ctx, email)
 	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			if err := c.loginFailed(ctx, User{}, address, now); err != nil {
+				return User{}, err
+			}
+		}
 		return User{}, fmt.Errorf("query: email[%s]: %w", email, err)
 	}
 
+	if usr.LockedUntil.After(now) {
+		return User{}, ErrAccountLocked
+	}
+
 	if err := bcrypt.CompareHashAndPassword(usr.PasswordHash, []byte(password)); err != nil {
+		if err := c.loginFailed(ctx, usr, address, now); err != nil {
+			return User{}, err
+		}
 		return User{}, fmt.Errorf("comparehashandpassword: %w", ErrAuthenticationFailure)
 	}
 
+	if !usr.Enabled {
+		return User{}, ErrUserDisabled
+	}
+
+	if err := c.storer.DeleteLoginFailures(ctx, usr.ID); err != nil {
+		return User{}, fmt.Errorf("deleteloginfailures: userID[%s]: %w", usr.ID, err)
+	}
+
+	if cost, err := bcrypt.Cost(usr.PasswordHash); err == nil && cost < c.password.Cost {
+		before := usr
+
+		hash, err := c.password.hash(password)
+		if err != nil {
+			return User{}, err
+		}
+		usr.PasswordHash = hash
+
+		if err := c.storer.Update(ctx, usr); err != nil {
+			return User{}, fmt.Errorf("update: userID[%s]: %w", usr.ID, err)
+		}
+
+		if err := c.delegate.Call(ctx, ActionPasswordRehashedData(before, usr)); err != nil {
+			return User{}, fmt.Errorf("update: userID[%s]: %w", usr.ID, err)
+		}
+	}
+
 	return usr, nil
 }
+
+// MarkEmailVerified records that the user proved they own their email
+// address.
+func (c *Core) MarkEmailVerified(ctx context.Context, usr User) (User, error) {
+	before := usr
+
+	usr.EmailVerified = true
+	usr.DateUpdated = time.Now()
+
+	if err := c.storer.Update(ctx, usr); err != nil {
+		return User{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionEmailVerifiedData(before, usr)); err != nil {
+		return User{}, fmt.Errorf("update: %w", err)
+	}
+
+	return usr, nil
+}
+
+// ResetPassword replaces the password of a user who proved who they are
+// without it. The password must follow the password policy, the violations
+// are returned as field errors.
+func (c *Core) ResetPassword(ctx context.Context, usr User, password string) (User, error) {
+	if err := c.password.checkPassword(password, usr.Name, usr.Email); err != nil {
+		return User{}, err
+	}
+
+	before := usr
+
+	hash, err := c.password.hash(password)
+	if err != nil {
+		return User{}, err
+	}
+	usr.PasswordHash = hash
+	usr.DateUpdated = time.Now()
+
+	if err := c.storer.Update(ctx, usr); err != nil {
+		return User{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionPasswordResetData(before, usr)); err != nil {
+		return User{}, fmt.Errorf("update: %w", err)
+	}
+
+	return usr, nil
+}
+
+// IsEnabled reports if the specified user exists and is enabled.
+func (c *Core) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	usr, err := c.QueryByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return usr.Enabled, nil
+}
+
+// Unlock removes the lock and the failed logins of the user.
+func (c *Core) Unlock(ctx context.Context, usr User) (User, error) {
+	before := usr
+
+	usr.LockedUntil = time.Time{}
+
+	if err := c.storer.Lock(ctx, usr); err != nil {
+		return User{}, fmt.Errorf("lock: %w", err)
+	}
+
+	if err := c.storer.DeleteLoginFailures(ctx, usr.ID); err != nil {
+		return User{}, fmt.Errorf("deleteloginfailures: userID[%s]: %w", usr.ID, err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionUnlockedData(before, usr)); err != nil {
+		return User{}, fmt.Errorf("lock: %w", err)
+	}
+
+	return usr, nil
+}
+
+// PurgeLoginFailures removes the failed logins that are older than the
+// lockout window, they no longer count towards a lock.
+func (c *Core) PurgeLoginFailures(ctx context.Context) error {
+	if err := c.storer.DeleteLoginFailuresBefore(ctx, time.Now().Add(-c.lockout.Window)); err != nil {
+		return fmt.Errorf("deleteloginfailuresbefore: %w", err)
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// loginFailed records a failed login and locks the user once it reached the
+// maximum number of failures. Only failures after the previous lock expired
+// are counted, so the count starts over once a lock expires.
+func (c *Core) loginFailed(ctx context.Context, usr User, address string, now time.Time) error {
+	lf := LoginFailure{
+		ID:          uuid.New(),
+		UserID:      usr.ID,
+		Address:     address,
+		DateCreated: now,
+	}
+
+	if err := c.storer.CreateLoginFailure(ctx, lf); err != nil {
+		return fmt.Errorf("createloginfailure: %w", err)
+	}
+
+	if usr.ID == (uuid.UUID{}) {
+		return nil
+	}
+
+	since := now.Add(-c.lockout.Window)
+	if usr.LockedUntil.After(since) {
+		since = usr.LockedUntil
+	}
+
+	n, err := c.storer.CountLoginFailuresByUserID(ctx, usr.ID, since)
+	if err != nil {
+		return fmt.Errorf("countloginfailuresbyuserid: userID[%s]: %w", usr.ID, err)
+	}
+
+	if n < c.lockout.MaxFailures {
+		return nil
+	}
+
+	before := usr
+	usr.LockedUntil = now.Add(c.lockout.Duration)
+
+	if err := c.storer.Lock(ctx, usr); err != nil {
+		return fmt.Errorf("lock: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionLockedData(before, usr)); err != nil {
+		return fmt.Errorf("lock: %w", err)
+	}
+
+	return nil
+}
@@ -6,14 +6,19 @@ import (
 	"fmt"
 	"net/mail"
 	"runtime/debug"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user/stores/userdb"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/order"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var c *docker.Container
@@ -33,6 +38,9 @@ func TestMain(m *testing.M) {
 func Test_User(t *testing.T) {
 	t.Run("crud", crud)
 	t.Run("paging", paging)
+	t.Run("lockout", lockout)
+	t.Run("disabled", disabled)
+	t.Run("password", password)
 }
 
 // =============================================================================
@@ -60,7 +68,7 @@ func crud(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
 	defer cancel()
 
 	t.Log("Go seeding ...")
@@ -167,7 +175,7 @@ func paging(t *testing.T) {
 
 	api := test.CoreAPIs
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
 	defer cancel()
 
 	// -------------------------------------------------------------------------
@@ -224,3 +232,238 @@ func paging(t *testing.T) {
 		t.Errorf("Should have different users")
 	}
 }
+
+func lockout(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	lo := user.Lockout{
+		MaxFailures:        3,
+		MaxAddressFailures: 5,
+		Window:             time.Minute,
+		Duration:           time.Minute,
+	}
+	core := user.NewCore(userdb.NewStore(test.Log, test.DB), user.WithLockout(lo))
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	for i := 0; i < lo.MaxFailures; i++ {
+		if _, err := core.Authenticate(ctx, *email, "bad", "10.0.0.1"); !errors.Is(err, user.ErrAuthenticationFailure) {
+			t.Fatalf("Should get an authentication failure for a bad password : %s.", err)
+		}
+	}
+
+	if _, err := core.Authenticate(ctx, *email, "gophers", "10.0.0.2"); !errors.Is(err, user.ErrAccountLocked) {
+		t.Fatalf("Should get a locked account after too many failures : %s.", err)
+	}
+
+	usr, err := core.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve user by email : %s.", err)
+	}
+
+	if _, err := core.Unlock(ctx, usr); err != nil {
+		t.Fatalf("Should be able to unlock the user : %s.", err)
+	}
+
+	if _, err := core.Authenticate(ctx, *email, "gophers", "10.0.0.2"); err != nil {
+		t.Fatalf("Should be able to authenticate once unlocked : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	unknown, err := mail.ParseAddress("unknown@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	for i := 0; i < lo.MaxAddressFailures; i++ {
+		if _, err := core.Authenticate(ctx, *unknown, "bad", "10.0.0.3"); !errors.Is(err, user.ErrNotFound) {
+			t.Fatalf("Should get not found for an unknown email : %s.", err)
+		}
+	}
+
+	if _, err := core.Authenticate(ctx, *email, "gophers", "10.0.0.3"); !errors.Is(err, user.ErrTooManyAttempts) {
+		t.Fatalf("Should refuse an address after too many failures : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := core.PurgeLoginFailures(ctx); err != nil {
+		t.Fatalf("Should be able to purge the failed logins : %s.", err)
+	}
+
+	if _, err := core.Authenticate(ctx, *email, "gophers", "10.0.0.3"); !errors.Is(err, user.ErrTooManyAttempts) {
+		t.Fatalf("Should keep the failed logins within the window : %s.", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	short := user.NewCore(userdb.NewStore(test.Log, test.DB), user.WithLockout(user.Lockout{Window: time.Millisecond}))
+	if err := short.PurgeLoginFailures(ctx); err != nil {
+		t.Fatalf("Should be able to purge the failed logins : %s.", err)
+	}
+
+	if _, err := core.Authenticate(ctx, *email, "gophers", "10.0.0.3"); err != nil {
+		t.Fatalf("Should be able to authenticate once the failed logins are purged : %s.", err)
+	}
+}
+
+func disabled(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve user by email : %s.", err)
+	}
+
+	enabled := false
+	if _, err := api.User.Update(ctx, usr, user.UpdateUser{Enabled: &enabled}); err != nil {
+		t.Fatalf("Should be able to disable the user : %s.", err)
+	}
+
+	ok, err := api.User.IsEnabled(ctx, usr.ID)
+	if err != nil {
+		t.Fatalf("Should be able to check the user is enabled : %s.", err)
+	}
+
+	if ok {
+		t.Fatalf("Should get back the user as disabled")
+	}
+
+	if _, err := api.User.Authenticate(ctx, *email, "gophers", "10.0.0.1"); !errors.Is(err, user.ErrUserDisabled) {
+		t.Fatalf("Should NOT be able to authenticate a disabled user : %s.", err)
+	}
+}
+
+func password(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	pp := user.PasswordPolicy{
+		MinLength:  10,
+		MinClasses: 3,
+		Cost:       bcrypt.DefaultCost + 1,
+	}
+	core := user.NewCore(userdb.NewStore(test.Log, test.DB), user.WithPasswordPolicy(pp))
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	seeded, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	member, err := core.QueryByEmail(ctx, *seeded)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	email, err := mail.ParseAddress("bill@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	nu := user.NewUser{
+		TenantID:   member.TenantID,
+		Name:       "Bill Kennedy",
+		Email:      *email,
+		Roles:      []user.Role{user.RoleUser},
+		Department: "development",
+	}
+
+	weak := []string{
+		"Short1!",
+		"alllowercaseletters",
+		"Password123",
+		"Kennedy-2024!",
+		"my-Bill@example.com-1",
+		"Correct-Horse-42-" + strings.Repeat("x", 60),
+	}
+
+	for _, pw := range weak {
+		nu.Password = pw
+		if _, err := core.Create(ctx, nu); !validate.IsFieldErrors(err) {
+			t.Fatalf("Should NOT be able to create a user with the password %q : %v.", pw, err)
+		}
+	}
+
+	nu.Password = "Correct-Horse-42"
+	usr, err := core.Create(ctx, nu)
+	if err != nil {
+		t.Fatalf("Should be able to create a user with a strong password : %s.", err)
+	}
+
+	weakPW := "bill-Kennedy-99"
+	if _, err := core.Update(ctx, usr, user.UpdateUser{Password: &weakPW}); !validate.IsFieldErrors(err) {
+		t.Fatalf("Should NOT be able to update to a weak password : %v.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := core.Authenticate(ctx, *seeded, "gophers", "10.0.0.1"); err != nil {
+		t.Fatalf("Should be able to authenticate the seeded user : %s.", err)
+	}
+
+	saved, err := core.QueryByEmail(ctx, *seeded)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve user by email : %s.", err)
+	}
+
+	cost, err := bcrypt.Cost(saved.PasswordHash)
+	if err != nil {
+		t.Fatalf("Should be able to read the cost of the hash : %s.", err)
+	}
+
+	if cost != pp.Cost {
+		t.Logf("got: %v", cost)
+		t.Logf("exp: %v", pp.Cost)
+		t.Errorf("Should upgrade the cost of the hash on authenticate")
+	}
+
+	if _, err := core.Authenticate(ctx, *seeded, "gophers", "10.0.0.1"); err != nil {
+		t.Fatalf("Should be able to authenticate with the upgraded hash : %s.", err)
+	}
+}
@@ -0,0 +1,25 @@
+package verify
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Set of purposes a token can be issued for. A token issued for one purpose
+// can't be used for another.
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+// Token represents a single-use token sent to a user by email. Only the id
+// is stored, the token itself is signed so it can't be forged.
+type Token struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Purpose     string
+	DateExpires time.Time
+	DateCreated time.Time
+	DateUsed    time.Time
+}
@@ -0,0 +1,49 @@
+package verifydb
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/verify"
+	"github.com/google/uuid"
+)
+
+// dbToken represents an individual verification token.
+type dbToken struct {
+	ID          uuid.UUID    `db:"token_id"`
+	UserID      uuid.UUID    `db:"user_id"`
+	Purpose     string       `db:"purpose"`
+	DateExpires time.Time    `db:"date_expires"`
+	DateCreated time.Time    `db:"date_created"`
+	DateUsed    sql.NullTime `db:"date_used"`
+}
+
+func toDBToken(tkn verify.Token) dbToken {
+	return dbToken{
+		ID:          tkn.ID,
+		UserID:      tkn.UserID,
+		Purpose:     tkn.Purpose,
+		DateExpires: tkn.DateExpires.UTC(),
+		DateCreated: tkn.DateCreated.UTC(),
+		DateUsed: sql.NullTime{
+			Time:  tkn.DateUsed.UTC(),
+			Valid: !tkn.DateUsed.IsZero(),
+		},
+	}
+}
+
+func toCoreToken(dbTkn dbToken) verify.Token {
+	tkn := verify.Token{
+		ID:          dbTkn.ID,
+		UserID:      dbTkn.UserID,
+		Purpose:     dbTkn.Purpose,
+		DateExpires: dbTkn.DateExpires.In(time.Local),
+		DateCreated: dbTkn.DateCreated.In(time.Local),
+	}
+
+	if dbTkn.DateUsed.Valid {
+		tkn.DateUsed = dbTkn.DateUsed.Time.In(time.Local)
+	}
+
+	return tkn
+}
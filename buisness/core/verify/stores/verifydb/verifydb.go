@@ -0,0 +1,95 @@
+// Package verifydb contains verification token related CRUD functionality.
+package verifydb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/verify"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for verification token database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (verify.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new verification token into the database.
+func (s *Store) Create(ctx context.Context, tkn verify.Token) error {
+	const q = `
+	INSERT INTO action_tokens
+		(token_id, user_id, purpose, date_expires, date_created, date_used)
+	VALUES
+		(:token_id, :user_id, :purpose, :date_expires, :date_created, :date_used)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBToken(tkn)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Use marks the token as used. The update only matches a token with the
+// purpose that is not used and has not expired.
+func (s *Store) Use(ctx context.Context, tokenID uuid.UUID, purpose string, now time.Time) (verify.Token, error) {
+	data := struct {
+		ID      string    `db:"token_id"`
+		Purpose string    `db:"purpose"`
+		Now     time.Time `db:"now"`
+	}{
+		ID:      tokenID.String(),
+		Purpose: purpose,
+		Now:     now.UTC(),
+	}
+
+	const q = `
+	UPDATE
+		action_tokens
+	SET
+		"date_used" = :now
+	WHERE
+		token_id = :token_id AND purpose = :purpose AND date_used IS NULL AND date_expires > :now
+	RETURNING
+		token_id, user_id, purpose, date_expires, date_created, date_used`
+
+	var dbTkn dbToken
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, q, data, &dbTkn); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return verify.Token{}, fmt.Errorf("namedquerystruct: %w", verify.ErrNotFound)
+		}
+		return verify.Token{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreToken(dbTkn), nil
+}
@@ -0,0 +1,311 @@
+// Package verify provides the core business API for the single-use tokens
+// that are emailed to users to verify their email address or reset their
+// password.
+package verify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/mailer"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("token not found")
+	ErrInvalidToken = errors.New("token is not valid")
+)
+
+// Set of token lifetimes.
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+// sendTimeout bounds a reset email sent in the background.
+const sendTimeout = time.Minute
+
+// =============================================================================
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, tkn Token) error
+	// Use must only mark the token as used when it has the purpose, is not
+	// used yet and has not expired, returning ErrNotFound otherwise. This
+	// keeps a token from being used twice by concurrent requests.
+	Use(ctx context.Context, tokenID uuid.UUID, purpose string, now time.Time) (Token, error)
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+}
+
+// Mailer declares the behavior this package needs to send the tokens.
+type Mailer interface {
+	Send(ctx context.Context, msg mailer.Message) error
+}
+
+// =============================================================================
+
+// Core manages the set of APIs for verification token access.
+type Core struct {
+	log     *zap.SugaredLogger
+	usrCore *user.Core
+	storer  Storer
+	mailer  Mailer
+	secret  []byte
+}
+
+// NewCore constructs a core for verification token api access. The secret
+// signs the tokens and must be the same for every instance of the service.
+func NewCore(log *zap.SugaredLogger, usrCore *user.Core, storer Storer, mailer Mailer, secret []byte) *Core {
+	return &Core{
+		log:     log,
+		usrCore: usrCore,
+		storer:  storer,
+		mailer:  mailer,
+		secret:  secret,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	usrCore, err := c.usrCore.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		log:     c.log,
+		usrCore: usrCore,
+		storer:  trS,
+		mailer:  c.mailer,
+		secret:  c.secret,
+	}
+
+	return c, nil
+}
+
+// SendVerification emails the user a token to verify their email address.
+func (c *Core) SendVerification(ctx context.Context, usr user.User) error {
+	tkn, err := c.create(ctx, usr.ID, PurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      usr.Email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Use this token to verify your email address, it expires in %s:\n\n%s\n", verifyEmailTTL, tkn),
+	}
+
+	if err := c.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	return nil
+}
+
+// SendReset emails the user with the specified email a token to reset their
+// password. Nothing is sent when no enabled user has this email, and that
+// is not an error so callers can't find out which emails are registered.
+func (c *Core) SendReset(ctx context.Context, email mail.Address) error {
+	usr, err := c.usrCore.QueryByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, user.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("user.querybyemail: %w", err)
+	}
+
+	if !usr.Enabled {
+		return nil
+	}
+
+	tkn, err := c.create(ctx, usr.ID, PurposeResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      usr.Email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Use this token to reset your password, it expires in %s:\n\n%s\n\nIf you didn't ask to reset your password you can ignore this email.\n", resetPasswordTTL, tkn),
+	}
+
+	if err := c.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	return nil
+}
+
+// RequestReset sends the reset email of SendReset in the background and
+// returns straight away, so neither the response nor the time it takes
+// tells the caller if the email is registered. A failure is only logged.
+// The core must not be under a transaction, it would be over by the time
+// the email is sent.
+func (c *Core) RequestReset(ctx context.Context, email mail.Address) {
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+		defer cancel()
+
+		if err := c.SendReset(ctx, email); err != nil {
+			c.log.Errorw("verify", "status", "sending reset email failed", "ERROR", err)
+		}
+	}()
+}
+
+// Verify uses the token to mark the email address of its user as verified.
+func (c *Core) Verify(ctx context.Context, tkn string) (user.User, error) {
+	usr, err := c.use(ctx, tkn, PurposeVerifyEmail)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	usr, err = c.usrCore.MarkEmailVerified(ctx, usr)
+	if err != nil {
+		return user.User{}, fmt.Errorf("user.markemailverified: %w", err)
+	}
+
+	return usr, nil
+}
+
+// ResetPassword uses the token to change the password of its user.
+func (c *Core) ResetPassword(ctx context.Context, tkn string, password string) (user.User, error) {
+	usr, err := c.use(ctx, tkn, PurposeResetPassword)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	usr, err = c.usrCore.ResetPassword(ctx, usr, password)
+	if err != nil {
+		return user.User{}, fmt.Errorf("user.resetpassword: %w", err)
+	}
+
+	return usr, nil
+}
+
+// =============================================================================
+
+// create stores a new token and returns its signed form for the user.
+func (c *Core) create(ctx context.Context, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	tkn := Token{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Purpose:     purpose,
+		DateExpires: now.Add(ttl),
+		DateCreated: now,
+	}
+
+	if err := c.storer.Create(ctx, tkn); err != nil {
+		return "", fmt.Errorf("create: %w", err)
+	}
+
+	return c.sign(tkn), nil
+}
+
+// use checks the signature of the token, marks it as used and returns its
+// user. Expired tokens and tokens for another purpose are rejected before
+// the database is asked.
+func (c *Core) use(ctx context.Context, signed string, purpose string) (user.User, error) {
+	tokenID, expires, err := c.parse(signed, purpose)
+	if err != nil {
+		return user.User{}, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if now.After(expires) {
+		return user.User{}, ErrInvalidToken
+	}
+
+	tkn, err := c.storer.Use(ctx, tokenID, purpose, now)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return user.User{}, ErrInvalidToken
+		}
+		return user.User{}, fmt.Errorf("use: %w", err)
+	}
+
+	usr, err := c.usrCore.QueryByID(ctx, tkn.UserID)
+	if err != nil {
+		return user.User{}, fmt.Errorf("user.querybyid: %s: %w", tkn.UserID, err)
+	}
+
+	if !usr.Enabled {
+		return user.User{}, ErrInvalidToken
+	}
+
+	return usr, nil
+}
+
+// sign returns the token as the base64 encoded id and expiry followed by
+// the signature of the payload and the purpose.
+func (c *Core) sign(tkn Token) string {
+	payload := make([]byte, 24)
+	copy(payload, tkn.ID[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(tkn.DateExpires.Unix()))
+
+	p := base64.RawURLEncoding.EncodeToString(payload)
+
+	return p + "." + base64.RawURLEncoding.EncodeToString(c.mac(p, tkn.Purpose))
+}
+
+// parse checks the signature of the token for the purpose and returns the
+// id and expiry it carries.
+func (c *Core) parse(signed string, purpose string) (uuid.UUID, time.Time, error) {
+	p, sig, ok := strings.Cut(signed, ".")
+	if !ok {
+		return uuid.UUID{}, time.Time{}, errors.New("malformed token")
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return uuid.UUID{}, time.Time{}, fmt.Errorf("decoding signature: %w", err)
+	}
+
+	if !hmac.Equal(mac, c.mac(p, purpose)) {
+		return uuid.UUID{}, time.Time{}, errors.New("invalid signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil || len(payload) != 24 {
+		return uuid.UUID{}, time.Time{}, errors.New("malformed payload")
+	}
+
+	tokenID, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.UUID{}, time.Time{}, fmt.Errorf("parsing token id: %w", err)
+	}
+
+	expires := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+
+	return tokenID, expires, nil
+}
+
+func (c *Core) mac(payload string, purpose string) []byte {
+	h := hmac.New(sha256.New, c.secret)
+	h.Write([]byte(purpose + "." + payload))
+
+	return h.Sum(nil)
+}
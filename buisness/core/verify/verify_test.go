@@ -0,0 +1,149 @@
+package verify_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/verify"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/verify/stores/verifydb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/mailer"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Verify(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	var mlr outbox
+	core := verify.NewCore(test.Log, test.CoreAPIs.User, verifydb.NewStore(test.Log, test.DB), &mlr, []byte("secret"))
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("user@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	usr, err := test.CoreAPIs.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	if err := core.SendVerification(ctx, usr); err != nil {
+		t.Fatalf("Should be able to send a verification email : %s.", err)
+	}
+
+	verifyTkn := mlr.token(t)
+
+	if _, err := core.ResetPassword(ctx, verifyTkn, "N3w-Passphrase"); !errors.Is(err, verify.ErrInvalidToken) {
+		t.Fatalf("Should NOT be able to reset a password with a verification token : %s.", err)
+	}
+
+	usr, err = core.Verify(ctx, verifyTkn)
+	if err != nil {
+		t.Fatalf("Should be able to verify the email : %s.", err)
+	}
+
+	if !usr.EmailVerified {
+		t.Fatalf("Should have the email verified")
+	}
+
+	if _, err := core.Verify(ctx, verifyTkn); !errors.Is(err, verify.ErrInvalidToken) {
+		t.Fatalf("Should NOT be able to use a token twice : %s.", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	unknown, err := mail.ParseAddress("unknown@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	if err := core.SendReset(ctx, *unknown); err != nil {
+		t.Fatalf("Should not fail for an unknown email : %s.", err)
+	}
+
+	if len(mlr.msgs) != 0 {
+		t.Fatalf("Should NOT send an email for an unknown email")
+	}
+
+	if err := core.SendReset(ctx, *email); err != nil {
+		t.Fatalf("Should be able to send a reset email : %s.", err)
+	}
+
+	resetTkn := mlr.token(t)
+
+	if _, err := core.ResetPassword(ctx, resetTkn+"x", "N3w-Passphrase"); !errors.Is(err, verify.ErrInvalidToken) {
+		t.Fatalf("Should NOT be able to reset a password with a tampered token : %s.", err)
+	}
+
+	if _, err := core.ResetPassword(ctx, resetTkn, "N3w-Passphrase"); err != nil {
+		t.Fatalf("Should be able to reset the password : %s.", err)
+	}
+
+	if _, err := test.CoreAPIs.User.Authenticate(ctx, *email, "N3w-Passphrase", "127.0.0.1"); err != nil {
+		t.Fatalf("Should be able to authenticate with the new password : %s.", err)
+	}
+}
+
+// outbox is a mailer that keeps the messages it was asked to send.
+type outbox struct {
+	msgs []mailer.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mailer.Message) error {
+	o.msgs = append(o.msgs, msg)
+	return nil
+}
+
+// token removes the last message and returns the token in its body.
+func (o *outbox) token(t *testing.T) string {
+	t.Helper()
+
+	if len(o.msgs) == 0 {
+		t.Fatalf("Should have sent an email")
+	}
+
+	msg := o.msgs[len(o.msgs)-1]
+	o.msgs = o.msgs[:len(o.msgs)-1]
+
+	for _, line := range strings.Split(msg.Body, "\n") {
+		if strings.Contains(line, ".") && !strings.Contains(line, " ") {
+			return line
+		}
+	}
+
+	t.Fatalf("Should have a token in the email : %q", msg.Body)
+	return ""
+}
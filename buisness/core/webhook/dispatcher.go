@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Set of headers sent with every delivery.
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Sign returns the signature of a delivery. It is the hex encoded HMAC-SHA256
+// of the timestamp, a dot and the body, keyed with the secret of the
+// webhook. Receivers compute the same value to check a delivery.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// =============================================================================
+
+// DispatchConfig represents the settings of the dispatcher. A delivery that
+// failed waits MinBackoff before the next attempt, doubling every attempt up
+// to MaxBackoff, and is moved to the dead letters after MaxAttempts.
+type DispatchConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+	Client      *http.Client
+}
+
+// Dispatcher delivers the events waiting in the outbox to the webhooks.
+type Dispatcher struct {
+	log    *zap.SugaredLogger
+	core   *Core
+	client *http.Client
+	cfg    DispatchConfig
+}
+
+// NewDispatcher constructs a dispatcher for the outbox of the core. The
+// default http client is used when the config doesn't provide one.
+func NewDispatcher(log *zap.SugaredLogger, core *Core, cfg DispatchConfig) *Dispatcher {
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Dispatcher{
+		log:    log,
+		core:   core,
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+// Run dispatches the outbox at the interval. It blocks until the context is
+// cancelled. A failed dispatch is logged and tried again at the next tick.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if _, err := d.Dispatch(ctx); err != nil {
+				d.log.Errorw("webhook", "status", "dispatch failed", "ERROR", err)
+			}
+		}
+	}
+}
+
+// Dispatch claims a batch of the deliveries that are due and attempts them
+// concurrently. It returns how many were delivered. The deliveries are
+// claimed for twice the request timeout, so a delivery left behind by an
+// instance that stopped is attempted again once the claim expires.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	// The deliveries of every tenant are dispatched.
+	ctx = tenant.SetScopeAll(ctx)
+
+	now := time.Now()
+
+	ds, err := d.core.storer.ClaimDeliveries(ctx, now, now.Add(2*d.cfg.Timeout), d.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claimdeliveries: %w", err)
+	}
+
+	if len(ds) == 0 {
+		return 0, nil
+	}
+
+	// The webhooks are loaded once for the batch. A webhook removed since the
+	// delivery was claimed takes its deliveries with it.
+	whs := make(map[uuid.UUID]Webhook)
+	for _, dl := range ds {
+		if _, exists := whs[dl.WebhookID]; exists {
+			continue
+		}
+
+		wh, err := d.core.storer.QueryByID(ctx, dl.WebhookID)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return 0, fmt.Errorf("querybyid: webhookID[%s]: %w", dl.WebhookID, err)
+		}
+		whs[wh.ID] = wh
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		delivered int
+		errs      []error
+	)
+
+	for _, dl := range ds {
+		wh, exists := whs[dl.WebhookID]
+		if !exists {
+			continue
+		}
+
+		wg.Add(1)
+		go func(wh Webhook, dl Delivery) {
+			defer wg.Done()
+
+			ok, err := d.attempt(ctx, wh, dl)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if ok {
+				delivered++
+			}
+		}(wh, dl)
+	}
+
+	wg.Wait()
+
+	return delivered, errors.Join(errs...)
+}
+
+// attempt delivers the event to the webhook and records the outcome. It
+// returns true when the receiver accepted the event.
+func (d *Dispatcher) attempt(ctx context.Context, wh Webhook, dl Delivery) (bool, error) {
+	var sendErr error
+	switch {
+	case !wh.Enabled:
+		sendErr = errors.New("webhook disabled")
+		dl.Attempts = d.cfg.MaxAttempts
+
+	default:
+		sendErr = d.send(ctx, wh, dl)
+		dl.Attempts++
+	}
+
+	if sendErr == nil {
+		if err := d.core.storer.DeleteDelivery(ctx, dl); err != nil {
+			return true, fmt.Errorf("deletedelivery: deliveryID[%s]: %w", dl.ID, err)
+		}
+
+		d.log.Infow("webhook", "status", "delivered", "webhook_id", wh.ID, "delivery_id", dl.ID, "event", dl.Event, "attempts", dl.Attempts)
+		return true, nil
+	}
+
+	dl.LastError = sendErr.Error()
+
+	if dl.Attempts >= d.cfg.MaxAttempts {
+		if err := d.core.storer.MoveToDeadLetter(ctx, dl, time.Now()); err != nil {
+			return false, fmt.Errorf("movetodeadletter: deliveryID[%s]: %w", dl.ID, err)
+		}
+
+		d.log.Errorw("webhook", "status", "delivery dead lettered", "webhook_id", wh.ID, "delivery_id", dl.ID, "event", dl.Event, "attempts", dl.Attempts, "ERROR", sendErr)
+		return false, nil
+	}
+
+	dl.NextAttempt = time.Now().Add(d.backoff(dl.Attempts))
+
+	if err := d.core.storer.UpdateDelivery(ctx, dl); err != nil {
+		return false, fmt.Errorf("updatedelivery: deliveryID[%s]: %w", dl.ID, err)
+	}
+
+	d.log.Infow("webhook", "status", "delivery failed, will retry", "webhook_id", wh.ID, "delivery_id", dl.ID, "event", dl.Event, "attempts", dl.Attempts, "next_attempt", dl.NextAttempt, "ERROR", sendErr)
+	return false, nil
+}
+
+// send posts the signed payload to the webhook. Any status outside of the
+// 2xx range is a failure.
+func (d *Dispatcher) send(ctx context.Context, wh Webhook, dl Delivery) error {
+	ctx, cancel := context.WithTimeout(ctx, d.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+
+	ts := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, dl.EventID.String())
+	req.Header.Set(HeaderEvent, dl.Event)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(wh.Secret, ts, dl.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// The body is drained so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post: unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// backoff returns how long to wait before the next attempt of a delivery
+// that failed the specified number of attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.cfg.MinBackoff
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+
+	return wait
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// DomainName represents the name of this domain for delegate events.
+const DomainName = "webhook"
+
+// Set of delegate actions the webhook domain publishes.
+const (
+	// ActionCreated is published once a webhook is stored.
+	ActionCreated = "created"
+
+	// ActionUpdated is published once a webhook is updated.
+	ActionUpdated = "updated"
+
+	// ActionDeleted is published once a webhook is removed.
+	ActionDeleted = "deleted"
+)
+
+// ActionParms represents the parameters for the actions of the webhook
+// domain. Only the IDs are marshaled, Before and After are the webhook
+// before and after the change for the handlers in the service.
+type ActionParms struct {
+	WebhookID uuid.UUID `json:"webhookID"`
+	TenantID  uuid.UUID `json:"tenantID"`
+	Before    *Webhook  `json:"-"`
+	After     *Webhook  `json:"-"`
+}
+
+// ActionCreatedData constructs the data for the created action.
+func ActionCreatedData(wh Webhook) delegate.Data {
+	return actionData(ActionCreated, nil, &wh)
+}
+
+// ActionUpdatedData constructs the data for the updated action.
+func ActionUpdatedData(before Webhook, wh Webhook) delegate.Data {
+	return actionData(ActionUpdated, &before, &wh)
+}
+
+// ActionDeletedData constructs the data for the deleted action.
+func ActionDeletedData(wh Webhook) delegate.Data {
+	return actionData(ActionDeleted, &wh, nil)
+}
+
+// ParseActionParms returns the parameters of an action of the webhook
+// domain.
+func ParseActionParms(data delegate.Data) (ActionParms, error) {
+	params, ok := data.Params.(ActionParms)
+	if !ok {
+		return ActionParms{}, fmt.Errorf("unexpected params type %T for %s.%s", data.Params, data.Domain, data.Action)
+	}
+
+	return params, nil
+}
+
+func actionData(action string, before *Webhook, after *Webhook) delegate.Data {
+	wh := after
+	if wh == nil {
+		wh = before
+	}
+
+	return delegate.Data{
+		Domain: DomainName,
+		Action: action,
+		Params: ActionParms{WebhookID: wh.ID, TenantID: wh.TenantID, Before: before, After: after},
+	}
+}
@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook represents a URL of a tenant the events it subscribed to are
+// delivered to. The secret signs the deliveries so the receiver can check
+// they came from the service.
+type Webhook struct {
+	ID          uuid.UUID
+	TenantID    uuid.UUID
+	URL         string
+	Secret      string
+	Events      []string
+	Enabled     bool
+	DateCreated time.Time
+	DateUpdated time.Time
+}
+
+// NewWebhook contains information needed to create a new webhook.
+type NewWebhook struct {
+	TenantID uuid.UUID
+	URL      string
+	Events   []string
+}
+
+// UpdateWebhook contains information needed to update a webhook. Fields that
+// are not set are left unchanged.
+type UpdateWebhook struct {
+	URL     *string
+	Events  []string
+	Enabled *bool
+}
+
+// Delivery represents an event waiting in the outbox to be delivered to a
+// webhook. The event ID is the same for every webhook the event goes to, so
+// receivers can tell a retry from a new event.
+type Delivery struct {
+	ID          uuid.UUID
+	WebhookID   uuid.UUID
+	EventID     uuid.UUID
+	Event       string
+	Payload     []byte
+	Attempts    int
+	LastError   string
+	NextAttempt time.Time
+	DateCreated time.Time
+}
+
+// DeadLetter represents a delivery that failed every attempt.
+type DeadLetter struct {
+	ID          uuid.UUID
+	WebhookID   uuid.UUID
+	EventID     uuid.UUID
+	Event       string
+	Payload     []byte
+	Attempts    int
+	LastError   string
+	DateCreated time.Time
+	DateFailed  time.Time
+}
@@ -0,0 +1,149 @@
+package webhookdb
+
+import (
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/dbarray"
+	"github.com/google/uuid"
+)
+
+// dbWebhook represents an individual webhook.
+type dbWebhook struct {
+	ID          uuid.UUID      `db:"webhook_id"`
+	TenantID    uuid.UUID      `db:"tenant_id"`
+	URL         string         `db:"url"`
+	Secret      string         `db:"secret"`
+	Events      dbarray.String `db:"events"`
+	Enabled     bool           `db:"enabled"`
+	DateCreated time.Time      `db:"date_created"`
+	DateUpdated time.Time      `db:"date_updated"`
+}
+
+func toDBWebhook(wh webhook.Webhook) dbWebhook {
+	events := make([]string, len(wh.Events))
+	copy(events, wh.Events)
+
+	return dbWebhook{
+		ID:          wh.ID,
+		TenantID:    wh.TenantID,
+		URL:         wh.URL,
+		Secret:      wh.Secret,
+		Events:      events,
+		Enabled:     wh.Enabled,
+		DateCreated: wh.DateCreated.UTC(),
+		DateUpdated: wh.DateUpdated.UTC(),
+	}
+}
+
+func toCoreWebhook(dbWh dbWebhook) webhook.Webhook {
+	events := make([]string, len(dbWh.Events))
+	copy(events, dbWh.Events)
+
+	return webhook.Webhook{
+		ID:          dbWh.ID,
+		TenantID:    dbWh.TenantID,
+		URL:         dbWh.URL,
+		Secret:      dbWh.Secret,
+		Events:      events,
+		Enabled:     dbWh.Enabled,
+		DateCreated: dbWh.DateCreated.In(time.Local),
+		DateUpdated: dbWh.DateUpdated.In(time.Local),
+	}
+}
+
+func toCoreWebhookSlice(dbWhs []dbWebhook) []webhook.Webhook {
+	whs := make([]webhook.Webhook, len(dbWhs))
+	for i, dbWh := range dbWhs {
+		whs[i] = toCoreWebhook(dbWh)
+	}
+	return whs
+}
+
+// =============================================================================
+
+// dbDelivery represents a delivery waiting in the outbox.
+type dbDelivery struct {
+	ID          uuid.UUID `db:"delivery_id"`
+	WebhookID   uuid.UUID `db:"webhook_id"`
+	EventID     uuid.UUID `db:"event_id"`
+	Event       string    `db:"event"`
+	Payload     []byte    `db:"payload"`
+	Attempts    int       `db:"attempts"`
+	LastError   string    `db:"last_error"`
+	NextAttempt time.Time `db:"next_attempt_at"`
+	DateCreated time.Time `db:"date_created"`
+}
+
+func toDBDelivery(d webhook.Delivery) dbDelivery {
+	return dbDelivery{
+		ID:          d.ID,
+		WebhookID:   d.WebhookID,
+		EventID:     d.EventID,
+		Event:       d.Event,
+		Payload:     d.Payload,
+		Attempts:    d.Attempts,
+		LastError:   d.LastError,
+		NextAttempt: d.NextAttempt.UTC(),
+		DateCreated: d.DateCreated.UTC(),
+	}
+}
+
+func toCoreDelivery(dbD dbDelivery) webhook.Delivery {
+	return webhook.Delivery{
+		ID:          dbD.ID,
+		WebhookID:   dbD.WebhookID,
+		EventID:     dbD.EventID,
+		Event:       dbD.Event,
+		Payload:     dbD.Payload,
+		Attempts:    dbD.Attempts,
+		LastError:   dbD.LastError,
+		NextAttempt: dbD.NextAttempt.In(time.Local),
+		DateCreated: dbD.DateCreated.In(time.Local),
+	}
+}
+
+func toCoreDeliverySlice(dbDs []dbDelivery) []webhook.Delivery {
+	ds := make([]webhook.Delivery, len(dbDs))
+	for i, dbD := range dbDs {
+		ds[i] = toCoreDelivery(dbD)
+	}
+	return ds
+}
+
+// =============================================================================
+
+// dbDeadLetter represents a delivery that failed every attempt.
+type dbDeadLetter struct {
+	ID          uuid.UUID `db:"delivery_id"`
+	WebhookID   uuid.UUID `db:"webhook_id"`
+	EventID     uuid.UUID `db:"event_id"`
+	Event       string    `db:"event"`
+	Payload     []byte    `db:"payload"`
+	Attempts    int       `db:"attempts"`
+	LastError   string    `db:"last_error"`
+	DateCreated time.Time `db:"date_created"`
+	DateFailed  time.Time `db:"date_failed"`
+}
+
+func toCoreDeadLetter(dbDL dbDeadLetter) webhook.DeadLetter {
+	return webhook.DeadLetter{
+		ID:          dbDL.ID,
+		WebhookID:   dbDL.WebhookID,
+		EventID:     dbDL.EventID,
+		Event:       dbDL.Event,
+		Payload:     dbDL.Payload,
+		Attempts:    dbDL.Attempts,
+		LastError:   dbDL.LastError,
+		DateCreated: dbDL.DateCreated.In(time.Local),
+		DateFailed:  dbDL.DateFailed.In(time.Local),
+	}
+}
+
+func toCoreDeadLetterSlice(dbDLs []dbDeadLetter) []webhook.DeadLetter {
+	dls := make([]webhook.DeadLetter, len(dbDLs))
+	for i, dbDL := range dbDLs {
+		dls[i] = toCoreDeadLetter(dbDL)
+	}
+	return dls
+}
@@ -0,0 +1,362 @@
+// Package webhookdb contains webhook related CRUD functionality.
+package webhookdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Store manages the set of APIs for webhook database access.
+type Store struct {
+	log *zap.SugaredLogger
+	db  sqlx.ExtContext
+}
+
+// NewStore constructs the api for data access.
+func NewStore(log *zap.SugaredLogger, db *sqlx.DB) *Store {
+	return &Store{
+		log: log,
+		db:  db,
+	}
+}
+
+// ExecuteUnderTransaction constructs a new Store value replacing the sqlx DB
+// value with a sqlx DB value that is currently inside a transaction.
+func (s *Store) ExecuteUnderTransaction(tx transaction.Transaction) (webhook.Storer, error) {
+	ec, err := db.GetExtContext(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Store{
+		log: s.log,
+		db:  ec,
+	}
+
+	return s, nil
+}
+
+// Create inserts a new webhook into the database.
+func (s *Store) Create(ctx context.Context, wh webhook.Webhook) error {
+	const q = `
+	INSERT INTO webhooks
+		(webhook_id, tenant_id, url, secret, events, enabled, date_created, date_updated)
+	VALUES
+		(:webhook_id, :tenant_id, :url, :secret, :events, :enabled, :date_created, :date_updated)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBWebhook(wh)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Update replaces a webhook document in the database.
+func (s *Store) Update(ctx context.Context, wh webhook.Webhook) error {
+	const q = `
+	UPDATE
+		webhooks
+	SET
+		"url" = :url,
+		"events" = :events,
+		"enabled" = :enabled,
+		"date_updated" = :date_updated
+	WHERE
+		webhook_id = :webhook_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBWebhook(wh)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a webhook from the database. Its deliveries and dead letters
+// go with it.
+func (s *Store) Delete(ctx context.Context, wh webhook.Webhook) error {
+	data := struct {
+		ID string `db:"webhook_id"`
+	}{
+		ID: wh.ID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		webhooks
+	WHERE
+		webhook_id = :webhook_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryAll retrieves the webhooks the context can see from the database.
+func (s *Store) QueryAll(ctx context.Context) ([]webhook.Webhook, error) {
+	data := map[string]interface{}{}
+
+	const q = `
+	SELECT
+		webhook_id, tenant_id, url, secret, events, enabled, date_created, date_updated
+	FROM
+		webhooks`
+
+	buf := bytes.NewBufferString(q)
+	if tenantID, ok := tenant.GetScope(ctx); ok {
+		data["scope_tenant_id"] = tenantID
+		buf.WriteString(" WHERE tenant_id = :scope_tenant_id")
+	}
+	buf.WriteString(" ORDER BY date_created")
+
+	var dbWhs []dbWebhook
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, buf.String(), data, &dbWhs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreWebhookSlice(dbWhs), nil
+}
+
+// QueryByID finds the webhook identified by a given ID.
+func (s *Store) QueryByID(ctx context.Context, webhookID uuid.UUID) (webhook.Webhook, error) {
+	data := map[string]interface{}{
+		"webhook_id": webhookID.String(),
+	}
+
+	const q = `
+	SELECT
+		webhook_id, tenant_id, url, secret, events, enabled, date_created, date_updated
+	FROM
+		webhooks
+	WHERE
+		webhook_id = :webhook_id`
+
+	buf := bytes.NewBufferString(q)
+	applyScope(ctx, data, buf)
+
+	var dbWh dbWebhook
+	if err := db.NamedQueryStruct(ctx, s.log, s.db, buf.String(), data, &dbWh); err != nil {
+		if errors.Is(err, db.ErrDBNotFound) {
+			return webhook.Webhook{}, fmt.Errorf("namedquerystruct: %w", webhook.ErrNotFound)
+		}
+		return webhook.Webhook{}, fmt.Errorf("namedquerystruct: %w", err)
+	}
+
+	return toCoreWebhook(dbWh), nil
+}
+
+// QueryBySubscription finds the enabled webhooks of the tenant that
+// subscribed to the event.
+func (s *Store) QueryBySubscription(ctx context.Context, tenantID uuid.UUID, event string) ([]webhook.Webhook, error) {
+	data := struct {
+		TenantID string `db:"tenant_id"`
+		Event    string `db:"event"`
+	}{
+		TenantID: tenantID.String(),
+		Event:    event,
+	}
+
+	const q = `
+	SELECT
+		webhook_id, tenant_id, url, secret, events, enabled, date_created, date_updated
+	FROM
+		webhooks
+	WHERE
+		tenant_id = :tenant_id AND
+		enabled AND
+		:event = ANY(events)`
+
+	var dbWhs []dbWebhook
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbWhs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreWebhookSlice(dbWhs), nil
+}
+
+// =============================================================================
+
+// CreateDelivery inserts a new delivery into the outbox.
+func (s *Store) CreateDelivery(ctx context.Context, d webhook.Delivery) error {
+	const q = `
+	INSERT INTO webhook_deliveries
+		(delivery_id, webhook_id, event_id, event, payload, attempts, last_error, next_attempt_at, date_created)
+	VALUES
+		(:delivery_id, :webhook_id, :event_id, :event, :payload, :attempts, :last_error, :next_attempt_at, :date_created)`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBDelivery(d)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDeliveries pushes the next attempt of the deliveries that are due to
+// the lease and returns them. The rows another dispatcher has locked are
+// skipped, so two dispatchers never claim the same delivery.
+func (s *Store) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Time, limit int) ([]webhook.Delivery, error) {
+	data := struct {
+		Now   time.Time `db:"now"`
+		Lease time.Time `db:"lease"`
+		Limit int       `db:"limit"`
+	}{
+		Now:   now.UTC(),
+		Lease: lease.UTC(),
+		Limit: limit,
+	}
+
+	const q = `
+	UPDATE
+		webhook_deliveries
+	SET
+		next_attempt_at = :lease
+	WHERE
+		delivery_id IN (
+			SELECT
+				delivery_id
+			FROM
+				webhook_deliveries
+			WHERE
+				next_attempt_at <= :now
+			ORDER BY
+				next_attempt_at
+			LIMIT :limit
+			FOR UPDATE SKIP LOCKED
+		)
+	RETURNING
+		delivery_id, webhook_id, event_id, event, payload, attempts, last_error, next_attempt_at, date_created`
+
+	var dbDs []dbDelivery
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreDeliverySlice(dbDs), nil
+}
+
+// UpdateDelivery records a failed attempt of a delivery.
+func (s *Store) UpdateDelivery(ctx context.Context, d webhook.Delivery) error {
+	const q = `
+	UPDATE
+		webhook_deliveries
+	SET
+		"attempts" = :attempts,
+		"last_error" = :last_error,
+		"next_attempt_at" = :next_attempt_at
+	WHERE
+		delivery_id = :delivery_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, toDBDelivery(d)); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDelivery removes a delivered event from the outbox.
+func (s *Store) DeleteDelivery(ctx context.Context, d webhook.Delivery) error {
+	data := struct {
+		ID string `db:"delivery_id"`
+	}{
+		ID: d.ID.String(),
+	}
+
+	const q = `
+	DELETE FROM
+		webhook_deliveries
+	WHERE
+		delivery_id = :delivery_id`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// MoveToDeadLetter removes a delivery from the outbox and adds it to the dead
+// letters in one statement.
+func (s *Store) MoveToDeadLetter(ctx context.Context, d webhook.Delivery, dateFailed time.Time) error {
+	data := struct {
+		ID         string    `db:"delivery_id"`
+		Attempts   int       `db:"attempts"`
+		LastError  string    `db:"last_error"`
+		DateFailed time.Time `db:"date_failed"`
+	}{
+		ID:         d.ID.String(),
+		Attempts:   d.Attempts,
+		LastError:  d.LastError,
+		DateFailed: dateFailed.UTC(),
+	}
+
+	const q = `
+	WITH moved AS (
+		DELETE FROM
+			webhook_deliveries
+		WHERE
+			delivery_id = :delivery_id
+		RETURNING
+			delivery_id, webhook_id, event_id, event, payload, date_created
+	)
+	INSERT INTO webhook_dead_letters
+		(delivery_id, webhook_id, event_id, event, payload, attempts, last_error, date_created, date_failed)
+	SELECT
+		delivery_id, webhook_id, event_id, event, payload, :attempts, :last_error, date_created, :date_failed
+	FROM
+		moved`
+
+	if err := db.NamedExecContext(ctx, s.log, s.db, q, data); err != nil {
+		return fmt.Errorf("namedexeccontext: %w", err)
+	}
+
+	return nil
+}
+
+// QueryDeadLetters retrieves the dead letters of a webhook from the database.
+func (s *Store) QueryDeadLetters(ctx context.Context, webhookID uuid.UUID) ([]webhook.DeadLetter, error) {
+	data := struct {
+		ID string `db:"webhook_id"`
+	}{
+		ID: webhookID.String(),
+	}
+
+	const q = `
+	SELECT
+		delivery_id, webhook_id, event_id, event, payload, attempts, last_error, date_created, date_failed
+	FROM
+		webhook_dead_letters
+	WHERE
+		webhook_id = :webhook_id
+	ORDER BY
+		date_failed DESC`
+
+	var dbDLs []dbDeadLetter
+	if err := db.NamedQuerySlice(ctx, s.log, s.db, q, data, &dbDLs); err != nil {
+		return nil, fmt.Errorf("namedqueryslice: %w", err)
+	}
+
+	return toCoreDeadLetterSlice(dbDLs), nil
+}
+
+// =============================================================================
+
+// applyScope limits a query that already has a WHERE clause to the tenant
+// of the context.
+func applyScope(ctx context.Context, data map[string]interface{}, buf *bytes.Buffer) {
+	if tenantID, ok := tenant.GetScope(ctx); ok {
+		data["scope_tenant_id"] = tenantID
+		buf.WriteString(" AND tenant_id = :scope_tenant_id")
+	}
+}
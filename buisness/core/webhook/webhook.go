@@ -0,0 +1,346 @@
+// Package webhook provides the core business API for the webhooks tenants
+// register to be told about the changes to their users and products. The
+// events are written to an outbox in the transaction of the change and a
+// dispatcher delivers them afterwards.
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/uuid"
+)
+
+// Set of error variables for CRUD operations.
+var (
+	ErrNotFound     = errors.New("webhook not found")
+	ErrUnknownEvent = errors.New("event not known")
+)
+
+// secretPrefix marks the secrets generated by the service, so they are easy
+// to spot in configuration files and logs.
+const secretPrefix = "whsec_"
+
+// events are the events of the other domains a webhook can subscribe to.
+var events = []delegate.Data{
+	{Domain: user.DomainName, Action: user.ActionCreated},
+	{Domain: user.DomainName, Action: user.ActionUpdated},
+	{Domain: user.DomainName, Action: user.ActionDisabled},
+	{Domain: user.DomainName, Action: user.ActionDeleted},
+	{Domain: product.DomainName, Action: product.ActionCreated},
+	{Domain: product.DomainName, Action: product.ActionUpdated},
+	{Domain: product.DomainName, Action: product.ActionDeleted},
+}
+
+// Events returns the names of the events a webhook can subscribe to.
+func Events() []string {
+	names := make([]string, len(events))
+	for i, evt := range events {
+		names[i] = eventName(evt)
+	}
+
+	return names
+}
+
+func eventName(data delegate.Data) string {
+	return data.Domain + "." + data.Action
+}
+
+// =============================================================================
+
+// Storer interface declares the behavior this package needs to perists and
+// retrieve data.
+type Storer interface {
+	Create(ctx context.Context, wh Webhook) error
+	Update(ctx context.Context, wh Webhook) error
+	Delete(ctx context.Context, wh Webhook) error
+	QueryAll(ctx context.Context) ([]Webhook, error)
+	QueryByID(ctx context.Context, webhookID uuid.UUID) (Webhook, error)
+	// QueryBySubscription must return the enabled webhooks of the tenant
+	// that subscribed to the event, whatever the scope of the context.
+	QueryBySubscription(ctx context.Context, tenantID uuid.UUID, event string) ([]Webhook, error)
+	CreateDelivery(ctx context.Context, d Delivery) error
+	// ClaimDeliveries must push the next attempt of the deliveries it
+	// returns to the lease, skipping the deliveries another dispatcher is
+	// claiming, so an instance of the service can't deliver what another
+	// one is delivering.
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Time, limit int) ([]Delivery, error)
+	UpdateDelivery(ctx context.Context, d Delivery) error
+	DeleteDelivery(ctx context.Context, d Delivery) error
+	// MoveToDeadLetter must remove the delivery from the outbox and add it to
+	// the dead letters at once.
+	MoveToDeadLetter(ctx context.Context, d Delivery, dateFailed time.Time) error
+	QueryDeadLetters(ctx context.Context, webhookID uuid.UUID) ([]DeadLetter, error)
+	ExecuteUnderTransaction(tx transaction.Transaction) (Storer, error)
+}
+
+// =============================================================================
+
+// Option represents a function that can alter the core when it is
+// constructed.
+type Option func(c *Core)
+
+// WithDelegate sets the delegate the events of the webhook domain are
+// published to and the events of the other domains are handled from.
+// Without it no event is written to the outbox.
+func WithDelegate(dlg *delegate.Delegate) Option {
+	return func(c *Core) {
+		c.delegate = dlg
+	}
+}
+
+// Core manages the set of APIs for webhook access.
+type Core struct {
+	storer   Storer
+	delegate *delegate.Delegate
+}
+
+// NewCore constructs a core for webhook api access.
+func NewCore(storer Storer, options ...Option) *Core {
+	c := Core{
+		storer: storer,
+	}
+
+	for _, option := range options {
+		option(&c)
+	}
+
+	for _, evt := range events {
+		c.delegate.Register(evt.Domain, evt.Action, c.enqueue)
+	}
+
+	return &c
+}
+
+// ExecuteUnderTransaction constructs a new Core value that will use the
+// specified transaction in any store related calls.
+func (c *Core) ExecuteUnderTransaction(tx transaction.Transaction) (*Core, error) {
+	trS, err := c.storer.ExecuteUnderTransaction(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &Core{
+		storer:   trS,
+		delegate: c.delegate,
+	}
+
+	return c, nil
+}
+
+// Create adds a new webhook to the system. The secret deliveries are signed
+// with is generated here.
+func (c *Core) Create(ctx context.Context, nw NewWebhook) (Webhook, error) {
+	if err := checkEvents(nw.Events); err != nil {
+		return Webhook{}, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return Webhook{}, fmt.Errorf("generating secret: %w", err)
+	}
+
+	now := time.Now()
+
+	wh := Webhook{
+		ID:          uuid.New(),
+		TenantID:    nw.TenantID,
+		URL:         nw.URL,
+		Secret:      secretPrefix + base64.RawURLEncoding.EncodeToString(b),
+		Events:      nw.Events,
+		Enabled:     true,
+		DateCreated: now,
+		DateUpdated: now,
+	}
+
+	if err := c.storer.Create(ctx, wh); err != nil {
+		return Webhook{}, fmt.Errorf("create: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionCreatedData(wh)); err != nil {
+		return Webhook{}, fmt.Errorf("create: %w", err)
+	}
+
+	return wh, nil
+}
+
+// Update modifies information about a webhook.
+func (c *Core) Update(ctx context.Context, wh Webhook, uw UpdateWebhook) (Webhook, error) {
+	before := wh
+
+	if uw.URL != nil {
+		wh.URL = *uw.URL
+	}
+
+	if uw.Events != nil {
+		if err := checkEvents(uw.Events); err != nil {
+			return Webhook{}, err
+		}
+		wh.Events = uw.Events
+	}
+
+	if uw.Enabled != nil {
+		wh.Enabled = *uw.Enabled
+	}
+
+	wh.DateUpdated = time.Now()
+
+	if err := c.storer.Update(ctx, wh); err != nil {
+		return Webhook{}, fmt.Errorf("update: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionUpdatedData(before, wh)); err != nil {
+		return Webhook{}, fmt.Errorf("update: %w", err)
+	}
+
+	return wh, nil
+}
+
+// Delete removes the specified webhook with the deliveries still waiting for
+// it.
+func (c *Core) Delete(ctx context.Context, wh Webhook) error {
+	if err := c.storer.Delete(ctx, wh); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	if err := c.delegate.Call(ctx, ActionDeletedData(wh)); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// QueryAll retrieves the webhooks the context can see.
+func (c *Core) QueryAll(ctx context.Context) ([]Webhook, error) {
+	whs, err := c.storer.QueryAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("queryall: %w", err)
+	}
+
+	return whs, nil
+}
+
+// QueryByID finds the webhook by the specified ID.
+func (c *Core) QueryByID(ctx context.Context, webhookID uuid.UUID) (Webhook, error) {
+	wh, err := c.storer.QueryByID(ctx, webhookID)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("query: webhookID[%s]: %w", webhookID, err)
+	}
+
+	return wh, nil
+}
+
+// QueryDeadLetters retrieves the deliveries of the webhook that failed every
+// attempt.
+func (c *Core) QueryDeadLetters(ctx context.Context, webhookID uuid.UUID) ([]DeadLetter, error) {
+	dls, err := c.storer.QueryDeadLetters(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("querydeadletters: webhookID[%s]: %w", webhookID, err)
+	}
+
+	return dls, nil
+}
+
+// =============================================================================
+
+// payload represents the body of a delivery.
+type payload struct {
+	ID          uuid.UUID       `json:"id"`
+	Event       string          `json:"event"`
+	Data        json.RawMessage `json:"data"`
+	DateCreated time.Time       `json:"dateCreated"`
+}
+
+// enqueue writes an event to the outbox for every webhook of the tenant that
+// subscribed to it. It runs in the transaction of the change, so the event
+// is only delivered if the change is committed.
+func (c *Core) enqueue(ctx context.Context, data delegate.Data) error {
+	params, err := json.Marshal(data.Params)
+	if err != nil {
+		return fmt.Errorf("marshal params: %w", err)
+	}
+
+	// The params of every event a webhook can subscribe to carry the tenant
+	// the change belongs to.
+	var scope struct {
+		TenantID uuid.UUID `json:"tenantID"`
+	}
+	if err := json.Unmarshal(params, &scope); err != nil {
+		return fmt.Errorf("unmarshal tenant: %w", err)
+	}
+
+	if tx, ok := transaction.Get(ctx); ok {
+		c, err = c.ExecuteUnderTransaction(tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	event := eventName(data)
+
+	whs, err := c.storer.QueryBySubscription(ctx, scope.TenantID, event)
+	if err != nil {
+		return fmt.Errorf("querybysubscription: event[%s]: %w", event, err)
+	}
+
+	if len(whs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	pl := payload{
+		ID:          uuid.New(),
+		Event:       event,
+		Data:        params,
+		DateCreated: now.UTC(),
+	}
+
+	body, err := json.Marshal(pl)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+
+	for _, wh := range whs {
+		d := Delivery{
+			ID:          uuid.New(),
+			WebhookID:   wh.ID,
+			EventID:     pl.ID,
+			Event:       event,
+			Payload:     body,
+			NextAttempt: now,
+			DateCreated: now,
+		}
+
+		if err := c.storer.CreateDelivery(ctx, d); err != nil {
+			return fmt.Errorf("createdelivery: webhookID[%s]: %w", wh.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// checkEvents makes sure the webhook only subscribes to known events.
+func checkEvents(names []string) error {
+	known := make(map[string]bool, len(events))
+	for _, evt := range events {
+		known[eventName(evt)] = true
+	}
+
+	for _, name := range names {
+		if !known[name] {
+			return fmt.Errorf("%w: %q", ErrUnknownEvent, name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbtest"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
+)
+
+var c *docker.Container
+
+func TestMain(m *testing.M) {
+	var err error
+	c, err = dbtest.StartDB()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dbtest.StopDB(c)
+
+	m.Run()
+}
+
+func Test_Webhook(t *testing.T) {
+	t.Run("delivery", delivery)
+}
+
+// =============================================================================
+
+// receiver records the deliveries an httptest server is sent.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	rc.mu.Unlock()
+
+	w.WriteHeader(rc.status)
+}
+
+func delivery(t *testing.T) {
+	test := dbtest.NewTest(t, c)
+	defer func() {
+		if r := recover(); r != nil {
+			t.Log(r)
+			t.Error(string(debug.Stack()))
+		}
+		test.Teardown()
+	}()
+
+	api := test.CoreAPIs
+
+	ctx, cancel := context.WithTimeout(tenant.SetScopeAll(context.Background()), 10*time.Second)
+	defer cancel()
+
+	// -------------------------------------------------------------------------
+
+	email, err := mail.ParseAddress("admin@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	admin, err := api.User.QueryByEmail(ctx, *email)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the seeded user: %s.", err)
+	}
+
+	ok := receiver{status: http.StatusOK}
+	okSrv := httptest.NewServer(&ok)
+	defer okSrv.Close()
+
+	failing := receiver{status: http.StatusInternalServerError}
+	failingSrv := httptest.NewServer(&failing)
+	defer failingSrv.Close()
+
+	if _, err := api.Webhook.Create(ctx, webhook.NewWebhook{TenantID: admin.TenantID, URL: okSrv.URL, Events: []string{"user.renamed"}}); !errors.Is(err, webhook.ErrUnknownEvent) {
+		t.Fatalf("Should NOT be able to subscribe to an unknown event : %s.", err)
+	}
+
+	okWh, err := api.Webhook.Create(ctx, webhook.NewWebhook{TenantID: admin.TenantID, URL: okSrv.URL, Events: []string{"user.created"}})
+	if err != nil {
+		t.Fatalf("Should be able to create a webhook : %s.", err)
+	}
+
+	failingWh, err := api.Webhook.Create(ctx, webhook.NewWebhook{TenantID: admin.TenantID, URL: failingSrv.URL, Events: []string{"user.updated"}})
+	if err != nil {
+		t.Fatalf("Should be able to create a webhook : %s.", err)
+	}
+
+	dsp := webhook.NewDispatcher(test.Log, api.Webhook, webhook.DispatchConfig{
+		Interval:    time.Second,
+		BatchSize:   10,
+		MaxAttempts: 2,
+		Timeout:     5 * time.Second,
+	})
+
+	// -------------------------------------------------------------------------
+
+	newEmail, err := mail.ParseAddress("hook@example.com")
+	if err != nil {
+		t.Fatalf("Should be able to parse email: %s.", err)
+	}
+
+	nu := user.NewUser{
+		TenantID: admin.TenantID,
+		Name:     "Hook Gopher",
+		Email:    *newEmail,
+		Roles:    []user.Role{user.RoleUser},
+		Password: "Hook-Gopher-42",
+	}
+
+	usr, err := api.User.Create(ctx, nu)
+	if err != nil {
+		t.Fatalf("Should be able to create a user : %s.", err)
+	}
+
+	n, err := dsp.Dispatch(ctx)
+	if err != nil {
+		t.Fatalf("Should be able to dispatch the outbox : %s.", err)
+	}
+
+	if n != 1 || len(ok.requests) != 1 {
+		t.Fatalf("Should deliver the created event once, delivered %d, received %d", n, len(ok.requests))
+	}
+
+	req, body := ok.requests[0], ok.bodies[0]
+
+	if req.Header.Get(webhook.HeaderEvent) != "user.created" {
+		t.Errorf("Should get the name of the event, got %q", req.Header.Get(webhook.HeaderEvent))
+	}
+
+	ts, err := strconv.ParseInt(req.Header.Get(webhook.HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("Should get the timestamp of the delivery : %s.", err)
+	}
+
+	if req.Header.Get(webhook.HeaderSignature) != webhook.Sign(okWh.Secret, ts, body) {
+		t.Errorf("Should be able to verify the signature of the delivery")
+	}
+
+	if !strings.Contains(string(body), usr.ID.String()) {
+		t.Errorf("Should get the user in the payload, got %s", body)
+	}
+
+	if n, err := dsp.Dispatch(ctx); err != nil || n != 0 {
+		t.Fatalf("Should NOT deliver an event twice, delivered %d : %v.", n, err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := api.User.Update(ctx, usr, user.UpdateUser{Name: dbtest.StringPointer("Hooked Gopher")}); err != nil {
+		t.Fatalf("Should be able to update the user : %s.", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if n, err := dsp.Dispatch(ctx); err != nil || n != 0 {
+			t.Fatalf("Should NOT deliver to a failing webhook, delivered %d : %v.", n, err)
+		}
+	}
+
+	if len(failing.requests) != 2 {
+		t.Fatalf("Should attempt the delivery twice, got %d", len(failing.requests))
+	}
+
+	dls, err := api.Webhook.QueryDeadLetters(ctx, failingWh.ID)
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the dead letters : %s.", err)
+	}
+
+	if len(dls) != 1 || dls[0].Event != "user.updated" || dls[0].Attempts != 2 {
+		t.Fatalf("Should move the delivery to the dead letters after the last attempt, got %+v", dls)
+	}
+
+	if n, err := dsp.Dispatch(ctx); err != nil || n != 0 || len(failing.requests) != 2 {
+		t.Fatalf("Should NOT attempt a dead letter again, delivered %d : %v.", n, err)
+	}
+}
@@ -9,7 +9,7 @@ import (
 
 // QueryFilter holds the available fields a query can be filtered on.
 type QueryFilter struct {
-	UserID   *uuid.UUID `validate:"omitempty,uuid4"`
+	UserID   *uuid.UUID `validate:"omitempty"`
 	UserName *string    `validate:"omitempty,min=3"`
 }
 
@@ -0,0 +1,38 @@
+package summarydb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/cview/user/summary"
+)
+
+// applyFilter adds the filter to the query. A context limited to a tenant
+// only sees the summaries of the users of that tenant.
+func (s *Store) applyFilter(ctx context.Context, filter summary.QueryFilter, data map[string]interface{}, buf *bytes.Buffer) {
+	var wc []string
+
+	if tenantID, ok := tenant.GetScope(ctx); ok {
+		data["scope_tenant_id"] = tenantID
+		wc = append(wc, "tenant_id = :scope_tenant_id")
+	}
+
+	if filter.UserID != nil {
+		data["user_id"] = *filter.UserID
+		wc = append(wc, "user_id = :user_id")
+	}
+
+	if filter.UserName != nil {
+		data["user_name"] = fmt.Sprintf("%%%s%%", *filter.UserName)
+		wc = append(wc, "user_name LIKE :user_name")
+	}
+
+	// Add string "WHERE" if wc is not empty
+	if len(wc) > 0 {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(strings.Join(wc, " AND "))
+	}
+}
@@ -0,0 +1,33 @@
+package summarydb
+
+import (
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/cview/user/summary"
+	"github.com/google/uuid"
+)
+
+// dbSummary represents a row from the user_summary view.
+type dbSummary struct {
+	UserID     uuid.UUID `db:"user_id"`
+	UserName   string    `db:"user_name"`
+	TotalCount int       `db:"total_count"`
+	TotalCost  float64   `db:"total_cost"`
+}
+
+func toCoreSummary(dbSmm dbSummary) summary.Summary {
+	smm := summary.Summary{
+		UserID:     dbSmm.UserID,
+		UserName:   dbSmm.UserName,
+		TotalCount: dbSmm.TotalCount,
+		TotalCost:  dbSmm.TotalCost,
+	}
+
+	return smm
+}
+
+func toCoreSummarySlice(dbSmms []dbSummary) []summary.Summary {
+	smms := make([]summary.Summary, len(dbSmms))
+	for i, dbSmm := range dbSmms {
+		smms[i] = toCoreSummary(dbSmm)
+	}
+	return smms
+}
@@ -44,3 +44,376 @@ JOIN
 GROUP BY
     u.user_id
 
+-- Version: 1.04
+-- Description: Report users without products in the user_summary view.
+CREATE OR REPLACE VIEW user_summary AS
+SELECT
+    u.user_id                AS user_id,
+	u.name                   AS user_name,
+    COUNT(p.product_id)      AS total_count,
+    COALESCE(SUM(p.cost), 0) AS total_cost
+FROM
+    users AS u
+LEFT JOIN
+    products AS p ON p.user_id = u.user_id
+GROUP BY
+    u.user_id;
+
+-- Version: 1.05
+-- Description: Create table sales
+CREATE TABLE sales (
+	sale_id      UUID           NOT NULL,
+	product_id   UUID           NOT NULL,
+	user_id      UUID           NOT NULL,
+	quantity     INT            NOT NULL,
+	paid         NUMERIC(10, 2) NOT NULL,
+	date_created TIMESTAMP      NOT NULL,
+
+	PRIMARY KEY (sale_id),
+	FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Version: 1.06
+-- Description: Add product_sales view to compute sold and revenue.
+CREATE OR REPLACE VIEW product_sales AS
+SELECT
+	p.product_id                 AS product_id,
+	p.user_id                    AS user_id,
+	p.name                       AS name,
+	p.cost                       AS cost,
+	p.quantity                   AS quantity,
+	COALESCE(SUM(s.quantity), 0) AS sold,
+	COALESCE(SUM(s.paid), 0)     AS revenue,
+	p.date_created               AS date_created,
+	p.date_updated               AS date_updated
+FROM
+	products AS p
+LEFT JOIN
+	sales AS s ON s.product_id = p.product_id
+GROUP BY
+	p.product_id;
+
+-- Version: 1.07
+-- Description: Create table refresh_tokens
+CREATE TABLE refresh_tokens (
+	token_id     UUID      NOT NULL,
+	user_id      UUID      NOT NULL,
+	token_hash   TEXT      NOT NULL,
+	date_expires TIMESTAMP NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_revoked TIMESTAMP NULL,
+
+	PRIMARY KEY (token_id),
+	UNIQUE (token_hash),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Version: 1.08
+-- Description: Create table revoked_tokens for the access token denylist
+CREATE TABLE revoked_tokens (
+	jti          TEXT      NOT NULL,
+	date_expires TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (jti)
+);
+
+-- Version: 1.09
+-- Description: Create table api_keys
+CREATE TABLE api_keys (
+	key_id       UUID      NOT NULL,
+	user_id      UUID      NOT NULL,
+	name         TEXT      NOT NULL,
+	prefix       TEXT      NOT NULL,
+	key_hash     TEXT      NOT NULL,
+	roles        TEXT[]    NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_revoked TIMESTAMP NULL,
+
+	PRIMARY KEY (key_id),
+	UNIQUE (key_hash),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Version: 1.10
+-- Description: Add locked_until to users
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP NULL;
+
+-- Version: 1.11
+-- Description: Create table login_failures
+CREATE TABLE login_failures (
+	failure_id   UUID      NOT NULL,
+	user_id      UUID      NULL,
+	address      TEXT      NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (failure_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Version: 1.12
+-- Description: Index login_failures by address
+CREATE INDEX login_failures_address_idx ON login_failures (address, date_created);
+
+-- Version: 1.13
+-- Description: Add email_verified to users
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Version: 1.14
+-- Description: Create table action_tokens for email verification and password resets
+CREATE TABLE action_tokens (
+	token_id     UUID      NOT NULL,
+	user_id      UUID      NOT NULL,
+	purpose      TEXT      NOT NULL,
+	date_expires TIMESTAMP NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_used    TIMESTAMP NULL,
+
+	PRIMARY KEY (token_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Version: 1.15
+-- Description: Create table mfa_factors
+CREATE TABLE mfa_factors (
+	user_id        UUID      NOT NULL,
+	secret         BYTEA     NOT NULL,
+	last_step      BIGINT    NOT NULL DEFAULT 0,
+	failures       INT       NOT NULL DEFAULT 0,
+	locked_until   TIMESTAMP NULL,
+	date_created   TIMESTAMP NOT NULL,
+	date_confirmed TIMESTAMP NULL,
+
+	PRIMARY KEY (user_id),
+	FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- Version: 1.16
+-- Description: Create table mfa_recovery_codes
+CREATE TABLE mfa_recovery_codes (
+	code_id      UUID      NOT NULL,
+	user_id      UUID      NOT NULL,
+	code_hash    TEXT      NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_used    TIMESTAMP NULL,
+
+	PRIMARY KEY (code_id),
+	UNIQUE (user_id, code_hash),
+	FOREIGN KEY (user_id) REFERENCES mfa_factors(user_id) ON DELETE CASCADE
+);
+
+-- Version: 1.17
+-- Description: Add amr to refresh_tokens
+ALTER TABLE refresh_tokens ADD COLUMN amr TEXT[] NOT NULL DEFAULT '{}';
+
+-- Version: 1.18
+-- Description: Create table tenants
+CREATE TABLE tenants (
+	tenant_id    UUID        NOT NULL,
+	name         TEXT UNIQUE NOT NULL,
+	date_created TIMESTAMP   NOT NULL,
+	date_updated TIMESTAMP   NOT NULL,
+
+	PRIMARY KEY (tenant_id)
+);
+
+-- Version: 1.19
+-- Description: Add the default tenant that existing users are moved to
+INSERT INTO tenants (tenant_id, name, date_created, date_updated)
+VALUES ('9a3f8d1e-27c4-4b6a-8e5d-0c1b2f7a4e93', 'Default', now(), now())
+ON CONFLICT DO NOTHING;
+
+-- Version: 1.20
+-- Description: Add tenant_id to users
+ALTER TABLE users ADD COLUMN tenant_id UUID NOT NULL DEFAULT '9a3f8d1e-27c4-4b6a-8e5d-0c1b2f7a4e93' REFERENCES tenants(tenant_id);
+
+-- Version: 1.21
+-- Description: Require the tenant of new users to be set
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- Version: 1.22
+-- Description: Add tenant_id to products
+ALTER TABLE products ADD COLUMN tenant_id UUID NOT NULL DEFAULT '9a3f8d1e-27c4-4b6a-8e5d-0c1b2f7a4e93' REFERENCES tenants(tenant_id);
+
+-- Version: 1.23
+-- Description: Require the tenant of new products to be set
+ALTER TABLE products ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- Version: 1.24
+-- Description: Add tenant_id to the product_sales view
+CREATE OR REPLACE VIEW product_sales AS
+SELECT
+	p.product_id                 AS product_id,
+	p.user_id                    AS user_id,
+	p.name                       AS name,
+	p.cost                       AS cost,
+	p.quantity                   AS quantity,
+	COALESCE(SUM(s.quantity), 0) AS sold,
+	COALESCE(SUM(s.paid), 0)     AS revenue,
+	p.date_created               AS date_created,
+	p.date_updated               AS date_updated,
+	p.tenant_id                  AS tenant_id
+FROM
+	products AS p
+LEFT JOIN
+	sales AS s ON s.product_id = p.product_id
+GROUP BY
+	p.product_id;
+
+-- Version: 1.25
+-- Description: Add tenant_id to the user_summary view
+CREATE OR REPLACE VIEW user_summary AS
+SELECT
+    u.user_id                AS user_id,
+	u.name                   AS user_name,
+    COUNT(p.product_id)      AS total_count,
+    COALESCE(SUM(p.cost), 0) AS total_cost,
+    u.tenant_id              AS tenant_id
+FROM
+    users AS u
+LEFT JOIN
+    products AS p ON p.user_id = u.user_id
+GROUP BY
+    u.user_id;
+
+-- Version: 1.26
+-- Description: Create table roles
+CREATE TABLE roles (
+	name         TEXT      NOT NULL,
+	permissions  TEXT[]    NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_updated TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (name)
+);
+
+-- Version: 1.27
+-- Description: Add the built in roles
+INSERT INTO roles (name, permissions, date_created, date_updated) VALUES
+	('USER', '{product:read,product:write,sale:write,apikey:write}', NOW(), NOW()),
+	('ADMIN', '{user:read,user:write,product:read,product:write,sale:read,sale:write,apikey:write,tenant:read}', NOW(), NOW()),
+	('SUPER_ADMIN', '{user:read,user:write,product:read,product:write,sale:read,sale:write,apikey:write,tenant:read,tenant:write,role:write}', NOW(), NOW())
+	ON CONFLICT DO NOTHING;
+
+-- Version: 1.28
+-- Description: Create table audits
+CREATE TABLE audits (
+	audit_id     UUID      NOT NULL,
+	actor_id     UUID      NULL,
+	tenant_id    UUID      NULL,
+	action       TEXT      NOT NULL,
+	entity       TEXT      NOT NULL,
+	entity_id    TEXT      NOT NULL,
+	before       JSONB     NULL,
+	after        JSONB     NULL,
+	trace_id     TEXT      NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (audit_id)
+);
+
+-- Version: 1.29
+-- Description: Index the audits by entity
+CREATE INDEX audits_entity_idx ON audits (entity, entity_id);
+
+-- Version: 1.30
+-- Description: Index the audits by actor
+CREATE INDEX audits_actor_idx ON audits (actor_id);
+
+-- Version: 1.31
+-- Description: Create table webhooks
+CREATE TABLE webhooks (
+	webhook_id   UUID      NOT NULL,
+	tenant_id    UUID      NOT NULL REFERENCES tenants(tenant_id) ON DELETE CASCADE,
+	url          TEXT      NOT NULL,
+	secret       TEXT      NOT NULL,
+	events       TEXT[]    NOT NULL,
+	enabled      BOOLEAN   NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_updated TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (webhook_id)
+);
+
+-- Version: 1.32
+-- Description: Create table webhook_deliveries, the outbox of the webhooks
+CREATE TABLE webhook_deliveries (
+	delivery_id     UUID      NOT NULL,
+	webhook_id      UUID      NOT NULL REFERENCES webhooks(webhook_id) ON DELETE CASCADE,
+	event_id        UUID      NOT NULL,
+	event           TEXT      NOT NULL,
+	payload         JSONB     NOT NULL,
+	attempts        INT       NOT NULL,
+	last_error      TEXT      NOT NULL,
+	next_attempt_at TIMESTAMP NOT NULL,
+	date_created    TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (delivery_id)
+);
+
+-- Version: 1.33
+-- Description: Index the webhook deliveries by when they are due
+CREATE INDEX webhook_deliveries_next_attempt_idx ON webhook_deliveries (next_attempt_at);
+
+-- Version: 1.34
+-- Description: Create table webhook_dead_letters for the deliveries that failed every attempt
+CREATE TABLE webhook_dead_letters (
+	delivery_id  UUID      NOT NULL,
+	webhook_id   UUID      NOT NULL REFERENCES webhooks(webhook_id) ON DELETE CASCADE,
+	event_id     UUID      NOT NULL,
+	event        TEXT      NOT NULL,
+	payload      JSONB     NOT NULL,
+	attempts     INT       NOT NULL,
+	last_error   TEXT      NOT NULL,
+	date_created TIMESTAMP NOT NULL,
+	date_failed  TIMESTAMP NOT NULL,
+
+	PRIMARY KEY (delivery_id)
+);
+
+-- Version: 1.35
+-- Description: Add the function that notifies the changes feed of a changed row
+CREATE OR REPLACE FUNCTION notify_change() RETURNS TRIGGER AS $$
+DECLARE
+	rec JSONB;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		rec := to_jsonb(OLD);
+	ELSE
+		rec := to_jsonb(NEW);
+	END IF;
+
+	PERFORM pg_notify('changes', json_build_object(
+		'entity', TG_ARGV[0],
+		'action', CASE TG_OP WHEN 'INSERT' THEN 'created' WHEN 'UPDATE' THEN 'updated' ELSE 'deleted' END,
+		'id', rec ->> TG_ARGV[1],
+		'user_id', rec ->> 'user_id',
+		'tenant_id', rec ->> 'tenant_id'
+	)::TEXT);
+
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Version: 1.36
+-- Description: Notify the changes feed of the changed users
+CREATE TRIGGER users_notify_change AFTER INSERT OR UPDATE OR DELETE ON users
+	FOR EACH ROW EXECUTE FUNCTION notify_change('user', 'user_id');
+
+-- Version: 1.37
+-- Description: Notify the changes feed of the changed products
+CREATE TRIGGER products_notify_change AFTER INSERT OR UPDATE OR DELETE ON products
+	FOR EACH ROW EXECUTE FUNCTION notify_change('product', 'product_id');
+
+-- Version: 1.38
+-- Description: Keep the sales when their product or user is deleted, the sold and revenue figures are computed from them
+ALTER TABLE sales
+	DROP CONSTRAINT sales_product_id_fkey,
+	DROP CONSTRAINT sales_user_id_fkey,
+	ADD CONSTRAINT sales_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(product_id) ON DELETE RESTRICT,
+	ADD CONSTRAINT sales_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE RESTRICT;
+
+-- Version: 1.39
+-- Description: Let the built in roles read the events stream
+UPDATE roles SET permissions = array_append(permissions, 'events:read')
+	WHERE name IN ('USER', 'ADMIN', 'SUPER_ADMIN') AND NOT ('events:read' = ANY(permissions));
@@ -1,3 +1,16 @@
+INSERT INTO tenants (
+    tenant_id,
+    name,
+    date_created,
+    date_updated
+  )
+VALUES (
+    '9a3f8d1e-27c4-4b6a-8e5d-0c1b2f7a4e93',
+    'Default',
+    '2019-03-24 00:00:00',
+    '2019-03-24 00:00:00'
+  ) ON CONFLICT DO NOTHING;
+
 INSERT INTO users (
     user_id,
     name,
@@ -5,6 +18,7 @@ INSERT INTO users (
     roles,
     password_hash,
     department,
+    tenant_id,
     enabled,
     date_created,
     date_updated
@@ -16,6 +30,7 @@ VALUES (
     '{ADMIN,USER}',
     '$2a$10$1ggfMVZV6Js0ybvJufLRUOWHS5f6KneuP0XwwHpJ8L8ipdry9f2/a',
     NULL,
+    '9a3f8d1e-27c4-4b6a-8e5d-0c1b2f7a4e93',
     true,
     '2019-03-24 00:00:00',
     '2019-03-24 00:00:00'
@@ -27,6 +42,19 @@ VALUES (
     '{USER}',
     '$2a$10$9/XASPKBbJKVfCAZKDH.UuhsuALDr5vVm6VrYA9VFR8rccK86C1hW',
     NULL,
+    '9a3f8d1e-27c4-4b6a-8e5d-0c1b2f7a4e93',
+    true,
+    '2019-03-24 00:00:00',
+    '2019-03-24 00:00:00'
+  ),
+  (
+    'e0c7a5b2-3d1f-4c8e-9b6a-7f2d4e1c8a05',
+    'Super Gopher',
+    'super@example.com',
+    '{SUPER_ADMIN}',
+    '$2a$10$1ggfMVZV6Js0ybvJufLRUOWHS5f6KneuP0XwwHpJ8L8ipdry9f2/a',
+    NULL,
+    '9a3f8d1e-27c4-4b6a-8e5d-0c1b2f7a4e93',
     true,
     '2019-03-24 00:00:00',
     '2019-03-24 00:00:00'
@@ -12,10 +12,27 @@ import (
 	"testing"
 	"time"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey/stores/apikeydb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit/stores/auditdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product/stores/productdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role/stores/roledb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/sale/stores/saledb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant/stores/tenantdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/token/stores/tokendb"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user/stores/userdb"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/webhook/stores/webhookdb"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/data/dbmigrate"
 	db "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/pgx"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/docker"
 	"go.uber.org/zap"
@@ -150,14 +167,22 @@ func NewTest(t *testing.T, c *docker.Container) *Test {
 	// -------------------------------------------------------------------------
 
 	cfg := auth.Config{
-		Log:       log,
-		KeyLookup: &keyStore{},
+		Log:          log,
+		KeyLookup:    &keyStore{},
+		Denylist:     coreAPIs.Token,
+		APIKeys:      coreAPIs.APIKey,
+		EnabledCheck: coreAPIs.User,
+		Roles:        coreAPIs.Role,
 	}
 	a, err := auth.New(cfg)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	if err := a.ReloadRoles(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
 	// -------------------------------------------------------------------------
 
 	// teardown is the function that should be invoked when the caller is done
@@ -190,7 +215,7 @@ func (test *Test) Token(email string, pass string) string {
 	addr, _ := mail.ParseAddress(email)
 
 	store := userdb.NewStore(test.Log, test.DB)
-	dbUsr, err := store.QueryByEmail(context.Background(), *addr)
+	dbUsr, err := store.QueryByEmail(tenant.SetScopeAll(context.Background()), *addr)
 	if err != nil {
 		return ""
 	}
@@ -202,7 +227,8 @@ func (test *Test) Token(email string, pass string) string {
 			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 		},
-		Roles: dbUsr.Roles,
+		Roles:    dbUsr.Roles,
+		TenantID: dbUsr.TenantID.String(),
 	}
 
 	token, err := test.Auth.GenerateToken(kid, claims)
@@ -236,18 +262,51 @@ func FloatPointer(f float64) *float64 {
 	return &f
 }
 
+// BoolPointer is a helper to get a *bool from a bool. It is in the tests
+// package because we normally don't want to deal with pointers to basic types
+// but it's useful in some tests.
+func BoolPointer(b bool) *bool {
+	return &b
+}
+
 // =============================================================================
 
 // CoreAPIs represents all the core api's needed for testing.
 type CoreAPIs struct {
-	User *user.Core
+	User    *user.Core
+	Product *product.Core
+	Sale    *sale.Core
+	Token   *token.Core
+	APIKey  *apikey.Core
+	Tenant  *tenant.Core
+	Role    *role.Core
+	Audit   *audit.Core
+	Webhook *webhook.Core
 }
 
 func newCoreAPIs(log *zap.SugaredLogger, db *sqlx.DB) CoreAPIs {
-	usrCore := user.NewCore(userdb.NewStore(log, db))
+	dlg := delegate.New(log)
+
+	audCore := audit.NewCore(auditdb.NewStore(log, db), audit.WithDelegate(dlg))
+	usrCore := user.NewCore(userdb.NewStore(log, db), user.WithDelegate(dlg))
+	prdCore := product.NewCore(usrCore, productdb.NewStore(log, db), product.WithDelegate(dlg))
+	slCore := sale.NewCore(prdCore, saledb.NewStore(log, db), sale.WithDelegate(dlg))
+	tknCore := token.NewCore(tokendb.NewStore(log, db), token.WithDelegate(dlg))
+	akCore := apikey.NewCore(usrCore, apikeydb.NewStore(log, db), apikey.WithDelegate(dlg))
+	tntCore := tenant.NewCore(tenantdb.NewStore(log, db), tenant.WithDelegate(dlg))
+	rlCore := role.NewCore(roledb.NewStore(log, db), role.WithDelegate(dlg))
+	whCore := webhook.NewCore(webhookdb.NewStore(log, db), webhook.WithDelegate(dlg))
 
 	return CoreAPIs{
-		User: usrCore,
+		User:    usrCore,
+		Product: prdCore,
+		Sale:    slCore,
+		Token:   tknCore,
+		APIKey:  akCore,
+		Tenant:  tntCore,
+		Role:    rlCore,
+		Audit:   audCore,
+		Webhook: whCore,
 	}
 }
 
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Notification represents a message a session sent on a channel with
+// NOTIFY. A notification sent in a transaction is only delivered once the
+// transaction commits.
+type Notification struct {
+	Channel string
+	Payload string
+}
+
+// Listen takes a connection out of the pool and listens on the channel with
+// it, calling fn for every notification. It blocks until the context is
+// cancelled, which is not an error, or the connection fails.
+func Listen(ctx context.Context, log *zap.SugaredLogger, db *sqlx.DB, channel string, fn func(Notification)) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("conn: %w", err)
+	}
+	defer conn.Close()
+
+	// LISTEN is per connection, so we need the pgx connection the pool
+	// handed out rather than the pool itself.
+	return conn.Raw(func(driverConn any) error {
+		sc, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+		pc := sc.Conn()
+
+		q := "LISTEN " + pgx.Identifier{channel}.Sanitize()
+		log.Infow("database.Listen", "query", q)
+
+		if _, err := pc.Exec(ctx, q); err != nil {
+			return fmt.Errorf("listen: %w", err)
+		}
+
+		// The connection goes back to the pool, it must not keep listening.
+		// Once the context is cancelled pgx may have closed the connection,
+		// the pool discards it then.
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			pc.Exec(ctx, "UNLISTEN *")
+		}()
+
+		for {
+			n, err := pc.WaitForNotification(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("waitfornotification: %w", err)
+			}
+
+			fn(Notification{Channel: n.Channel, Payload: n.Payload})
+		}
+	})
+}
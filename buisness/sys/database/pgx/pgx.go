@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
 	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -18,14 +19,16 @@ import (
 )
 
 const (
-	uniqueViolation = "23505"
-	undefinedTable  = "42P01"
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	undefinedTable      = "42P01"
 )
 
 // Set of error variables for CRUD operations.
 var (
 	ErrDBNotFound        = sql.ErrNoRows
 	ErrDBDuplicatedEntry = errors.New("duplicated entry")
+	ErrDBForeignKey      = errors.New("foreign key violation")
 	ErrUndefinedTable    = errors.New("undefined table")
 )
 
@@ -74,6 +77,36 @@ func Open(cfg Config) (*sqlx.DB, error) {
 	return db, nil
 }
 
+// dbBeginner implements the transaction.Beginner interface.
+type dbBeginner struct {
+	sqlxDB *sqlx.DB
+}
+
+// NewBeginner constructs a value that implements the transaction.Beginner
+// interface for the specified database.
+func NewBeginner(sqlxDB *sqlx.DB) transaction.Beginner {
+	return &dbBeginner{
+		sqlxDB: sqlxDB,
+	}
+}
+
+// Begin implements the transaction.Beginner interface.
+func (db *dbBeginner) Begin() (transaction.Transaction, error) {
+	return db.sqlxDB.Beginx()
+}
+
+// GetExtContext is a helper function that extracts the sqlx value from the
+// transaction.Transaction interface so a store can run its queries inside
+// the transaction.
+func GetExtContext(tx transaction.Transaction) (sqlx.ExtContext, error) {
+	ec, ok := tx.(sqlx.ExtContext)
+	if !ok {
+		return nil, fmt.Errorf("transaction(%T) not of a type *sqlx.Tx", tx)
+	}
+
+	return ec, nil
+}
+
 // StatusCheck returns nil if it can successfully talk to the database. It
 // returns a non-nil error otherwise.
 func StatusCheck(ctx context.Context, db *sqlx.DB) error {
@@ -130,6 +163,8 @@ func NamedExecContext(ctx context.Context, log *zap.SugaredLogger, db sqlx.ExtCo
 				return ErrUndefinedTable
 			case uniqueViolation:
 				return ErrDBDuplicatedEntry
+			case foreignKeyViolation:
+				return ErrDBForeignKey
 			}
 		}
 		return err
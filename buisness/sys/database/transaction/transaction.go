@@ -0,0 +1,88 @@
+// Package transaction provides support for database transactions. The core
+// and store packages use these interfaces so they don't need to know what
+// database technology is being used to manage the transaction.
+package transaction
+
+import (
+	"context"
+	"sync"
+)
+
+// Transaction represents a value that can commit or rollback a transaction.
+type Transaction interface {
+	Commit() error
+	Rollback() error
+}
+
+// Beginner represents a value that can begin a transaction.
+type Beginner interface {
+	Begin() (Transaction, error)
+}
+
+// =============================================================================
+
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// trKey is used to store/retrieve a Transaction value from a context.Context.
+const trKey ctxKey = 1
+
+// state holds the transaction of a context and the functions to call once
+// it commits.
+type state struct {
+	tx Transaction
+
+	mu    sync.Mutex
+	hooks []func()
+}
+
+// Set stores a value that can manage a transaction inside the context. The
+// caller that commits the transaction must call Committed afterwards.
+func Set(ctx context.Context, tx Transaction) context.Context {
+	return context.WithValue(ctx, trKey, &state{tx: tx})
+}
+
+// Get retrieves the value that can manage a transaction from the context.
+func Get(ctx context.Context) (Transaction, bool) {
+	v, ok := ctx.Value(trKey).(*state)
+	if !ok {
+		return nil, false
+	}
+
+	return v.tx, true
+}
+
+// OnCommit registers a function to call once the transaction in the context
+// commits, for changes outside of the database that must not happen if the
+// transaction is rolled back. Without a transaction the function is called
+// straight away.
+func OnCommit(ctx context.Context, fn func()) {
+	v, ok := ctx.Value(trKey).(*state)
+	if !ok {
+		fn()
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.hooks = append(v.hooks, fn)
+}
+
+// Committed calls the functions registered with OnCommit, in the order they
+// were registered.
+func Committed(ctx context.Context) {
+	v, ok := ctx.Value(trKey).(*state)
+	if !ok {
+		return
+	}
+
+	v.mu.Lock()
+	hooks := v.hooks
+	v.hooks = nil
+	v.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+}
@@ -0,0 +1,48 @@
+package transaction_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/google/go-cmp/cmp"
+)
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+func Test_OnCommit(t *testing.T) {
+	ctx := transaction.Set(context.Background(), tx{})
+
+	var calls []string
+	transaction.OnCommit(ctx, func() { calls = append(calls, "first") })
+	transaction.OnCommit(ctx, func() { calls = append(calls, "second") })
+
+	if len(calls) != 0 {
+		t.Fatalf("Should NOT call the functions before the transaction commits")
+	}
+
+	transaction.Committed(ctx)
+
+	exp := []string{"first", "second"}
+	if diff := cmp.Diff(calls, exp); diff != "" {
+		t.Errorf("Should call the functions in order once committed. Diff:\n%s", diff)
+	}
+
+	transaction.Committed(ctx)
+
+	if diff := cmp.Diff(calls, exp); diff != "" {
+		t.Errorf("Should call the functions only once. Diff:\n%s", diff)
+	}
+}
+
+func Test_OnCommitNoTransaction(t *testing.T) {
+	called := false
+	transaction.OnCommit(context.Background(), func() { called = true })
+
+	if !called {
+		t.Errorf("Should call the function straight away without a transaction")
+	}
+}
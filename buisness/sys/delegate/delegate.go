@@ -0,0 +1,84 @@
+// Package delegate provides the ability for a core package to publish the
+// events of its domain and for other core packages to react to them, without
+// the publisher having to import the packages that react.
+package delegate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Data represents an event of a domain. The Params are a value of the type
+// the publishing package declares for the action.
+type Data struct {
+	Domain string
+	Action string
+	Params any
+}
+
+// Func represents a function that handles an event.
+type Func func(context.Context, Data) error
+
+// Delegate manages the set of functions registered for the events.
+type Delegate struct {
+	log   *zap.SugaredLogger
+	mu    sync.RWMutex
+	funcs map[string]map[string][]Func
+}
+
+// New constructs a delegate for the events of the core packages.
+func New(log *zap.SugaredLogger) *Delegate {
+	return &Delegate{
+		log:   log,
+		funcs: make(map[string]map[string][]Func),
+	}
+}
+
+// Register adds a function to be called for the specified domain and action.
+// A nil delegate ignores the registration, so a core constructed without
+// one doesn't react to anything.
+func (d *Delegate) Register(domain string, action string, fn Func) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	actions, exists := d.funcs[domain]
+	if !exists {
+		actions = make(map[string][]Func)
+		d.funcs[domain] = actions
+	}
+
+	actions[action] = append(actions[action], fn)
+}
+
+// Call executes the functions registered for the event, in the order they
+// were registered. The functions run synchronously with the context of the
+// caller, so they are part of the transaction the context carries. The
+// first error stops the call and is returned, the caller should fail the
+// change that published the event.
+func (d *Delegate) Call(ctx context.Context, data Data) error {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.RLock()
+	funcs := d.funcs[data.Domain][data.Action]
+	d.mu.RUnlock()
+
+	d.log.Infow("delegate call", "status", "started", "domain", data.Domain, "action", data.Action, "funcs", len(funcs))
+	defer d.log.Infow("delegate call", "status", "completed", "domain", data.Domain, "action", data.Action)
+
+	for _, fn := range funcs {
+		if err := fn(ctx, data); err != nil {
+			return fmt.Errorf("delegate: %s.%s: %w", data.Domain, data.Action, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package delegate_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/delegate"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+)
+
+func Test_Call(t *testing.T) {
+	d := delegate.New(zap.NewNop().Sugar())
+
+	var calls []string
+	add := func(name string, err error) delegate.Func {
+		return func(ctx context.Context, data delegate.Data) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	d.Register("user", "deleted", add("first", nil))
+	d.Register("user", "deleted", add("second", nil))
+	d.Register("user", "created", add("other", nil))
+
+	data := delegate.Data{Domain: "user", Action: "deleted"}
+
+	if err := d.Call(context.Background(), data); err != nil {
+		t.Fatalf("Should be able to call the functions : %s", err)
+	}
+
+	exp := []string{"first", "second"}
+	if diff := cmp.Diff(calls, exp); diff != "" {
+		t.Errorf("Should call only the functions of the event in the order they were registered. Diff:\n%s", diff)
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := d.Call(context.Background(), delegate.Data{Domain: "product", Action: "deleted"}); err != nil {
+		t.Errorf("Should be able to call an event nothing is registered for : %s", err)
+	}
+}
+
+func Test_CallError(t *testing.T) {
+	d := delegate.New(zap.NewNop().Sugar())
+
+	errStop := errors.New("stop")
+
+	var calls []string
+	d.Register("user", "deleted", func(ctx context.Context, data delegate.Data) error {
+		calls = append(calls, "first")
+		return errStop
+	})
+	d.Register("user", "deleted", func(ctx context.Context, data delegate.Data) error {
+		calls = append(calls, "second")
+		return nil
+	})
+
+	err := d.Call(context.Background(), delegate.Data{Domain: "user", Action: "deleted"})
+
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Should get back the error of the function : %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "user.deleted") {
+		t.Logf("got: %v", err)
+		t.Errorf("Should name the event in the error")
+	}
+
+	exp := []string{"first"}
+	if diff := cmp.Diff(calls, exp); diff != "" {
+		t.Errorf("Should stop calling the functions at the first error. Diff:\n%s", diff)
+	}
+}
+
+func Test_Nil(t *testing.T) {
+	var d *delegate.Delegate
+
+	called := false
+	d.Register("user", "deleted", func(ctx context.Context, data delegate.Data) error {
+		called = true
+		return errors.New("should not be called")
+	})
+
+	if err := d.Call(context.Background(), delegate.Data{Domain: "user", Action: "deleted"}); err != nil {
+		t.Errorf("Should ignore the call on a nil delegate : %s", err)
+	}
+
+	if called {
+		t.Errorf("Should NOT register functions on a nil delegate")
+	}
+}
@@ -9,20 +9,51 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage"
+	"github.com/open-policy-agent/opa/storage/inmem"
 	"go.uber.org/zap"
 )
 
 // ErrForbidden is returned when a auth issue is identified.
 var ErrForbidden = errors.New("attempted action is not allowed")
 
-// Claims represents the authorization claims transmitted via a JWT.
+// Set of authentication methods for the amr claim, the values are the ones
+// registered by RFC 8176.
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+)
+
+// Claims represents the authorization claims transmitted via a JWT. AMR
+// lists the methods the user authenticated with and TenantID is the tenant
+// the user is a member of.
 type Claims struct {
 	jwt.RegisteredClaims
-	Roles []user.Role `json:"roles"`
+	Roles    []user.Role `json:"roles"`
+	AMR      []string    `json:"amr,omitempty"`
+	TenantID string      `json:"tid,omitempty"`
+}
+
+// HasRole reports if the claims hold the specified role.
+func (c Claims) HasRole(role user.Role) bool {
+	for _, r := range c.Roles {
+		if r.Equal(role) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // KeyLookup declares a method set of behavior for looking up
@@ -37,54 +68,153 @@ type KeyLookup interface {
 	PublicKey(kid string) (key string, err error)
 }
 
-// Config represents information required to initialize auth.
+// Denylist declares the behavior for checking if an access token was revoked
+// before it expired. The jti is the id of the token.
+type Denylist interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// APIKeyLookup declares the behavior for resolving an api key to the key
+// and the roles it is scoped to.
+type APIKeyLookup interface {
+	Authenticate(ctx context.Context, key string) (apikey.APIKey, error)
+}
+
+// EnabledCheck declares the behavior for confirming the subject of the claims
+// is still an enabled user.
+type EnabledCheck interface {
+	IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// RoleLookup declares the behavior for loading the roles users can be granted
+// and the permissions each role holds.
+type RoleLookup interface {
+	QueryAll(ctx context.Context) ([]role.Role, error)
+}
+
+// enabledTTL is how long the result of an enabled check is cached. A user
+// that is disabled keeps being authorized at most this long.
+const enabledTTL = 30 * time.Second
+
+// enabledEntry is the cached result of an enabled check.
+type enabledEntry struct {
+	enabled bool
+	expires time.Time
+}
+
+// denylistTTL is how long the result of a denylist check for a token that is
+// not revoked is cached. A token revoked through another instance of the
+// service is rejected here at most this long after it was revoked.
+const denylistTTL = time.Minute
+
+// denyEntry is the cached result of a denylist check.
+type denyEntry struct {
+	revoked bool
+	expires time.Time
+}
+
+// defaultKeyCacheTTL is how long a public key is cached when the config
+// doesn't say otherwise.
+const defaultKeyCacheTTL = 5 * time.Minute
+
+// keyEntry is a cached public key.
+type keyEntry struct {
+	pem     string
+	expires time.Time
+}
+
+// Config represents information required to initialize auth. PolicyPath
+// is an optional directory or .tar.gz bundle of rego policies that are
+// loaded on top of the embedded ones. KeyCacheTTL bounds how long a key
+// that was removed from the KeyLookup keeps validating tokens. APIKeys is
+// optional, without it api keys are rejected. EnabledCheck is optional,
+// with it Authorize rejects the claims of users that were disabled. Roles
+// is optional, without it only the built in roles are known and no role
+// holds any permission.
 type Config struct {
-	Log       *zap.SugaredLogger
-	KeyLookup KeyLookup
-	Issuer    string
+	Log          *zap.SugaredLogger
+	KeyLookup    KeyLookup
+	Issuer       string
+	Denylist     Denylist
+	PolicyPath   string
+	KeyCacheTTL  time.Duration
+	APIKeys      APIKeyLookup
+	EnabledCheck EnabledCheck
+	Roles        RoleLookup
 }
 
 // Auth is used to authenticate clients. It can generate a token for a
 // set of user claims and recreate the claims by parsing the token.
 type Auth struct {
-	log       *zap.SugaredLogger
-	keyLookup KeyLookup
-	method    jwt.SigningMethod
-	parser    *jwt.Parser
-	issuer    string
-	mu        sync.RWMutex
-	cache     map[string]string
+	log          *zap.SugaredLogger
+	keyLookup    KeyLookup
+	parser       *jwt.Parser
+	issuer       string
+	mu           sync.RWMutex
+	cache        map[string]keyEntry
+	cacheTTL     time.Duration
+	denylist     Denylist
+	apiKeys      APIKeyLookup
+	enabled      EnabledCheck
+	enabledMu    sync.RWMutex
+	enabledCache map[string]enabledEntry
+	denyMu       sync.RWMutex
+	denyCache    map[string]denyEntry
+	roles        RoleLookup
+	data         storage.Store
+	policyPath   string
+	policies     atomic.Pointer[policySet]
 }
 
 // New creates an Auth to support authentication/authorization.
 func New(cfg Config) (*Auth, error) {
 	a := Auth{
-		log:       cfg.Log,
-		keyLookup: cfg.KeyLookup,
-		method:    jwt.GetSigningMethod(jwt.SigningMethodRS256.Name),
-		parser:    jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name})),
-		issuer:    cfg.Issuer,
-		cache:     make(map[string]string),
+		log:          cfg.Log,
+		keyLookup:    cfg.KeyLookup,
+		parser:       jwt.NewParser(jwt.WithValidMethods(validMethods)),
+		issuer:       cfg.Issuer,
+		cache:        make(map[string]keyEntry),
+		cacheTTL:     cfg.KeyCacheTTL,
+		denylist:     cfg.Denylist,
+		apiKeys:      cfg.APIKeys,
+		enabled:      cfg.EnabledCheck,
+		enabledCache: make(map[string]enabledEntry),
+		denyCache:    make(map[string]denyEntry),
+		roles:        cfg.Roles,
+		data:         inmem.NewFromObject(map[string]any{"permissions": builtInPermissions()}),
+		policyPath:   cfg.PolicyPath,
+	}
+
+	if a.cacheTTL <= 0 {
+		a.cacheTTL = defaultKeyCacheTTL
 	}
+
+	// Compiling the rego modules is expensive, so the query for every rule
+	// is prepared once here. A prepared query is safe for concurrent use.
+	if err := a.ReloadPolicies(context.Background()); err != nil {
+		return nil, err
+	}
+
 	return &a, nil
 }
 
 // GenerateToken generates a signed JWT token string representing the user Claims.
+// The signing algorithm is selected from the type of the key for the kid.
 func (a *Auth) GenerateToken(kid string, claims Claims) (string, error) {
-	token := jwt.NewWithClaims(a.method, claims)
-	token.Header["kid"] = kid
-
 	// This is hitting something that will will return the privateKey
 	privateKeyPEM, err := a.keyLookup.PrivateKey(kid)
 	if err != nil {
 		return "", fmt.Errorf("private key: %w", err)
 	}
 
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+	privateKey, method, err := parsePrivateKey(privateKeyPEM)
 	if err != nil {
 		return "", fmt.Errorf("parsing private pem: %w", err)
 	}
 
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
 	str, err := token.SignedString(privateKey)
 	if err != nil {
 		return "", fmt.Errorf("signing token: %w", err)
@@ -124,30 +254,104 @@ func (a *Auth) Authenticate(ctx context.Context, bearerToken string) (Claims, er
 		return Claims{}, fmt.Errorf("failed to fetch public key: %w", err)
 	}
 
-	input := map[string]any{
-		"Key":   pem,
-		"Token": parts[1],
-		"ISS":   a.issuer,
+	publicKey, method, err := parsePublicKey(pem)
+	if err != nil {
+		return Claims{}, fmt.Errorf("parsing public pem: %w", err)
+	}
+
+	// The algorithm comes from the key, never from the token. A token that
+	// claims another algorithm than the key for its kid is rejected.
+	if token.Method.Alg() != method.Alg() {
+		return Claims{}, fmt.Errorf("token alg %q doesn't match the key alg %q", token.Method.Alg(), method.Alg())
+	}
+
+	switch method {
+	case jwt.SigningMethodEdDSA:
+		// OPA's io.jwt.decode_verify doesn't support EdDSA, so these tokens
+		// are verified here the same way the policy verifies the others.
+		keyFunc := func(*jwt.Token) (any, error) { return publicKey, nil }
+		if _, err := a.parser.ParseWithClaims(parts[1], &Claims{}, keyFunc); err != nil {
+			return Claims{}, fmt.Errorf("authentication failed : %w", err)
+		}
+
+		if claims.Issuer != a.issuer {
+			return Claims{}, fmt.Errorf("authentication failed : issuer %q not valid", claims.Issuer)
+		}
+
+	default:
+		input := map[string]any{
+			"Key":   pem,
+			"Token": parts[1],
+			"ISS":   a.issuer,
+			"Alg":   method.Alg(),
+		}
+
+		if err := a.opaPolicyEvaluation(ctx, RuleAuthenticate, input); err != nil {
+			return Claims{}, fmt.Errorf("authentication failed : %w", err)
+		}
+	}
+
+	if err := a.checkDenylist(ctx, claims); err != nil {
+		return Claims{}, err
+	}
+
+	return claims, nil
+}
+
+// AuthenticateAPIKey validates the api key and returns the claims of the user
+// it belongs to, limited to the roles the key is scoped to. The claims have
+// no jti or expiry since a key is revoked through the key itself.
+func (a *Auth) AuthenticateAPIKey(ctx context.Context, key string) (Claims, error) {
+	if a.apiKeys == nil {
+		return Claims{}, errors.New("api keys are not supported")
 	}
 
-	if err := a.opaPolicyEvaluation(ctx, opaAuthentication, RuleAuthenticate, input); err != nil {
+	// The tenant of the key is only known once it is found.
+	ak, err := a.apiKeys.Authenticate(tenant.SetScopeAll(ctx), key)
+	if err != nil {
 		return Claims{}, fmt.Errorf("authentication failed : %w", err)
 	}
 
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: ak.UserID.String(),
+			Issuer:  a.issuer,
+		},
+		Roles:    ak.Roles,
+		TenantID: ak.TenantID.String(),
+	}
+
 	return claims, nil
 }
 
+// Revoke marks the access token with the specified jti as revoked in the
+// cache of this instance, so it is rejected right away. The token must also
+// be added to the denylist for other instances of the service to reject it.
+func (a *Auth) Revoke(jti string, expires time.Time) {
+	a.denyMu.Lock()
+	defer a.denyMu.Unlock()
+
+	a.denyCache[jti] = denyEntry{revoked: true, expires: expires}
+}
+
 // Authorize attempts to authorize the user with the provided input roles, if
 // none of the input roles are within the user's claims, we return an error
 // otherwise the user is authorized.
-func (a *Auth) Authorize(ctx context.Context, claims Claims, rule string) error {
+// The userID is the owner of the resource being acted on, rules like
+// RuleAdminOrSubject compare it with the subject of the claims.
+func (a *Auth) Authorize(ctx context.Context, claims Claims, userID uuid.UUID, rule string) error {
+	if err := a.checkEnabled(ctx, claims); err != nil {
+		return err
+	}
+
 	input := map[string]any{
 		"Roles":   claims.Roles,
 		"Subject": claims.Subject,
-		"UserID":  claims.Subject,
+		"UserID":  userID.String(),
+		"AMR":     claims.AMR,
 	}
 
-	if err := a.opaPolicyEvaluation(ctx, opaAuthorization, rule, input); err != nil {
+	if err := a.opaPolicyEvaluation(ctx, rule, input); err != nil {
 		return fmt.Errorf("rego evaluation failed : %w", err)
 	}
 
@@ -157,8 +361,9 @@ func (a *Auth) Authorize(ctx context.Context, claims Claims, rule string) error
 // ===========================================================
 // Everyting here is unexported
 // publicKeyLookup performs a lookup for the public pem for the specified kid.
+// Entries expire after the cache TTL, so a kid that disappears from the key
+// lookup stops validating tokens within that time.
 func (a *Auth) publicKeyLookup(kid string) (string, error) {
-	//TODO: Implement a cache invalidation when disabling keys
 	// Why we are caching the keys, because we don't want on every
 	// API call that needs authentication needs to go through this process
 	// Here we are declaring and executing a literal function
@@ -168,11 +373,11 @@ func (a *Auth) publicKeyLookup(kid string) (string, error) {
 		defer a.mu.RUnlock()
 
 		// We want to see if the kid is already in the cache
-		pem, exists := a.cache[kid]
-		if !exists {
+		entry, exists := a.cache[kid]
+		if !exists || time.Now().After(entry.expires) {
 			return "", errors.New("not found")
 		}
-		return pem, nil
+		return entry.pem, nil
 	}()
 
 	if err == nil {
@@ -182,28 +387,150 @@ func (a *Auth) publicKeyLookup(kid string) (string, error) {
 	// or doing a fetching operation that requires some latency
 	pem, err = a.keyLookup.PublicKey(kid)
 	if err != nil {
+		a.EvictKeys(kid)
 		return "", fmt.Errorf("fetching public key: %w", err)
 	}
 
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	a.cache[kid] = pem
+	a.cache[kid] = keyEntry{pem: pem, expires: time.Now().Add(a.cacheTTL)}
 
 	return pem, nil
 }
 
-// opaPolicyEvaluation asks opa to evaulate the token against the specified token
-// policy and public key.
-func (a *Auth) opaPolicyEvaluation(ctx context.Context, opaPolicy string, rule string, input any) error {
-	query := fmt.Sprintf("x = data.%s.%s", opaPackage, rule)
-	q, err := rego.New(
-		rego.Query(query),
-		rego.Module("rego", opaPolicy),
-	).PrepareForEval(ctx)
+// EvictKeys removes the specified kids from the public key cache, so tokens
+// signed with those keys stop validating right away if the keys are gone.
+func (a *Auth) EvictKeys(kids ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, kid := range kids {
+		delete(a.cache, kid)
+	}
+}
+
+// checkEnabled rejects the claims when the subject is no longer an enabled
+// user. The result is cached for a short time so most requests don't need
+// to hit the database.
+func (a *Auth) checkEnabled(ctx context.Context, claims Claims) error {
+	if a.enabled == nil {
+		return nil
+	}
+
+	now := time.Now()
+
+	a.enabledMu.RLock()
+	entry, exists := a.enabledCache[claims.Subject]
+	a.enabledMu.RUnlock()
+
+	if !exists || now.After(entry.expires) {
+		userID, err := uuid.Parse(claims.Subject)
+		if err != nil {
+			return fmt.Errorf("invalid subject in claims: %w", err)
+		}
+
+		// The check runs before the request is limited to the tenant of
+		// the claims.
+		enabled, err := a.enabled.IsEnabled(tenant.SetScopeAll(ctx), userID)
+		if err != nil {
+			return fmt.Errorf("checking user enabled: %w", err)
+		}
+
+		entry = enabledEntry{enabled: enabled, expires: now.Add(enabledTTL)}
+
+		a.enabledMu.Lock()
+		a.evictEnabledCache(now)
+		a.enabledCache[claims.Subject] = entry
+		a.enabledMu.Unlock()
+	}
+
+	if !entry.enabled {
+		return errors.New("user is disabled")
+	}
+
+	return nil
+}
+
+// evictEnabledCache removes the entries that have expired once the cache has
+// grown large. The caller must hold the write lock.
+func (a *Auth) evictEnabledCache(now time.Time) {
+	const maxEntries = 10_000
+	if len(a.enabledCache) < maxEntries {
+		return
+	}
+
+	for subject, entry := range a.enabledCache {
+		if now.After(entry.expires) {
+			delete(a.enabledCache, subject)
+		}
+	}
+}
+
+// checkDenylist rejects the token when its jti was revoked. Tokens without a
+// jti were issued before revocation was supported and are left alone.
+func (a *Auth) checkDenylist(ctx context.Context, claims Claims) error {
+	if a.denylist == nil || claims.ID == "" {
+		return nil
+	}
+
+	now := time.Now()
+
+	a.denyMu.RLock()
+	entry, exists := a.denyCache[claims.ID]
+	a.denyMu.RUnlock()
+
+	if !exists || (!entry.revoked && now.After(entry.expires)) {
+		revoked, err := a.denylist.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return fmt.Errorf("checking denylist: %w", err)
+		}
+
+		entry = denyEntry{revoked: revoked, expires: now.Add(denylistTTL)}
+		if revoked && claims.ExpiresAt != nil {
+			entry.expires = claims.ExpiresAt.Time
+		}
+
+		a.denyMu.Lock()
+		a.evictDenyCache(now)
+		a.denyCache[claims.ID] = entry
+		a.denyMu.Unlock()
+	}
+
+	if entry.revoked {
+		return errors.New("token has been revoked")
+	}
+
+	return nil
+}
+
+// evictDenyCache removes the entries that have expired once the cache has
+// grown large. The caller must hold the write lock.
+func (a *Auth) evictDenyCache(now time.Time) {
+	const maxEntries = 10_000
+	if len(a.denyCache) < maxEntries {
+		return
+	}
+
+	for jti, entry := range a.denyCache {
+		if now.After(entry.expires) {
+			delete(a.denyCache, jti)
+		}
+	}
+}
+
+// opaPolicyEvaluation asks opa to evaulate the input against the specified
+// rule of the active set of policies.
+func (a *Auth) opaPolicyEvaluation(ctx context.Context, rule string, input any) error {
+	q, err := a.policies.Load().query(ctx, rule)
 	if err != nil {
 		return err
 	}
 
+	return evalQuery(ctx, q, input)
+}
+
+// evalQuery evaluates the prepared query and checks the rule passed.
+func evalQuery(ctx context.Context, q rego.PreparedEvalQuery, input any) error {
 	results, err := q.Eval(ctx, rego.EvalInput(input))
 	if err != nil {
 		return fmt.Errorf("query: %w", err)
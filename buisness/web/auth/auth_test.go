@@ -0,0 +1,741 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"go.uber.org/zap"
+)
+
+const kid = "s4sKIjD9kIRjxs2tulPqGLdxSfgPErRN1Mu3Hd9k9NQ"
+
+// The prepared queries must give the same answer as preparing the query on
+// every call did.
+func Test_PreparedQueries(t *testing.T) {
+	a, tkn := newAuth(t)
+	ctx := context.Background()
+
+	subject := uuid.New()
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: subject.String()},
+	}
+
+	tests := []struct {
+		name   string
+		rule   string
+		roles  []user.Role
+		userID uuid.UUID
+	}{
+		{"read-user", RuleProductRead, []user.Role{user.RoleUser}, uuid.UUID{}},
+		{"read-none", RuleProductRead, nil, uuid.UUID{}},
+		{"admin-admin", RuleAdminOnly, []user.Role{user.RoleAdmin}, uuid.UUID{}},
+		{"admin-user", RuleAdminOnly, []user.Role{user.RoleUser}, uuid.UUID{}},
+		{"user-user", RuleUserOnly, []user.Role{user.RoleUser}, uuid.UUID{}},
+		{"user-admin", RuleUserOnly, []user.Role{user.RoleAdmin}, uuid.UUID{}},
+		{"subject-admin", RuleAdminOrSubject, []user.Role{user.RoleAdmin}, uuid.New()},
+		{"subject-owner", RuleAdminOrSubject, []user.Role{user.RoleUser}, subject},
+		{"subject-other", RuleAdminOrSubject, []user.Role{user.RoleUser}, uuid.New()},
+		{"unknown-rule", "ruleUnknown", []user.Role{user.RoleAdmin}, uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims.Roles = tt.roles
+			input := authorizeInput(claims, tt.userID)
+
+			got := a.opaPolicyEvaluation(ctx, tt.rule, input)
+			exp := evalUnprepared(ctx, opaAuthorization, tt.rule, input)
+
+			if (got == nil) != (exp == nil) {
+				t.Logf("got: %v", got)
+				t.Logf("exp: %v", exp)
+				t.Errorf("Should get the same result from the prepared query")
+			}
+		})
+	}
+
+	t.Run("authenticate", func(t *testing.T) {
+		if _, err := a.Authenticate(ctx, "Bearer "+tkn); err != nil {
+			t.Errorf("Should be able to authenticate the token : %s", err)
+		}
+
+		if _, err := a.Authenticate(ctx, "Bearer "+tkn+"x"); err == nil {
+			t.Errorf("Should NOT be able to authenticate a tampered token")
+		}
+	})
+}
+
+// Keys of different types can be used side by side, the algorithm of a token
+// is always the one of the key for its kid.
+func Test_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := New(Config{
+		Log: zap.NewNop().Sugar(),
+		KeyLookup: &keyStore{keys: map[string]crypto.Signer{
+			"rsa": rsaKey,
+			"ec":  ecKey,
+			"ed":  edKey,
+		}},
+		Issuer: "service project",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.NewString(),
+			Issuer:    "service project",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Roles: []user.Role{user.RoleUser},
+	}
+
+	tests := []struct {
+		kid string
+		alg string
+	}{
+		{"rsa", "RS256"},
+		{"ec", "ES256"},
+		{"ed", "EdDSA"},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			tkn, err := a.GenerateToken(tt.kid, claims)
+			if err != nil {
+				t.Fatalf("Should be able to generate a token : %s", err)
+			}
+
+			token, _, err := jwt.NewParser().ParseUnverified(tkn, &Claims{})
+			if err != nil {
+				t.Fatalf("Should be able to parse the token : %s", err)
+			}
+
+			if token.Method.Alg() != tt.alg {
+				t.Logf("got: %v", token.Method.Alg())
+				t.Logf("exp: %v", tt.alg)
+				t.Errorf("Should sign the token with the algorithm of the key")
+			}
+
+			if _, err := a.Authenticate(ctx, "Bearer "+tkn); err != nil {
+				t.Errorf("Should be able to authenticate the token : %s", err)
+			}
+
+			if _, err := a.Authenticate(ctx, "Bearer "+tamper(tkn)); err == nil {
+				t.Errorf("Should NOT be able to authenticate a tampered token")
+			}
+		})
+	}
+
+	t.Run("wrong-kid", func(t *testing.T) {
+		tkn, err := a.GenerateToken("ec", claims)
+		if err != nil {
+			t.Fatalf("Should be able to generate a token : %s", err)
+		}
+
+		token, _, err := jwt.NewParser().ParseUnverified(tkn, &Claims{})
+		if err != nil {
+			t.Fatalf("Should be able to parse the token : %s", err)
+		}
+		token.Header["kid"] = "rsa"
+
+		tkn, err = token.SignedString(ecKey)
+		if err != nil {
+			t.Fatalf("Should be able to sign the token : %s", err)
+		}
+
+		if _, err := a.Authenticate(ctx, "Bearer "+tkn); err == nil {
+			t.Errorf("Should NOT authenticate a token with the alg of another key")
+		}
+	})
+
+	t.Run("wrong-issuer", func(t *testing.T) {
+		clm := claims
+		clm.Issuer = "another project"
+
+		tkn, err := a.GenerateToken("ed", clm)
+		if err != nil {
+			t.Fatalf("Should be able to generate a token : %s", err)
+		}
+
+		if _, err := a.Authenticate(ctx, "Bearer "+tkn); err == nil {
+			t.Errorf("Should NOT authenticate a token from another issuer")
+		}
+	})
+}
+
+// A key removed from the key lookup must stop validating tokens once its
+// cache entry expires, or right away when it's evicted.
+func Test_KeyCache(t *testing.T) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks := keyStore{keys: map[string]crypto.Signer{kid: pk}}
+
+	a, err := New(Config{
+		Log:         zap.NewNop().Sugar(),
+		KeyLookup:   &ks,
+		Issuer:      "service project",
+		KeyCacheTTL: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.NewString(),
+			Issuer:    "service project",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+
+	tkn, err := a.GenerateToken(kid, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := a.Authenticate(ctx, "Bearer "+tkn); err != nil {
+		t.Fatalf("Should be able to authenticate the token : %s", err)
+	}
+
+	delete(ks.keys, kid)
+
+	if _, err := a.Authenticate(ctx, "Bearer "+tkn); err != nil {
+		t.Fatalf("Should still authenticate with the cached key : %s", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := a.Authenticate(ctx, "Bearer "+tkn); err == nil {
+		t.Fatalf("Should NOT authenticate once the cached key expired")
+	}
+
+	// -------------------------------------------------------------------------
+
+	ks.keys[kid] = pk
+
+	if _, err := a.Authenticate(ctx, "Bearer "+tkn); err != nil {
+		t.Fatalf("Should authenticate once the key is back : %s", err)
+	}
+
+	delete(ks.keys, kid)
+	a.EvictKeys(kid)
+
+	if _, err := a.Authenticate(ctx, "Bearer "+tkn); err == nil {
+		t.Fatalf("Should NOT authenticate once the key is evicted")
+	}
+}
+
+// Authorize must reject the claims of a disabled user, checking the user
+// again only once the cached result expired.
+func Test_EnabledCheck(t *testing.T) {
+	users := enabledUsers{enabled: make(map[uuid.UUID]bool)}
+
+	a, err := New(Config{
+		Log:          zap.NewNop().Sugar(),
+		KeyLookup:    &keyStore{},
+		EnabledCheck: &users,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := uuid.New()
+	users.enabled[userID] = true
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+		Roles:            []user.Role{user.RoleUser},
+	}
+
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := a.Authorize(ctx, claims, uuid.UUID{}, RuleProductRead); err != nil {
+			t.Fatalf("Should authorize an enabled user : %s", err)
+		}
+	}
+
+	if users.calls != 1 {
+		t.Logf("got: %v", users.calls)
+		t.Logf("exp: %v", 1)
+		t.Errorf("Should only check the user once while the result is cached")
+	}
+
+	// -------------------------------------------------------------------------
+
+	users.enabled[userID] = false
+
+	a.enabledMu.Lock()
+	a.enabledCache[claims.Subject] = enabledEntry{enabled: true, expires: time.Now().Add(-time.Second)}
+	a.enabledMu.Unlock()
+
+	if err := a.Authorize(ctx, claims, uuid.UUID{}, RuleProductRead); err == nil {
+		t.Fatalf("Should NOT authorize a disabled user once the cached result expired")
+	}
+
+	unknown := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+		Roles:            []user.Role{user.RoleUser},
+	}
+
+	if err := a.Authorize(ctx, unknown, uuid.UUID{}, RuleProductRead); err == nil {
+		t.Fatalf("Should NOT authorize a user that doesn't exist")
+	}
+}
+
+// =============================================================================
+
+func Test_AdminOnlyMFA(t *testing.T) {
+	a, err := New(Config{
+		Log:       zap.NewNop().Sugar(),
+		KeyLookup: &keyStore{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		roles []user.Role
+		amr   []string
+		exp   bool
+	}{
+		{"admin-mfa", []user.Role{user.RoleAdmin}, []string{AMRPassword, AMROTP, AMRMFA}, true},
+		{"admin-pwd", []user.Role{user.RoleAdmin}, []string{AMRPassword}, false},
+		{"admin-none", []user.Role{user.RoleAdmin}, nil, false},
+		{"user-mfa", []user.Role{user.RoleUser}, []string{AMRPassword, AMROTP, AMRMFA}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+				Roles:            tt.roles,
+				AMR:              tt.amr,
+			}
+
+			err := a.Authorize(ctx, claims, uuid.UUID{}, RuleAdminOnlyMFA)
+			if (err == nil) != tt.exp {
+				t.Logf("got: %v", err)
+				t.Logf("exp: %v", tt.exp)
+				t.Errorf("Should only authorize admins that authenticated with mfa")
+			}
+		})
+	}
+}
+
+func Test_SuperAdmin(t *testing.T) {
+	a, err := New(Config{
+		Log:       zap.NewNop().Sugar(),
+		KeyLookup: &keyStore{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		roles []user.Role
+		rule  string
+		exp   bool
+	}{
+		{"super-event-read", []user.Role{user.RoleSuperAdmin}, RuleEventRead, true},
+		{"super-admin", []user.Role{user.RoleSuperAdmin}, RuleAdminOnly, true},
+		{"super-subject", []user.Role{user.RoleSuperAdmin}, RuleAdminOrSubject, true},
+		{"super-super", []user.Role{user.RoleSuperAdmin}, RuleSuperAdminOnly, true},
+		{"admin-super", []user.Role{user.RoleAdmin}, RuleSuperAdminOnly, false},
+		{"user-super", []user.Role{user.RoleUser}, RuleSuperAdminOnly, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+				Roles:            tt.roles,
+			}
+
+			err := a.Authorize(ctx, claims, uuid.New(), tt.rule)
+			if (err == nil) != tt.exp {
+				t.Logf("got: %v", err)
+				t.Logf("exp: %v", tt.exp)
+				t.Errorf("Should only authorize super admins for the super admin rule")
+			}
+		})
+	}
+}
+
+func Test_BuiltInPermissions(t *testing.T) {
+	a, err := New(Config{
+		Log:       zap.NewNop().Sugar(),
+		KeyLookup: &keyStore{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		roles []user.Role
+		rule  string
+		exp   bool
+	}{
+		{"user-user-read", []user.Role{user.RoleUser}, RuleUserRead, false},
+		{"user-apikey-write", []user.Role{user.RoleUser}, RuleAPIKeyWrite, true},
+		{"user-sale-read", []user.Role{user.RoleUser}, RuleSaleRead, false},
+		{"user-product-write", []user.Role{user.RoleUser}, RuleProductWrite, true},
+		{"admin-user-read", []user.Role{user.RoleAdmin}, RuleUserRead, true},
+		{"admin-user-write", []user.Role{user.RoleAdmin}, RuleUserWrite, true},
+		{"admin-tenant-read", []user.Role{user.RoleAdmin}, RuleTenantRead, true},
+		{"admin-tenant-write", []user.Role{user.RoleAdmin}, RuleTenantWrite, false},
+		{"admin-role-write", []user.Role{user.RoleAdmin}, RuleRoleWrite, false},
+		{"super-tenant-write", []user.Role{user.RoleSuperAdmin}, RuleTenantWrite, true},
+		{"super-role-write", []user.Role{user.RoleSuperAdmin}, RuleRoleWrite, true},
+		{"user-event-read", []user.Role{user.RoleUser}, RuleEventRead, true},
+		{"none-event-read", nil, RuleEventRead, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+				Roles:            tt.roles,
+			}
+
+			err := a.Authorize(ctx, claims, uuid.New(), tt.rule)
+			if (err == nil) != tt.exp {
+				t.Logf("got: %v", err)
+				t.Logf("exp: %v", tt.exp)
+				t.Errorf("Should authorize the built in roles by their seeded permissions")
+			}
+		})
+	}
+}
+
+func Test_Permissions(t *testing.T) {
+	rs := roleStore{
+		roles: []role.Role{
+			{Name: "USER", Permissions: []string{"product:read", "sale:write"}},
+			{Name: "AUDITOR", Permissions: []string{"product:read"}},
+			{Name: "BILLING", Permissions: []string{"sale:read"}},
+		},
+	}
+
+	a, err := New(Config{
+		Log:       zap.NewNop().Sugar(),
+		KeyLookup: &keyStore{},
+		Roles:     &rs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := user.ParseRole("AUDITOR"); err == nil {
+		t.Fatalf("Should NOT be able to parse a role before the roles are loaded")
+	}
+
+	if err := a.ReloadRoles(ctx); err != nil {
+		t.Fatalf("Should be able to load the roles : %s", err)
+	}
+	t.Cleanup(func() { user.SetRoles(nil) })
+
+	auditor, err := user.ParseRole("AUDITOR")
+	if err != nil {
+		t.Fatalf("Should be able to parse a loaded role : %s", err)
+	}
+
+	billing, err := user.ParseRole("BILLING")
+	if err != nil {
+		t.Fatalf("Should be able to parse a loaded role : %s", err)
+	}
+
+	tests := []struct {
+		name  string
+		roles []user.Role
+		rule  string
+		exp   bool
+	}{
+		{"user-read", []user.Role{user.RoleUser}, RuleProductRead, true},
+		{"user-write", []user.Role{user.RoleUser}, RuleSaleWrite, true},
+		{"auditor-read", []user.Role{auditor}, RuleProductRead, true},
+		{"auditor-write", []user.Role{auditor}, RuleSaleWrite, false},
+		{"auditor-product-write", []user.Role{auditor}, RuleProductWrite, false},
+		{"admin-read", []user.Role{user.RoleAdmin}, RuleProductRead, false},
+		{"billing-read", []user.Role{billing}, RuleProductRead, false},
+		{"billing-sale-read", []user.Role{billing}, RuleSaleRead, true},
+		{"billing-admin", []user.Role{billing}, RuleAdminOnly, false},
+		{"billing-subject", []user.Role{billing}, RuleAdminOrSubject, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := Claims{
+				RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+				Roles:            tt.roles,
+			}
+
+			err := a.Authorize(ctx, claims, uuid.New(), tt.rule)
+			if (err == nil) != tt.exp {
+				t.Logf("got: %v", err)
+				t.Logf("exp: %v", tt.exp)
+				t.Errorf("Should only authorize roles holding the permission")
+			}
+		})
+	}
+
+	// -------------------------------------------------------------------------
+
+	rs.roles = rs.roles[:1]
+
+	if err := a.ReloadRoles(ctx); err != nil {
+		t.Fatalf("Should be able to reload the roles : %s", err)
+	}
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+		Roles:            []user.Role{auditor},
+	}
+
+	if err := a.Authorize(ctx, claims, uuid.New(), RuleProductRead); err == nil {
+		t.Fatalf("Should NOT authorize a role that was removed")
+	}
+
+	if _, err := user.ParseRole("AUDITOR"); err == nil {
+		t.Fatalf("Should NOT be able to parse a role that was removed")
+	}
+}
+
+func Benchmark_Authorize(b *testing.B) {
+	a, _ := newAuth(b)
+	ctx := context.Background()
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+		Roles:            []user.Role{user.RoleUser},
+	}
+	input := authorizeInput(claims, uuid.New())
+
+	b.Run("prepared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := a.opaPolicyEvaluation(ctx, RuleProductRead, input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("unprepared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := evalUnprepared(ctx, opaAuthorization, RuleProductRead, input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func Benchmark_Authenticate(b *testing.B) {
+	a, tkn := newAuth(b)
+	ctx := context.Background()
+
+	pem, err := a.publicKeyLookup(kid)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	input := map[string]any{
+		"Key":   pem,
+		"Token": tkn,
+		"ISS":   a.issuer,
+		"Alg":   jwt.SigningMethodRS256.Name,
+	}
+
+	b.Run("prepared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := a.opaPolicyEvaluation(ctx, RuleAuthenticate, input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("unprepared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := evalUnprepared(ctx, opaAuthentication, RuleAuthenticate, input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// =============================================================================
+
+// evalUnprepared is how the policies were evaluated before the queries were
+// prepared in New, the query is compiled on every call.
+func evalUnprepared(ctx context.Context, opaPolicy string, rule string, input any) error {
+	query := fmt.Sprintf("x = data.%s.%s", opaPackage, rule)
+	q, err := rego.New(
+		rego.Query(query),
+		rego.Module("rego", opaPolicy),
+		rego.Store(inmem.NewFromObject(map[string]any{"permissions": builtInPermissions()})),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return err
+	}
+
+	return evalQuery(ctx, q, input)
+}
+
+// tamper changes the first character of the signature of the token. The
+// first character always carries six bits of the signature.
+func tamper(tkn string) string {
+	i := strings.LastIndex(tkn, ".") + 1
+
+	c := byte('A')
+	if tkn[i] == 'A' {
+		c = 'B'
+	}
+
+	return tkn[:i] + string(c) + tkn[i+1:]
+}
+
+func authorizeInput(claims Claims, userID uuid.UUID) map[string]any {
+	return map[string]any{
+		"Roles":   claims.Roles,
+		"Subject": claims.Subject,
+		"UserID":  userID.String(),
+		"AMR":     claims.AMR,
+	}
+}
+
+func newAuth(tb testing.TB) (*Auth, string) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	a, err := New(Config{
+		Log:       zap.NewNop().Sugar(),
+		KeyLookup: &keyStore{keys: map[string]crypto.Signer{kid: pk}},
+		Issuer:    "service project",
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.NewString(),
+			Issuer:    "service project",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+		Roles: []user.Role{user.RoleUser},
+	}
+
+	tkn, err := a.GenerateToken(kid, claims)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return a, tkn
+}
+
+// keyStore is a KeyLookup with the keys generated for the test.
+type keyStore struct {
+	keys map[string]crypto.Signer
+}
+
+func (ks *keyStore) PrivateKey(kid string) (string, error) {
+	pk, found := ks.keys[kid]
+	if !found {
+		return "", errors.New("kid lookup failed")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(pk)
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+func (ks *keyStore) PublicKey(kid string) (string, error) {
+	pk, found := ks.keys[kid]
+	if !found {
+		return "", errors.New("kid lookup failed")
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pk.Public())
+	if err != nil {
+		return "", err
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// enabledUsers is an EnabledCheck that counts the checks it made.
+type enabledUsers struct {
+	enabled map[uuid.UUID]bool
+	calls   int
+}
+
+func (eu *enabledUsers) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	eu.calls++
+	return eu.enabled[userID], nil
+}
+
+type roleStore struct {
+	roles []role.Role
+}
+
+func (rs *roleStore) QueryAll(ctx context.Context) ([]role.Role, error) {
+	return rs.roles, nil
+}
@@ -29,3 +29,30 @@ func IsAuthError(err error) bool {
 	var ae *AuthError
 	return errors.As(err, &ae)
 }
+
+// =============================================================================
+
+// ForbiddenError is used when an authenticated client isn't authorized for
+// the action it requested.
+type ForbiddenError struct {
+	msg string
+}
+
+// NewForbiddenError creates a ForbiddenError for the provided message.
+func NewForbiddenError(format string, args ...any) error {
+	return &ForbiddenError{
+		msg: fmt.Sprintf(format, args...),
+	}
+}
+
+// Error implements the error interface. This is what will be shown in the
+// services' logs.
+func (fe *ForbiddenError) Error() string {
+	return fe.msg
+}
+
+// IsForbiddenError checks if an error of type ForbiddenError exists.
+func IsForbiddenError(err error) bool {
+	var fe *ForbiddenError
+	return errors.As(err, &fe)
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/keystore"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// validMethods are the signing algorithms a token can use. The one used for
+// a kid is selected from the type of its key, so RSA and EC keys can be used
+// side by side while migrating from one to the other.
+var validMethods = []string{
+	jwt.SigningMethodRS256.Name,
+	jwt.SigningMethodES256.Name,
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// methodForKey returns the signing method to use with the key.
+func methodForKey(key any) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+
+	case *ecdsa.PrivateKey:
+		return methodForCurve(k.Curve)
+
+	case *ecdsa.PublicKey:
+		return methodForCurve(k.Curve)
+
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+func methodForCurve(curve elliptic.Curve) (jwt.SigningMethod, error) {
+	if curve != elliptic.P256() {
+		return nil, errors.New("only the P-256 curve is supported for ecdsa keys")
+	}
+
+	return jwt.SigningMethodES256, nil
+}
+
+// parsePrivateKey parses a PEM encoded private key and returns it with the
+// signing method to use with it.
+func parsePrivateKey(s string) (crypto.Signer, jwt.SigningMethod, error) {
+	key, err := keystore.ParsePrivateKey([]byte(s))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	method, err := methodForKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, method, nil
+}
+
+// parsePublicKey parses a PEM encoded public key and returns it with the
+// signing method to use with it.
+func parsePublicKey(s string) (crypto.PublicKey, jwt.SigningMethod, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, nil, errors.New("key must be pem encoded")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	method, err := methodForKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, method, nil
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/storage"
+)
+
+// policySet represents a compiled set of rego policies and the queries that
+// were prepared for the rules. A set is never changed once it is active, a
+// reload builds a new set and swaps it in. The data the policies are
+// evaluated against is shared by every set, so new permissions are seen
+// without a reload.
+type policySet struct {
+	hash     string
+	compiler *ast.Compiler
+	data     storage.Store
+	queries  map[string]rego.PreparedEvalQuery
+
+	// A rule the service doesn't require is prepared the first time it's
+	// used and kept here.
+	mu    sync.RWMutex
+	extra map[string]rego.PreparedEvalQuery
+}
+
+// newPolicySet compiles the modules and prepares the queries for the
+// required rules. It fails if any required rule is missing.
+func newPolicySet(ctx context.Context, modules map[string]string, data storage.Store) (*policySet, error) {
+	compiler, err := ast.CompileModules(modules)
+	if err != nil {
+		return nil, fmt.Errorf("compiling policies: %w", err)
+	}
+
+	ps := policySet{
+		hash:     hashModules(modules),
+		compiler: compiler,
+		data:     data,
+		queries:  make(map[string]rego.PreparedEvalQuery),
+		extra:    make(map[string]rego.PreparedEvalQuery),
+	}
+
+	for _, rule := range requiredRules {
+		ref := ast.MustParseRef(fmt.Sprintf("data.%s.%s", opaPackage, rule))
+		if len(compiler.GetRulesExact(ref)) == 0 {
+			return nil, fmt.Errorf("rule %q is not defined", rule)
+		}
+
+		q, err := ps.prepare(ctx, rule)
+		if err != nil {
+			return nil, fmt.Errorf("preparing rule %q: %w", rule, err)
+		}
+		ps.queries[rule] = q
+	}
+
+	return &ps, nil
+}
+
+// query returns the prepared query for the rule.
+func (ps *policySet) query(ctx context.Context, rule string) (rego.PreparedEvalQuery, error) {
+	if q, exists := ps.queries[rule]; exists {
+		return q, nil
+	}
+
+	ps.mu.RLock()
+	q, exists := ps.extra[rule]
+	ps.mu.RUnlock()
+
+	if exists {
+		return q, nil
+	}
+
+	q, err := ps.prepare(ctx, rule)
+	if err != nil {
+		return rego.PreparedEvalQuery{}, err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.extra[rule] = q
+
+	return q, nil
+}
+
+func (ps *policySet) prepare(ctx context.Context, rule string) (rego.PreparedEvalQuery, error) {
+	query := fmt.Sprintf("x = data.%s.%s", opaPackage, rule)
+	return rego.New(
+		rego.Query(query),
+		rego.Compiler(ps.compiler),
+		rego.Store(ps.data),
+	).PrepareForEval(ctx)
+}
+
+// =============================================================================
+
+// ReloadPolicies loads the policies, validates them and makes them the active
+// set. If anything fails the current set stays active.
+func (a *Auth) ReloadPolicies(ctx context.Context) error {
+	modules, err := loadPolicies(a.policyPath)
+	if err != nil {
+		return fmt.Errorf("loading policies: %w", err)
+	}
+
+	if current := a.policies.Load(); current != nil && current.hash == hashModules(modules) {
+		return nil
+	}
+
+	ps, err := newPolicySet(ctx, modules, a.data)
+	if err != nil {
+		return err
+	}
+
+	a.policies.Store(ps)
+
+	return nil
+}
+
+// WatchPolicies polls the policy path for changes and reloads the policies
+// when they change. It blocks until the context is cancelled. A failed reload
+// is logged and the previous set of policies stays active.
+func (a *Auth) WatchPolicies(ctx context.Context, interval time.Duration) {
+	if a.policyPath == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			hash := a.policies.Load().hash
+			if err := a.ReloadPolicies(ctx); err != nil {
+				a.log.Errorw("auth", "status", "policy reload failed, keeping previous policies", "path", a.policyPath, "ERROR", err)
+				continue
+			}
+
+			if a.policies.Load().hash != hash {
+				a.log.Infow("auth", "status", "policies reloaded", "path", a.policyPath)
+			}
+		}
+	}
+}
+
+// =============================================================================
+
+// maxPolicySize limits the size of a single policy file. This should be
+// reasonable for almost any policy and prevents reading something that
+// never ends.
+const maxPolicySize = 1024 * 1024
+
+// loadPolicies returns the embedded policies with the policies found at the
+// path loaded on top. The path can be a directory or a .tar.gz bundle, a
+// file named like an embedded policy replaces it.
+func loadPolicies(path string) (map[string]string, error) {
+	modules := map[string]string{
+		"authentication.rego": opaAuthentication,
+		"authorization.rego":  opaAuthorization,
+	}
+
+	if path == "" {
+		return modules, nil
+	}
+
+	var err error
+	switch {
+	case strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz"):
+		err = loadBundle(path, modules)
+	default:
+		err = loadDir(path, modules)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return modules, nil
+}
+
+func loadDir(dir string, modules map[string]string) error {
+	fsys := os.DirFS(dir)
+
+	fn := func(fileName string, dirEntry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walkdir failure: %w", err)
+		}
+
+		if dirEntry.IsDir() || filepath.Ext(fileName) != ".rego" {
+			return nil
+		}
+
+		file, err := fsys.Open(fileName)
+		if err != nil {
+			return fmt.Errorf("opening policy file: %w", err)
+		}
+		defer file.Close()
+
+		b, err := io.ReadAll(io.LimitReader(file, maxPolicySize))
+		if err != nil {
+			return fmt.Errorf("reading policy file %s: %w", fileName, err)
+		}
+
+		modules[fileName] = string(b)
+
+		return nil
+	}
+
+	if err := fs.WalkDir(fsys, ".", fn); err != nil {
+		return fmt.Errorf("walking directory: %w", err)
+	}
+
+	return nil
+}
+
+func loadBundle(path string, modules map[string]string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening bundle: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("reading bundle: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("reading bundle: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || filepath.Ext(hdr.Name) != ".rego" {
+			continue
+		}
+
+		b, err := io.ReadAll(io.LimitReader(tr, maxPolicySize))
+		if err != nil {
+			return fmt.Errorf("reading policy file %s: %w", hdr.Name, err)
+		}
+
+		modules[strings.TrimPrefix(filepath.Clean(hdr.Name), "/")] = string(b)
+	}
+}
+
+// hashModules returns a fingerprint of the modules so a reload can tell if
+// anything changed.
+func hashModules(modules map[string]string) string {
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, modules[name])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// userOnlyRead changes ruleProductRead so only users are allowed.
+var userOnlyRead = strings.Replace(opaAuthorization, "ruleProductRead {\n\tpermitted(\"product:read\")\n}", "ruleProductRead {\n\truleUserOnly\n}", 1)
+
+func Test_Policies(t *testing.T) {
+	t.Run("dir", policyDir)
+	t.Run("bundle", policyBundle)
+}
+
+func policyDir(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	a, err := New(Config{
+		Log:        zap.NewNop().Sugar(),
+		KeyLookup:  &keyStore{},
+		PolicyPath: dir,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct auth with an empty policy dir : %s", err)
+	}
+
+	admin := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+		Roles:            []user.Role{user.RoleAdmin},
+	}
+
+	if err := a.Authorize(ctx, admin, uuid.UUID{}, RuleProductRead); err != nil {
+		t.Fatalf("Should authorize an admin with the embedded policies : %s", err)
+	}
+
+	// -------------------------------------------------------------------------
+
+	writeFile(t, filepath.Join(dir, "authorization.rego"), userOnlyRead)
+
+	if err := a.ReloadPolicies(ctx); err != nil {
+		t.Fatalf("Should be able to reload the policies : %s", err)
+	}
+
+	if err := a.Authorize(ctx, admin, uuid.UUID{}, RuleProductRead); err == nil {
+		t.Fatalf("Should NOT authorize an admin with the reloaded policies")
+	}
+
+	// -------------------------------------------------------------------------
+
+	writeFile(t, filepath.Join(dir, "authorization.rego"), "package rego\n\nruleProductRead {")
+
+	if err := a.ReloadPolicies(ctx); err == nil {
+		t.Fatalf("Should NOT be able to reload a policy that doesn't compile")
+	}
+
+	writeFile(t, filepath.Join(dir, "authorization.rego"), "package rego\n\nruleProductRead := true")
+
+	if err := a.ReloadPolicies(ctx); err == nil {
+		t.Fatalf("Should NOT be able to reload policies missing a required rule")
+	}
+
+	if err := a.Authorize(ctx, admin, uuid.UUID{}, RuleProductRead); err == nil {
+		t.Fatalf("Should keep the previous policies when a reload fails")
+	}
+}
+
+func policyBundle(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	hdr := tar.Header{
+		Name:     "./authorization.rego",
+		Mode:     0600,
+		Size:     int64(len(userOnlyRead)),
+		Typeflag: tar.TypeReg,
+	}
+	if err := tw.WriteHeader(&hdr); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(userOnlyRead)); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	path := filepath.Join(t.TempDir(), "policies.tar.gz")
+	writeFile(t, path, buf.String())
+
+	a, err := New(Config{
+		Log:        zap.NewNop().Sugar(),
+		KeyLookup:  &keyStore{},
+		PolicyPath: path,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct auth with a policy bundle : %s", err)
+	}
+
+	admin := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: uuid.NewString()},
+		Roles:            []user.Role{user.RoleAdmin},
+	}
+
+	if err := a.Authorize(context.Background(), admin, uuid.UUID{}, RuleProductRead); err == nil {
+		t.Fatalf("Should NOT authorize an admin with the bundled policies")
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
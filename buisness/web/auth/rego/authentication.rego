@@ -14,9 +14,11 @@ jwt_valid := valid {
 }
 
 # This has a function that is part of rego 'decode_verify'
-# and you can pass inputs as token, public key & iss information
+# and you can pass inputs as token, public key, iss & alg information
 # then it will return verify_jwt and we gonna divide it into 3 parts
+# The alg is the one of the key, so a token signed with another alg fails
 verify_jwt := io.jwt.decode_verify(input.Token, {
 	"cert": input.Key,
 	"iss": input.ISS,
+	"alg": input.Alg,
 })
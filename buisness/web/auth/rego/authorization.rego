@@ -1,44 +1,139 @@
 package rego
 
-default ruleAny = false
-
 default ruleAdminOnly = false
 
 default ruleUserOnly = false
 
 default ruleAdminOrSubject = false
 
-roleUser := "USER"
+default ruleAdminOnlyMFA = false
 
-roleAdmin := "ADMIN"
+default ruleSuperAdminOnly = false
 
-roleAll := {roleAdmin, roleUser}
+default ruleSuperAdminOnlyMFA = false
 
-ruleAny {
-	claim_roles := {role | role := input.Roles[_]}
-	input_roles := roleAll & claim_roles
-	count(input_roles) > 0
-}
+default ruleProductRead = false
+
+default ruleProductWrite = false
+
+default ruleSaleWrite = false
+
+default ruleMFA = false
+
+default ruleUserRead = false
+
+default ruleUserWrite = false
+
+default ruleSaleRead = false
+
+default ruleTenantRead = false
+
+default ruleTenantWrite = false
+
+default ruleRoleWrite = false
+
+default ruleAPIKeyWrite = false
+
+default ruleEventRead = false
 
+# Every rule is decided by the permissions the roles in the claims hold, see
+# permitted.
+
+# An admin manages the users of their tenant.
 ruleAdminOnly {
-	claim_roles := {role | role := input.Roles[_]}
-	input_admin := {roleAdmin} & claim_roles
-	count(input_admin) > 0
+	permitted("user:write")
 }
 
+# A user is anyone holding a permission who isn't an admin.
 ruleUserOnly {
-	claim_roles := {role | role := input.Roles[_]}
-	input_user := {roleUser} & claim_roles
-	count(input_user) > 0
+	data.permissions[input.Roles[_]][_]
+	not permitted("user:write")
 }
 
 ruleAdminOrSubject {
-	claim_roles := {role | role := input.Roles[_]}
-	input_admin := {roleAdmin} & claim_roles
-	count(input_admin) > 0
+	permitted("user:write")
 } else {
-	claim_roles := {role | role := input.Roles[_]}
-	input_user := {roleUser} & claim_roles
-	count(input_user) > 0
+	data.permissions[input.Roles[_]][_]
 	input.UserID == input.Subject
 }
+
+ruleAdminOnlyMFA {
+	ruleAdminOnly
+	mfa
+}
+
+# mfa is true when the claims say the user authenticated with more than one
+# factor. Rules that need a second factor can require it.
+mfa {
+	input.AMR[_] == "mfa"
+}
+
+ruleMFA {
+	mfa
+}
+
+ruleSuperAdminOnly {
+	superAdmin
+}
+
+ruleSuperAdminOnlyMFA {
+	superAdmin
+	mfa
+}
+
+# superAdmin is true when the user can act on every tenant, which is what
+# managing the tenants takes.
+superAdmin {
+	permitted("tenant:write")
+}
+
+ruleUserRead {
+	permitted("user:read")
+}
+
+ruleUserWrite {
+	permitted("user:write")
+}
+
+ruleProductRead {
+	permitted("product:read")
+}
+
+ruleProductWrite {
+	permitted("product:write")
+}
+
+ruleSaleRead {
+	permitted("sale:read")
+}
+
+ruleSaleWrite {
+	permitted("sale:write")
+}
+
+ruleTenantRead {
+	permitted("tenant:read")
+}
+
+ruleTenantWrite {
+	permitted("tenant:write")
+}
+
+ruleRoleWrite {
+	permitted("role:write")
+}
+
+ruleAPIKeyWrite {
+	permitted("apikey:write")
+}
+
+ruleEventRead {
+	permitted("events:read")
+}
+
+# permitted is true when one of the roles in the claims holds the permission.
+# The permissions of every role are loaded from the database into
+# data.permissions.
+permitted(permission) {
+	data.permissions[input.Roles[_]][_] == permission
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/role"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/open-policy-agent/opa/storage"
+)
+
+// permissionsPath is where the permissions of every role are kept in the
+// data the policies are evaluated against. A policy looks up the
+// permissions of a role with data.permissions[role].
+var permissionsPath = storage.MustParsePath("/permissions")
+
+// builtInPermissions returns the permissions the database is seeded with for
+// the built in roles. The policies use them until the roles are loaded.
+func builtInPermissions() map[string]any {
+	return map[string]any{
+		"USER":        []any{"product:read", "product:write", "sale:write", "apikey:write", "events:read"},
+		"ADMIN":       []any{"user:read", "user:write", "product:read", "product:write", "sale:read", "sale:write", "apikey:write", "tenant:read", "events:read"},
+		"SUPER_ADMIN": []any{"user:read", "user:write", "product:read", "product:write", "sale:read", "sale:write", "apikey:write", "tenant:read", "tenant:write", "role:write", "events:read"},
+	}
+}
+
+// ReloadRoles loads the roles from the RoleLookup, makes them the roles
+// user.ParseRole accepts and hands their permissions to the policies. If
+// anything fails the current roles stay active.
+func (a *Auth) ReloadRoles(ctx context.Context) error {
+	if a.roles == nil {
+		return nil
+	}
+
+	rls, err := a.roles.QueryAll(ctx)
+	if err != nil {
+		return fmt.Errorf("loading roles: %w", err)
+	}
+
+	return a.setRoles(ctx, rls)
+}
+
+// ReloadRolesOnCommit reloads the roles once the transaction in the context
+// commits, so a change made to the roles under it is used right away and a
+// change that is rolled back never is. A failed reload is logged and left
+// to WatchRoles.
+func (a *Auth) ReloadRolesOnCommit(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+
+	transaction.OnCommit(ctx, func() {
+		if err := a.ReloadRoles(ctx); err != nil {
+			a.log.Errorw("auth", "status", "role reload failed, keeping previous roles", "ERROR", err)
+		}
+	})
+}
+
+// setRoles makes the specified roles the roles user.ParseRole accepts and
+// hands their permissions to the policies.
+func (a *Auth) setRoles(ctx context.Context, rls []role.Role) error {
+	names := make([]string, len(rls))
+	perms := make(map[string]any, len(rls))
+	for i, rl := range rls {
+		names[i] = rl.Name
+		perms[rl.Name] = rl.Permissions
+	}
+
+	if err := storage.WriteOne(ctx, a.data, storage.ReplaceOp, permissionsPath, perms); err != nil {
+		return fmt.Errorf("writing permissions: %w", err)
+	}
+
+	user.SetRoles(names)
+
+	return nil
+}
+
+// WatchRoles reloads the roles at the interval so changes made through
+// another instance of the service are picked up. It blocks until the
+// context is cancelled. A failed reload is logged and the previous roles
+// stay active.
+func (a *Auth) WatchRoles(ctx context.Context, interval time.Duration) {
+	if a.roles == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			if err := a.ReloadRoles(ctx); err != nil {
+				a.log.Errorw("auth", "status", "role reload failed, keeping previous roles", "ERROR", err)
+			}
+		}
+	}
+}
@@ -6,13 +6,49 @@ import (
 
 // These the current set of rules we have for auth.
 const (
-	RuleAuthenticate   = "auth"
-	RuleAny            = "ruleAny"
-	RuleAdminOnly      = "ruleAdminOnly"
-	RuleUserOnly       = "ruleUserOnly"
-	RuleAdminOrSubject = "ruleAdminOrSubject"
+	RuleAuthenticate      = "auth"
+	RuleAdminOnly         = "ruleAdminOnly"
+	RuleUserOnly          = "ruleUserOnly"
+	RuleAdminOrSubject    = "ruleAdminOrSubject"
+	RuleAdminOnlyMFA      = "ruleAdminOnlyMFA"
+	RuleSuperAdminOnly    = "ruleSuperAdminOnly"
+	RuleSuperAdminOnlyMFA = "ruleSuperAdminOnlyMFA"
+	RuleMFA               = "ruleMFA"
+	RuleUserRead          = "ruleUserRead"
+	RuleUserWrite         = "ruleUserWrite"
+	RuleProductRead       = "ruleProductRead"
+	RuleProductWrite      = "ruleProductWrite"
+	RuleSaleRead          = "ruleSaleRead"
+	RuleSaleWrite         = "ruleSaleWrite"
+	RuleTenantRead        = "ruleTenantRead"
+	RuleTenantWrite       = "ruleTenantWrite"
+	RuleRoleWrite         = "ruleRoleWrite"
+	RuleAPIKeyWrite       = "ruleAPIKeyWrite"
+	RuleEventRead         = "ruleEventRead"
 )
 
+// requiredRules are the rules the service depends on. Every set of policies
+// must define them and their queries are prepared when the set is loaded.
+var requiredRules = []string{
+	RuleAuthenticate,
+	RuleAdminOnly,
+	RuleUserOnly,
+	RuleAdminOrSubject,
+	RuleSuperAdminOnly,
+	RuleMFA,
+	RuleUserRead,
+	RuleUserWrite,
+	RuleProductRead,
+	RuleProductWrite,
+	RuleSaleRead,
+	RuleSaleWrite,
+	RuleTenantRead,
+	RuleTenantWrite,
+	RuleRoleWrite,
+	RuleAPIKeyWrite,
+	RuleEventRead,
+}
+
 // Package name of our rego code.
 const (
 	opaPackage string = "rego"
@@ -2,21 +2,64 @@ package mid
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/audit"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/tenant"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/auth"
+	v1 "github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"github.com/google/uuid"
 )
 
-// Authenticate validates a JWT from the `Authorization` header.
+// ErrInvalidID represents a condition where the id is not a uuid.
+var ErrInvalidID = errors.New("ID is not in its proper form")
+
+// Authenticate validates a JWT from the `Authorization` header, or an api key
+// from the `X-API-Key` header when one is present. Both produce the same
+// claims so the authorization rules apply to either. The data the request
+// can read is limited to the tenant in the claims, only super admins can
+// read every tenant. Tokens issued without a tenant are rejected. The subject
+// of the claims is the actor recorded for the changes the request makes.
 func Authenticate(a *auth.Auth) web.Middleware {
 	m := func(handler web.Handler) web.Handler {
 		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
-			claims, err := a.Authenticate(ctx, r.Header.Get("authorization"))
+			var claims auth.Claims
+			var err error
+
+			switch key := r.Header.Get("X-API-Key"); key {
+			case "":
+				claims, err = a.Authenticate(ctx, r.Header.Get("authorization"))
+			default:
+				claims, err = a.AuthenticateAPIKey(ctx, key)
+			}
 			if err != nil {
 				return auth.NewAuthError("authenticate: failed: %s", err)
 			}
 
+			switch {
+			case a.Authorize(ctx, claims, uuid.UUID{}, auth.RuleSuperAdminOnly) == nil:
+				ctx = tenant.SetScopeAll(ctx)
+
+			default:
+				tenantID, err := uuid.Parse(claims.TenantID)
+				if err != nil {
+					return auth.NewAuthError("authenticate: failed: no tenant in claims")
+				}
+				ctx = tenant.SetScope(ctx, tenantID)
+			}
+
+			// A subject that isn't a uuid can't be authorized for anything, so
+			// it's only left out of the audit log here.
+			if userID, err := uuid.Parse(claims.Subject); err == nil {
+				ctx = audit.SetActor(ctx, userID)
+			}
+
 			ctx = auth.SetClaims(ctx, claims)
 
 			return handler(ctx, w, r)
@@ -28,6 +71,21 @@ func Authenticate(a *auth.Auth) web.Middleware {
 	return m
 }
 
+// ScopeAll lets the routes that run before a user is authenticated, like
+// logging in, see the data of every tenant. The routes that authenticate are
+// limited to the tenant of the claims by Authenticate instead.
+func ScopeAll() web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			return handler(tenant.SetScopeAll(ctx), w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
 // Authorize validates that an authenticated user has at least one role from a
 // specified list. This method constructs the actual function that is used.
 func Authorize(a *auth.Auth, rule string) web.Middleware {
@@ -38,10 +96,158 @@ func Authorize(a *auth.Auth, rule string) web.Middleware {
 				return auth.NewAuthError("authorize: you are not authorized for that action, no claims")
 			}
 
-			if err := a.Authorize(ctx, claims, rule); err != nil {
-				return auth.NewAuthError("authorize: you are not authorized for that action, claims[%v], rule[%v]: %s", claims.Roles, rule, err)
+			if err := a.Authorize(ctx, claims, uuid.UUID{}, rule); err != nil {
+				return auth.NewForbiddenError("authorize: you are not authorized for that action, claims[%v], rule[%v]: %s", claims.Roles, rule, err)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// AuthorizeUser executes the specified rule and extracts the specified user
+// from the DB if a user id is specified in the call. Depending on the rule
+// specified, the user id from the claims may be compared with the specified
+// user id. The user is stored in the context for the handler to use. The
+// user of a super admin can only be acted on by a super admin or themselves,
+// so an admin of the same tenant can't take over the account.
+func AuthorizeUser(a *auth.Auth, usrCore *user.Core, rule string) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims := auth.GetClaims(ctx)
+			if claims.Subject == "" {
+				return auth.NewAuthError("authorize: you are not authorized for that action, no claims")
+			}
+
+			var userID uuid.UUID
+
+			if id := web.Param(r, "user_id"); id != "" {
+				var err error
+				userID, err = uuid.Parse(id)
+				if err != nil {
+					return v1.NewRequestError(ErrInvalidID, http.StatusBadRequest)
+				}
+
+				usr, err := usrCore.QueryByID(ctx, userID)
+				if err != nil {
+					switch {
+					case errors.Is(err, user.ErrNotFound):
+						return v1.NewRequestError(err, http.StatusNotFound)
+					default:
+						return fmt.Errorf("querybyid: userID[%s]: %w", userID, err)
+					}
+				}
+
+				if usr.HasRole(user.RoleSuperAdmin) && usr.ID.String() != claims.Subject {
+					if err := a.Authorize(ctx, claims, uuid.UUID{}, auth.RuleSuperAdminOnly); err != nil {
+						return auth.NewForbiddenError("authorize: only a super admin can act on a super admin")
+					}
+				}
+
+				ctx = setUser(ctx, usr)
+			}
+
+			if err := a.Authorize(ctx, claims, userID, rule); err != nil {
+				return auth.NewForbiddenError("authorize: you are not authorized for that action, claims[%v], rule[%v]: %s", claims.Roles, rule, err)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// AuthorizeProduct executes the specified rule and extracts the specified
+// product from the DB if a product id is specified in the call. The user id
+// of the product is compared with the user id from the claims depending on
+// the rule. When no product id is specified, the caller is acting on a
+// product they will own, so the subject of the claims is used.
+func AuthorizeProduct(a *auth.Auth, prdCore *product.Core, rule string) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims := auth.GetClaims(ctx)
+			if claims.Subject == "" {
+				return auth.NewAuthError("authorize: you are not authorized for that action, no claims")
+			}
+
+			userID, err := uuid.Parse(claims.Subject)
+			if err != nil {
+				return auth.NewAuthError("authorize: invalid subject in claims: %s", err)
+			}
+
+			if id := web.Param(r, "product_id"); id != "" {
+				productID, err := uuid.Parse(id)
+				if err != nil {
+					return v1.NewRequestError(ErrInvalidID, http.StatusBadRequest)
+				}
+
+				prd, err := prdCore.QueryByID(ctx, productID)
+				if err != nil {
+					switch {
+					case errors.Is(err, product.ErrNotFound):
+						return v1.NewRequestError(err, http.StatusNotFound)
+					default:
+						return fmt.Errorf("querybyid: productID[%s]: %w", productID, err)
+					}
+				}
+
+				userID = prd.UserID
+				ctx = setProduct(ctx, prd)
+			}
+
+			if err := a.Authorize(ctx, claims, userID, rule); err != nil {
+				return auth.NewForbiddenError("authorize: you are not authorized for that action, claims[%v], rule[%v]: %s", claims.Roles, rule, err)
+			}
+
+			return handler(ctx, w, r)
+		}
+
+		return h
+	}
+
+	return m
+}
+
+// AuthorizeAPIKey executes the specified rule and extracts the specified api
+// key from the DB. The user id of the key is compared with the user id from
+// the claims depending on the rule. The key is stored in the context for the
+// handler to use.
+func AuthorizeAPIKey(a *auth.Auth, akCore *apikey.Core, rule string) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			claims := auth.GetClaims(ctx)
+			if claims.Subject == "" {
+				return auth.NewAuthError("authorize: you are not authorized for that action, no claims")
+			}
+
+			keyID, err := uuid.Parse(web.Param(r, "key_id"))
+			if err != nil {
+				return v1.NewRequestError(ErrInvalidID, http.StatusBadRequest)
 			}
 
+			ak, err := akCore.QueryByID(ctx, keyID)
+			if err != nil {
+				switch {
+				case errors.Is(err, apikey.ErrNotFound):
+					return v1.NewRequestError(err, http.StatusNotFound)
+				default:
+					return fmt.Errorf("querybyid: keyID[%s]: %w", keyID, err)
+				}
+			}
+
+			if err := a.Authorize(ctx, claims, ak.UserID, rule); err != nil {
+				return auth.NewForbiddenError("authorize: you are not authorized for that action, claims[%v], rule[%v]: %s", claims.Roles, rule, err)
+			}
+
+			ctx = setAPIKey(ctx, ak)
+
 			return handler(ctx, w, r)
 		}
 
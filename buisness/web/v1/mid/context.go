@@ -2,8 +2,12 @@ package mid
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/apikey"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/product"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/core/user"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/metrics"
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
 )
@@ -33,3 +37,60 @@ func Metrics() web.Middleware {
 
 	return m
 }
+
+// =============================================================================
+
+// ctxKey represents the type of value for the context key.
+type ctxKey int
+
+// Set of keys used to store values loaded by the authorization middleware.
+const (
+	userKey ctxKey = iota + 1
+	productKey
+	apiKeyKey
+)
+
+func setUser(ctx context.Context, usr user.User) context.Context {
+	return context.WithValue(ctx, userKey, usr)
+}
+
+// GetUser returns the user from the context. The user is only available when
+// the route was wrapped with the AuthorizeUser middleware.
+func GetUser(ctx context.Context) (user.User, error) {
+	v, ok := ctx.Value(userKey).(user.User)
+	if !ok {
+		return user.User{}, errors.New("user not found in context")
+	}
+
+	return v, nil
+}
+
+func setProduct(ctx context.Context, prd product.Product) context.Context {
+	return context.WithValue(ctx, productKey, prd)
+}
+
+// GetProduct returns the product from the context. The product is only
+// available when the route was wrapped with the AuthorizeProduct middleware.
+func GetProduct(ctx context.Context) (product.Product, error) {
+	v, ok := ctx.Value(productKey).(product.Product)
+	if !ok {
+		return product.Product{}, errors.New("product not found in context")
+	}
+
+	return v, nil
+}
+
+func setAPIKey(ctx context.Context, ak apikey.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyKey, ak)
+}
+
+// GetAPIKey returns the api key from the context. The key is only available
+// when the route was wrapped with the AuthorizeAPIKey middleware.
+func GetAPIKey(ctx context.Context) (apikey.APIKey, error) {
+	v, ok := ctx.Value(apiKeyKey).(apikey.APIKey)
+	if !ok {
+		return apikey.APIKey{}, errors.New("api key not found in context")
+	}
+
+	return v, nil
+}
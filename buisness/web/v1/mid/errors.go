@@ -2,6 +2,7 @@ package mid
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
 	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/validate"
@@ -20,6 +21,20 @@ func Errors(log *zap.SugaredLogger) web.Middleware {
 			// Call the handler to see if an error occurred.
 			// So we can process it
 			if err := handler(ctx, w, r); err != nil {
+				// A streamed response that already started has sent its
+				// status, there is no way left to respond with the error.
+				// Most of them end because the client went away, which is
+				// not an error of the service.
+				if web.IsStreamError(err) {
+					if errors.Is(err, context.Canceled) || r.Context().Err() != nil {
+						log.Infow("stream ended", "trace_id", web.GetTraceID(ctx), "message", err)
+						return nil
+					}
+
+					log.Errorw("ERROR", "trace_id", web.GetTraceID(ctx), "message", err)
+					return nil
+				}
+
 				// First step in error handeling is to log the error.
 				log.Errorw("ERROR", "trace_id", web.GetTraceID(ctx), "message", err)
 
@@ -44,6 +59,12 @@ func Errors(log *zap.SugaredLogger) web.Middleware {
 						Fields: fieldErrors.Fields(),
 					}
 					status = http.StatusBadRequest
+				// Is the client authenticated but not allowed the action
+				case auth.IsForbiddenError(err):
+					er = v1.ErrorResponse{
+						Error: http.StatusText(http.StatusForbidden),
+					}
+					status = http.StatusForbidden
 				// Is it an auth error
 				case auth.IsAuthError(err):
 					er = v1.ErrorResponse{
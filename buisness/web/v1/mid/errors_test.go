@@ -0,0 +1,111 @@
+package mid_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/web/v1/mid"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func Test_ErrorsStream(t *testing.T) {
+	// The stream sends one value and then fails.
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var n int
+		next := func(ctx context.Context) (int, bool, error) {
+			n++
+			if n == 2 {
+				return 0, false, errors.New("query failed")
+			}
+			return n, true, nil
+		}
+
+		return web.RespondIter(ctx, w, web.NDJSON, http.StatusOK, next)
+	}
+
+	h := mid.Errors(zap.NewNop().Sugar())(handler)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if err := h(context.Background(), w, r); err != nil {
+		t.Fatalf("Should swallow the error of a stream that started : %s", err)
+	}
+
+	exp := "1\n"
+	if got := w.Body.String(); got != exp {
+		t.Logf("got: %q", got)
+		t.Logf("exp: %q", exp)
+		t.Errorf("Should NOT write an error response into the stream")
+	}
+}
+
+func Test_ErrorsStreamDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The client goes away once the first value was sent.
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		var n int
+		next := func(ctx context.Context) (int, bool, error) {
+			n++
+			if n == 2 {
+				cancel()
+			}
+			return n, true, nil
+		}
+
+		return web.RespondIter(ctx, w, web.NDJSON, http.StatusOK, next)
+	}
+
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(&buf), zapcore.DebugLevel)
+
+	h := mid.Errors(zap.New(core).Sugar())(handler)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	if err := h(ctx, w, r); err != nil {
+		t.Fatalf("Should swallow the error of a stream that started : %s", err)
+	}
+
+	logs := buf.String()
+
+	if strings.Contains(logs, `"level":"error"`) || !strings.Contains(logs, `"level":"info"`) {
+		t.Logf("got: %s", logs)
+		t.Errorf("Should log a client that went away at info")
+	}
+}
+
+func Test_ErrorsBeforeStream(t *testing.T) {
+	handler := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		next := func(ctx context.Context) (int, bool, error) {
+			return 0, false, errors.New("query failed")
+		}
+
+		return web.RespondIter(ctx, w, web.NDJSON, http.StatusOK, next)
+	}
+
+	h := mid.Errors(zap.NewNop().Sugar())(handler)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if err := h(context.Background(), w, r); err != nil {
+		t.Fatalf("Should respond with the error : %s", err)
+	}
+
+	if w.Code != http.StatusInternalServerError {
+		t.Logf("got: %d", w.Code)
+		t.Logf("exp: %d", http.StatusInternalServerError)
+		t.Errorf("Should respond with the error of a stream that didn't start")
+	}
+}
@@ -0,0 +1,65 @@
+package mid
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/buisness/sys/database/transaction"
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+	"go.uber.org/zap"
+)
+
+// ExecuteInTransaction starts a transaction around all the storage calls within
+// the scope of the handler function. The transaction is committed when the
+// handler returns without an error and rolled back otherwise. The functions
+// registered with transaction.OnCommit are called once it committed.
+func ExecuteInTransaction(log *zap.SugaredLogger, bgn transaction.Beginner) web.Middleware {
+	m := func(handler web.Handler) web.Handler {
+		h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			hasCommitted := false
+
+			log.Infow("BEGIN TRANSACTION", "trace_id", web.GetTraceID(ctx))
+			tx, err := bgn.Begin()
+			if err != nil {
+				return fmt.Errorf("BEGIN TRANSACTION: %w", err)
+			}
+
+			defer func() {
+				if !hasCommitted {
+					log.Infow("ROLLBACK TRANSACTION", "trace_id", web.GetTraceID(ctx))
+				}
+
+				if err := tx.Rollback(); err != nil {
+					if errors.Is(err, sql.ErrTxDone) {
+						return
+					}
+					log.Infow("ROLLBACK TRANSACTION", "trace_id", web.GetTraceID(ctx), "ERROR", err)
+				}
+			}()
+
+			ctx = transaction.Set(ctx, tx)
+
+			if err := handler(ctx, w, r); err != nil {
+				return fmt.Errorf("EXECUTE TRANSACTION: %w", err)
+			}
+
+			log.Infow("COMMIT TRANSACTION", "trace_id", web.GetTraceID(ctx))
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("COMMIT TRANSACTION: %w", err)
+			}
+
+			hasCommitted = true
+
+			transaction.Committed(ctx)
+
+			return nil
+		}
+
+		return h
+	}
+
+	return m
+}
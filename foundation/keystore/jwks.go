@@ -0,0 +1,275 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// JWK represents a single public key inside a JSON Web Key Set as described
+// in RFC 7517. N and E are set for RSA keys, Curve and X for EC and OKP keys
+// and Y only for EC keys.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg,omitempty"`
+	Use       string `json:"use,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JWKS represents a JSON Web Key Set document.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys in the key store as a JSON Web Key Set. The
+// keys are sorted by kid so the document is stable between calls.
+func (ks *KeyStore) JWKS() (JWKS, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	kids := make([]string, 0, len(ks.store))
+	for kid := range ks.store {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := JWKS{
+		Keys: make([]JWK, 0, len(kids)),
+	}
+
+	for _, kid := range kids {
+		jwk, err := toJWK(kid, ks.store[kid].PK.Public())
+		if err != nil {
+			return JWKS{}, fmt.Errorf("kid[%s]: %w", kid, err)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks, nil
+}
+
+// =============================================================================
+
+// JWKSStore represents a KeyLookup implementation backed by a JSON Web Key
+// Set document. It only holds public keys, so it can verify tokens but not
+// generate them.
+type JWKSStore struct {
+	jwks  JWKS
+	store map[string]crypto.PublicKey
+}
+
+// NewJWKS constructs a JWKSStore from the JSON Web Key Set document read from
+// the specified reader. Keys that are not signing keys of a supported type
+// are ignored.
+func NewJWKS(r io.Reader) (*JWKSStore, error) {
+	var jwks JWKS
+	if err := json.NewDecoder(io.LimitReader(r, 1024*1024)).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("decoding jwks: %w", err)
+	}
+
+	js := JWKSStore{
+		store: make(map[string]crypto.PublicKey),
+	}
+
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		switch jwk.KeyType {
+		case "RSA", "EC", "OKP":
+		default:
+			continue
+		}
+
+		if jwk.KeyID == "" {
+			return nil, errors.New("jwks key is missing its kid")
+		}
+
+		pub, err := fromJWK(jwk)
+		if err != nil {
+			return nil, fmt.Errorf("kid[%s]: %w", jwk.KeyID, err)
+		}
+
+		js.store[jwk.KeyID] = pub
+		js.jwks.Keys = append(js.jwks.Keys, jwk)
+	}
+
+	return &js, nil
+}
+
+// PrivateKey implements the auth.KeyLookup interface. A JSON Web Key Set
+// only provides public keys, so this always fails.
+func (js *JWKSStore) PrivateKey(kid string) (string, error) {
+	return "", errors.New("private keys are not available from a jwks")
+}
+
+// PublicKey searches the key set for a given kid and returns the public key.
+func (js *JWKSStore) PublicKey(kid string) (string, error) {
+	pub, found := js.store[kid]
+	if !found {
+		return "", errors.New("kid lookup failed")
+	}
+
+	return encodePublicKey(pub)
+}
+
+// JWKS returns the set of keys this store is using.
+func (js *JWKSStore) JWKS() (JWKS, error) {
+	return js.jwks, nil
+}
+
+// =============================================================================
+
+func toJWK(kid string, pub crypto.PublicKey) (JWK, error) {
+	jwk := JWK{
+		KeyID: kid,
+		Use:   "sig",
+	}
+
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Algorithm = jwt.SigningMethodRS256.Name
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+
+	case *ecdsa.PublicKey:
+		jwk.KeyType = "EC"
+		jwk.Algorithm = jwt.SigningMethodES256.Name
+		jwk.Curve = "P-256"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32)))
+
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Algorithm = jwt.SigningMethodEdDSA.Alg()
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", pub)
+	}
+
+	return jwk, nil
+}
+
+func fromJWK(jwk JWK) (crypto.PublicKey, error) {
+	decode := func(name string, v string) ([]byte, error) {
+		b, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", name, err)
+		}
+		if len(b) == 0 {
+			return nil, fmt.Errorf("missing %s", name)
+		}
+		return b, nil
+	}
+
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decode("modulus", jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decode("exponent", jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(e) > 4 {
+			return nil, errors.New("invalid rsa key parameters")
+		}
+
+		pub := rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+
+		return &pub, nil
+
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+		}
+
+		x, err := decode("x", jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decode("y", jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		pub := ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("invalid ec key parameters")
+		}
+
+		return &pub, nil
+
+	case "OKP":
+		if jwk.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+		}
+
+		x, err := decode("x", jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key parameters")
+		}
+
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", jwk.KeyType)
+}
+
+func encodePublicKey(pub any) (string, error) {
+	asn1Bytes, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshaling public key: %w", err)
+	}
+
+	block := pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: asn1Bytes,
+	}
+
+	var b bytes.Buffer
+	if err := pem.Encode(&b, &block); err != nil {
+		return "", fmt.Errorf("encoding to public file: %w", err)
+	}
+
+	return b.String(), nil
+}
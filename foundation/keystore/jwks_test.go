@@ -0,0 +1,122 @@
+package keystore_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/keystore"
+)
+
+func Test_JWKS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"rsa.pem":     {Data: rsaPEM(t)},
+		"ec.pem":      {Data: ecPEM(t)},
+		"ed25519.pem": {Data: edPEM(t)},
+	}
+
+	ks, err := keystore.NewFS(fsys)
+	if err != nil {
+		t.Fatalf("Should be able to construct the keystore : %s", err)
+	}
+
+	jwks, err := ks.JWKS()
+	if err != nil {
+		t.Fatalf("Should be able to get the jwks : %s", err)
+	}
+
+	tt := []struct {
+		kid string
+		kty string
+		alg string
+	}{
+		{"ec", "EC", "ES256"},
+		{"ed25519", "OKP", "EdDSA"},
+		{"rsa", "RSA", "RS256"},
+	}
+
+	if len(jwks.Keys) != len(tt) {
+		t.Fatalf("Should get a key for every key in the store : got %d", len(jwks.Keys))
+	}
+
+	for i, tst := range tt {
+		jwk := jwks.Keys[i]
+		if jwk.KeyID != tst.kid || jwk.KeyType != tst.kty || jwk.Algorithm != tst.alg {
+			t.Logf("got: %s %s %s", jwk.KeyID, jwk.KeyType, jwk.Algorithm)
+			t.Logf("exp: %s %s %s", tst.kid, tst.kty, tst.alg)
+			t.Errorf("Should get the keys sorted by kid with their type and algorithm")
+		}
+	}
+
+	// -------------------------------------------------------------------------
+
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(jwks); err != nil {
+		t.Fatalf("Should be able to encode the jwks : %s", err)
+	}
+
+	js, err := keystore.NewJWKS(&b)
+	if err != nil {
+		t.Fatalf("Should be able to construct a store from the jwks : %s", err)
+	}
+
+	for _, tst := range tt {
+		got, err := js.PublicKey(tst.kid)
+		if err != nil {
+			t.Fatalf("Should be able to get the public key of %s from the jwks : %s", tst.kid, err)
+		}
+
+		if exp := publicKey(t, ks, tst.kid); got != exp {
+			t.Logf("got: %s", got)
+			t.Logf("exp: %s", exp)
+			t.Errorf("Should get the same public key of %s back from the jwks", tst.kid)
+		}
+	}
+
+	if _, err := js.PrivateKey("rsa"); err == nil {
+		t.Errorf("Should NOT get a private key from a jwks")
+	}
+}
+
+func Test_JWKSUnsupported(t *testing.T) {
+	t.Run("ignored", func(t *testing.T) {
+		doc := `{"keys":[
+			{"kty":"oct","kid":"secret","k":"c2VjcmV0"},
+			{"kty":"RSA","kid":"encryption","use":"enc","n":"AQAB","e":"AQAB"}
+		]}`
+
+		js, err := keystore.NewJWKS(strings.NewReader(doc))
+		if err != nil {
+			t.Fatalf("Should ignore keys of other types and uses : %s", err)
+		}
+
+		for _, kid := range []string{"secret", "encryption"} {
+			if _, err := js.PublicKey(kid); err == nil {
+				t.Errorf("Should NOT find the ignored key %s", kid)
+			}
+		}
+	})
+
+	tt := []struct {
+		name string
+		jwk  string
+	}{
+		{"ec-curve", `{"kty":"EC","kid":"k","crv":"P-384","x":"AQ","y":"AQ"}`},
+		{"okp-curve", `{"kty":"OKP","kid":"k","crv":"X25519","x":"AQ"}`},
+		{"ec-point", `{"kty":"EC","kid":"k","crv":"P-256","x":"AQ","y":"AQ"}`},
+		{"okp-size", `{"kty":"OKP","kid":"k","crv":"Ed25519","x":"AQ"}`},
+		{"no-kid", `{"kty":"RSA","n":"AQAB","e":"AQAB"}`},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			doc := `{"keys":[` + tst.jwk + `]}`
+
+			if _, err := keystore.NewJWKS(strings.NewReader(doc)); err == nil {
+				t.Errorf("Should NOT be able to construct a store from the key %s", tst.jwk)
+			}
+		})
+	}
+}
@@ -4,7 +4,11 @@
 package keystore
 
 import (
-	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -13,21 +17,28 @@ import (
 	"io"
 	"io/fs"
 	"path"
+	"sort"
 	"strings"
-
-	"github.com/golang-jwt/jwt/v4"
+	"sync"
+	"time"
 )
 
-// PrivateKey represents key information.
+// PrivateKey represents key information. The key can be an RSA, an ECDSA
+// P-256 or an Ed25519 private key.
 type PrivateKey struct {
-	PK  *rsa.PrivateKey
+	PK  crypto.Signer
 	PEM []byte
 }
 
 // KeyStore represents an in memory store implementation of the
 // KeyLookup interface for use with the auth package.
 type KeyStore struct {
+	mu    sync.RWMutex
 	store map[string]PrivateKey
+
+	// fsys is only set when the keys were read from a file system, so
+	// they can be read again when the files change.
+	fsys fs.FS
 }
 
 // New constructs an empty KeyStore ready for use.
@@ -49,7 +60,96 @@ func NewMap(store map[string]PrivateKey) *KeyStore {
 // Example: keystore.NewFS(os.DirFS("/zarf/keys/"))
 // Example: /zarf/keys/54bb2165-71e1-41a6-af3e-7da4a0e1e2c1.pem
 func NewFS(fsys fs.FS) (*KeyStore, error) {
-	ks := New()
+	store, err := loadFS(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := KeyStore{
+		store: store,
+		fsys:  fsys,
+	}
+
+	return &ks, nil
+}
+
+// Reload reads the keys from the file system again, adding, replacing and
+// removing keys to match the files. If any file can't be read or parsed the
+// current keys are kept. It returns the kids that were removed.
+func (ks *KeyStore) Reload() ([]string, error) {
+	if ks.fsys == nil {
+		return nil, errors.New("keystore was not constructed from a file system")
+	}
+
+	store, err := loadFS(ks.fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	var removed []string
+	for kid := range ks.store {
+		if _, exists := store[kid]; !exists {
+			removed = append(removed, kid)
+		}
+	}
+	sort.Strings(removed)
+
+	ks.store = store
+
+	return removed, nil
+}
+
+// Watch polls the file system for changes to the keys on the specified
+// interval until the context is cancelled. The function is called after
+// every reload with the kids that were removed, or with the error if the
+// reload failed and the current keys were kept.
+func (ks *KeyStore) Watch(ctx context.Context, interval time.Duration, fn func(removed []string, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			fn(ks.Reload())
+		}
+	}
+}
+
+// PrivateKey searches the key store for a given kid and returns the private key.
+func (ks *KeyStore) PrivateKey(kid string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	privateKey, found := ks.store[kid]
+	if !found {
+		return "", errors.New("kid lookup failed")
+	}
+
+	return string(privateKey.PEM), nil
+}
+
+// PublicKey searches the key store for a given kid and returns the public key.
+func (ks *KeyStore) PublicKey(kid string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	privateKey, found := ks.store[kid]
+	if !found {
+		return "", errors.New("kid lookup failed")
+	}
+
+	return encodePublicKey(privateKey.PK.Public())
+}
+
+// loadFS reads all the PEM files rooted inside of the file system.
+func loadFS(fsys fs.FS) (map[string]PrivateKey, error) {
+	store := make(map[string]PrivateKey)
 
 	fn := func(fileName string, dirEntry fs.DirEntry, err error) error {
 		if err != nil {
@@ -78,7 +178,7 @@ func NewFS(fsys fs.FS) (*KeyStore, error) {
 			return fmt.Errorf("reading auth private key: %w", err)
 		}
 
-		pk, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		pk, err := ParsePrivateKey(pem)
 		if err != nil {
 			return fmt.Errorf("parsing auth private key: %w", err)
 		}
@@ -88,7 +188,7 @@ func NewFS(fsys fs.FS) (*KeyStore, error) {
 			PEM: pem,
 		}
 
-		ks.store[strings.TrimSuffix(dirEntry.Name(), ".pem")] = key
+		store[strings.TrimSuffix(dirEntry.Name(), ".pem")] = key
 
 		return nil
 	}
@@ -97,40 +197,57 @@ func NewFS(fsys fs.FS) (*KeyStore, error) {
 		return nil, fmt.Errorf("walking directory: %w", err)
 	}
 
-	return ks, nil
+	return store, nil
 }
 
-// PrivateKey searches the key store for a given kid and returns the private key.
-func (ks *KeyStore) PrivateKey(kid string) (string, error) {
-	privateKey, found := ks.store[kid]
-	if !found {
-		return "", errors.New("kid lookup failed")
+// PublicPEM returns the PEM encoded public key of the PEM encoded private
+// key. It lets other KeyLookup implementations that only store private keys
+// provide the public key.
+func PublicPEM(privatePEM []byte) (string, error) {
+	pk, err := ParsePrivateKey(privatePEM)
+	if err != nil {
+		return "", fmt.Errorf("parsing private key: %w", err)
 	}
 
-	return string(privateKey.PEM), nil
+	return encodePublicKey(pk.Public())
 }
 
-// PublicKey searches the key store for a given kid and returns the public key.
-func (ks *KeyStore) PublicKey(kid string) (string, error) {
-	privateKey, found := ks.store[kid]
-	if !found {
-		return "", errors.New("kid lookup failed")
+// ParsePrivateKey parses a PEM encoded RSA, ECDSA P-256 or Ed25519 private
+// key in any of the formats openssl produces.
+func ParsePrivateKey(b []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("key must be pem encoded")
 	}
 
-	asn1Bytes, err := x509.MarshalPKIXPublicKey(&privateKey.PK.PublicKey)
-	if err != nil {
-		return "", fmt.Errorf("marshaling public key: %w", err)
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		// Some tools write PKCS1 keys with the generic PKCS8 header.
+		if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		}
 	}
-
-	block := pem.Block{
-		Type:  "PUBLIC KEY",
-		Bytes: asn1Bytes,
+	if err != nil {
+		return nil, err
 	}
 
-	var b bytes.Buffer
-	if err := pem.Encode(&b, &block); err != nil {
-		return "", fmt.Errorf("encoding to private file: %w", err)
+	switch pk := key.(type) {
+	case *rsa.PrivateKey:
+		return pk, nil
+	case *ecdsa.PrivateKey:
+		if pk.Curve != elliptic.P256() {
+			return nil, errors.New("only the P-256 curve is supported for ecdsa keys")
+		}
+		return pk, nil
+	case ed25519.PrivateKey:
+		return pk, nil
 	}
 
-	return b.String(), nil
+	return nil, fmt.Errorf("unsupported key type %T", key)
 }
@@ -0,0 +1,218 @@
+package keystore_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/keystore"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Reload(t *testing.T) {
+	first := rsaPEM(t)
+	second := ecPEM(t)
+
+	fsys := fstest.MapFS{
+		"first.pem":  {Data: first},
+		"second.pem": {Data: second},
+		"notes.txt":  {Data: []byte("not a key")},
+	}
+
+	ks, err := keystore.NewFS(fsys)
+	if err != nil {
+		t.Fatalf("Should be able to construct the keystore : %s", err)
+	}
+
+	secondPub := publicKey(t, ks, "second")
+
+	// -------------------------------------------------------------------------
+	// Add a key and replace one.
+
+	fsys["third.pem"] = &fstest.MapFile{Data: edPEM(t)}
+	fsys["first.pem"] = &fstest.MapFile{Data: rsaPEM(t)}
+
+	firstPub := publicKey(t, ks, "first")
+
+	removed, err := ks.Reload()
+	if err != nil {
+		t.Fatalf("Should be able to reload the keys : %s", err)
+	}
+
+	if len(removed) != 0 {
+		t.Errorf("Should NOT remove a key when none was removed : %v", removed)
+	}
+
+	if _, err := ks.PrivateKey("third"); err != nil {
+		t.Errorf("Should find the added key : %s", err)
+	}
+
+	if publicKey(t, ks, "first") == firstPub {
+		t.Errorf("Should use the replaced key")
+	}
+
+	if publicKey(t, ks, "second") != secondPub {
+		t.Errorf("Should keep the unchanged key")
+	}
+
+	// -------------------------------------------------------------------------
+	// Remove keys.
+
+	delete(fsys, "first.pem")
+	delete(fsys, "second.pem")
+
+	removed, err = ks.Reload()
+	if err != nil {
+		t.Fatalf("Should be able to reload the keys : %s", err)
+	}
+
+	exp := []string{"first", "second"}
+	if diff := cmp.Diff(removed, exp); diff != "" {
+		t.Errorf("Should return the kids of the removed keys. Diff:\n%s", diff)
+	}
+
+	if _, err := ks.PrivateKey("first"); err == nil {
+		t.Errorf("Should NOT find the removed key")
+	}
+
+	// -------------------------------------------------------------------------
+	// A file that can't be parsed keeps the previous keys.
+
+	fsys["bad.pem"] = &fstest.MapFile{Data: []byte("not a key")}
+	delete(fsys, "third.pem")
+
+	if _, err := ks.Reload(); err == nil {
+		t.Fatalf("Should NOT be able to reload a key that can't be parsed")
+	}
+
+	if _, err := ks.PrivateKey("third"); err != nil {
+		t.Errorf("Should keep the previous keys when the reload fails : %s", err)
+	}
+}
+
+func Test_ReloadNotFS(t *testing.T) {
+	ks := keystore.New()
+
+	if _, err := ks.Reload(); err == nil {
+		t.Errorf("Should NOT be able to reload a keystore without a file system")
+	}
+}
+
+func Test_Watch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"first.pem":  {Data: rsaPEM(t)},
+		"second.pem": {Data: edPEM(t)},
+	}
+
+	ks, err := keystore.NewFS(fsys)
+	if err != nil {
+		t.Fatalf("Should be able to construct the keystore : %s", err)
+	}
+
+	delete(fsys, "second.pem")
+
+	type result struct {
+		removed []string
+		err     error
+	}
+	ch := make(chan result, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ks.Watch(ctx, 10*time.Millisecond, func(removed []string, err error) {
+			select {
+			case ch <- result{removed, err}:
+			default:
+			}
+		})
+		close(done)
+	}()
+
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			t.Fatalf("Should be able to reload the keys : %s", res.err)
+		}
+
+		exp := []string{"second"}
+		if diff := cmp.Diff(res.removed, exp); diff != "" {
+			t.Errorf("Should call the function with the removed kids. Diff:\n%s", diff)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Should reload the keys on the interval")
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Should stop watching once the context is cancelled")
+	}
+}
+
+// =============================================================================
+
+func publicKey(t *testing.T, ks *keystore.KeyStore, kid string) string {
+	t.Helper()
+
+	pub, err := ks.PublicKey(kid)
+	if err != nil {
+		t.Fatalf("Should be able to get the public key of %s : %s", kid, err)
+	}
+
+	return pub
+}
+
+func rsaPEM(t *testing.T) []byte {
+	t.Helper()
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Should be able to generate an rsa key : %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(pk)})
+}
+
+func ecPEM(t *testing.T) []byte {
+	t.Helper()
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an ecdsa key : %s", err)
+	}
+
+	b, err := x509.MarshalECPrivateKey(pk)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the ecdsa key : %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b})
+}
+
+func edPEM(t *testing.T) []byte {
+	t.Helper()
+
+	_, pk, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Should be able to generate an ed25519 key : %s", err)
+	}
+
+	b, err := x509.MarshalPKCS8PrivateKey(pk)
+	if err != nil {
+		t.Fatalf("Should be able to marshal the ed25519 key : %s", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b})
+}
@@ -0,0 +1,157 @@
+// Package mailer provides support for sending email, either through an SMTP
+// server or by only logging the messages for development.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Message represents an email to send.
+type Message struct {
+	To      mail.Address
+	Subject string
+	Body    string
+}
+
+// =============================================================================
+
+// Config represents the settings needed to send email through SMTP. TLS is
+// negotiated with STARTTLS when the server supports it unless DisableTLS is
+// set, and the credentials are only used when a username is provided.
+type Config struct {
+	Host       string
+	Port       int
+	Username   string
+	Password   string
+	From       mail.Address
+	DisableTLS bool
+}
+
+// SMTP sends email through an SMTP server.
+type SMTP struct {
+	cfg Config
+}
+
+// NewSMTP constructs a mailer that sends email through an SMTP server.
+func NewSMTP(cfg Config) (*SMTP, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+
+	if cfg.From.Address == "" {
+		return nil, errors.New("from address is required")
+	}
+
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+
+	return &SMTP{cfg: cfg}, nil
+}
+
+// Send delivers the message to the SMTP server. The context bounds the whole
+// exchange with the server.
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("dialing smtp server: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("smtp client: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && !s.cfg.DisableTLS {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	if s.cfg.Username != "" {
+		auth := smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(s.cfg.From.Address); err != nil {
+		return fmt.Errorf("mail from: %w", err)
+	}
+
+	if err := c.Rcpt(msg.To.Address); err != nil {
+		return fmt.Errorf("rcpt to: %w", err)
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("data: %w", err)
+	}
+
+	if _, err := w.Write(s.format(msg)); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("closing message: %w", err)
+	}
+
+	return c.Quit()
+}
+
+// format builds the plain text message with its headers.
+func (s *SMTP) format(msg Message) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", s.cfg.From.String())
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", "", "\n", " ").Replace(msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return b.Bytes()
+}
+
+// =============================================================================
+
+// Log only logs the messages, it is meant for development where no SMTP
+// server is available.
+type Log struct {
+	log *zap.SugaredLogger
+}
+
+// NewLog constructs a mailer that only logs the messages.
+func NewLog(log *zap.SugaredLogger) *Log {
+	return &Log{log: log}
+}
+
+// Send logs the message.
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	l.log.Infow("mailer", "status", "message not sent, logging only", "to", msg.To.Address, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
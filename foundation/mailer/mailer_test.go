@@ -0,0 +1,160 @@
+package mailer_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/mailer"
+)
+
+// delivery is a message received by the fake smtp server.
+type delivery struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTP starts a fake smtp server that speaks just enough of the
+// protocol for net/smtp. Every delivered message is sent on the channel.
+func startSMTP(t *testing.T) (string, int, <-chan delivery) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan delivery, 1)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, ch)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	return host, p, ch
+}
+
+func serveSMTP(conn net.Conn, ch chan<- delivery) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost fake smtp")
+
+	var d delivery
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			d.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+			reply("250 OK")
+
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			d.to = append(d.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			reply("250 OK")
+
+		case cmd == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			d.data = b.String()
+			ch <- d
+			reply("250 OK")
+
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func Test_SMTP(t *testing.T) {
+	host, port, ch := startSMTP(t)
+
+	m, err := mailer.NewSMTP(mailer.Config{
+		Host: host,
+		Port: port,
+		From: mail.Address{Name: "Sales", Address: "noreply@example.com"},
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct the mailer : %s", err)
+	}
+
+	msg := mailer.Message{
+		To:      mail.Address{Address: "user@example.com"},
+		Subject: "Reset your password",
+		Body:    "Your token:\nabc123",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := m.Send(ctx, msg); err != nil {
+		t.Fatalf("Should be able to send the message : %s", err)
+	}
+
+	var d delivery
+	select {
+	case d = <-ch:
+	case <-time.After(time.Second):
+		t.Fatalf("Should have delivered the message")
+	}
+
+	if d.from != "noreply@example.com" {
+		t.Logf("got: %v", d.from)
+		t.Logf("exp: %v", "noreply@example.com")
+		t.Errorf("Should send the message from the configured address")
+	}
+
+	if len(d.to) != 1 || d.to[0] != "user@example.com" {
+		t.Logf("got: %v", d.to)
+		t.Logf("exp: %v", []string{"user@example.com"})
+		t.Errorf("Should send the message to the recipient")
+	}
+
+	if !strings.Contains(d.data, "Subject: Reset your password\r\n") {
+		t.Errorf("Should have the subject in the headers : %q", d.data)
+	}
+
+	if !strings.HasSuffix(d.data, "Your token:\r\nabc123\r\n") {
+		t.Errorf("Should have the body after the headers : %q", d.data)
+	}
+}
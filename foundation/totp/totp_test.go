@@ -0,0 +1,84 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/totp"
+)
+
+// secret is the SHA1 key from the test vectors in RFC 6238.
+var secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func Test_Code(t *testing.T) {
+	// The RFC vectors have eight digits, a six digit code is the last six.
+	tt := []struct {
+		unix int64
+		exp  string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tst := range tt {
+		got, err := totp.Code(secret, time.Unix(tst.unix, 0))
+		if err != nil {
+			t.Fatalf("Should be able to generate a code : %s", err)
+		}
+
+		if got != tst.exp {
+			t.Logf("got: %v", got)
+			t.Logf("exp: %v", tst.exp)
+			t.Errorf("Should get the code from the RFC for time %d", tst.unix)
+		}
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	s, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("Should be able to generate a secret : %s", err)
+	}
+
+	now := time.Now()
+
+	prev, err := totp.Code(s, now.Add(-totp.Period))
+	if err != nil {
+		t.Fatalf("Should be able to generate a code : %s", err)
+	}
+
+	step, ok := totp.Validate(s, prev, now, 1)
+	if !ok {
+		t.Fatalf("Should accept the code of the previous step")
+	}
+
+	if step != totp.Step(now)-1 {
+		t.Logf("got: %v", step)
+		t.Logf("exp: %v", totp.Step(now)-1)
+		t.Errorf("Should return the step the code matched")
+	}
+
+	old, err := totp.Code(s, now.Add(-3*totp.Period))
+	if err != nil {
+		t.Fatalf("Should be able to generate a code : %s", err)
+	}
+
+	if _, ok := totp.Validate(s, old, now, 1); ok {
+		t.Errorf("Should NOT accept a code outside the skew")
+	}
+
+	if _, ok := totp.Validate("not base32!", prev, now, 1); ok {
+		t.Errorf("Should NOT accept a code for an invalid secret")
+	}
+
+	uri := totp.URI("Sales", "user@example.com", s)
+	if !strings.HasPrefix(uri, "otpauth://totp/Sales:user@example.com?") || !strings.Contains(uri, "secret="+s) {
+		t.Errorf("Should build an otpauth uri : %s", uri)
+	}
+}
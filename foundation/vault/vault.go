@@ -0,0 +1,224 @@
+// Package vault implements the auth.KeyLookup interface using the key/value
+// version 2 secrets engine of HashiCorp Vault. Every private key is stored
+// as a secret named after its kid with the PEM under the "key" field.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/keystore"
+)
+
+// Set of error variables for the vault api.
+var (
+	ErrNotFound     = errors.New("kid lookup failed")
+	ErrUnauthorized = errors.New("vault denied access to the key")
+)
+
+// defaultCacheTTL is how long a key is cached when the config doesn't say
+// otherwise.
+const defaultCacheTTL = 5 * time.Minute
+
+// Config represents the mandatory settings needed to work with Vault.
+type Config struct {
+	Address   string
+	Token     string
+	MountPath string
+	CacheTTL  time.Duration
+	Client    *http.Client
+}
+
+// entry is a cached private key.
+type entry struct {
+	pem     string
+	expires time.Time
+}
+
+// Vault provides support to access Hashicorp's Vault product for keys.
+type Vault struct {
+	address   string
+	token     string
+	mountPath string
+	cacheTTL  time.Duration
+	client    *http.Client
+	mu        sync.RWMutex
+	store     map[string]entry
+}
+
+// New constructs a vault for use.
+func New(cfg Config) (*Vault, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("vault address is required")
+	}
+
+	if cfg.Token == "" {
+		return nil, errors.New("vault token is required")
+	}
+
+	if cfg.MountPath == "" {
+		return nil, errors.New("vault mount path is required")
+	}
+
+	v := Vault{
+		address:   strings.TrimSuffix(cfg.Address, "/"),
+		token:     cfg.Token,
+		mountPath: strings.Trim(cfg.MountPath, "/"),
+		cacheTTL:  cfg.CacheTTL,
+		client:    cfg.Client,
+		store:     make(map[string]entry),
+	}
+
+	if v.cacheTTL <= 0 {
+		v.cacheTTL = defaultCacheTTL
+	}
+
+	if v.client == nil {
+		v.client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &v, nil
+}
+
+// AddPrivateKey adds a new private key into vault as PEM encoded.
+func (v *Vault) AddPrivateKey(ctx context.Context, kid string, pem []byte) error {
+	body := struct {
+		Data map[string]string `json:"data"`
+	}{
+		Data: map[string]string{"key": string(pem)},
+	}
+
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(body); err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+
+	if err := v.do(ctx, http.MethodPost, kid, &b, nil); err != nil {
+		return fmt.Errorf("add: kid[%s]: %w", kid, err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.store, kid)
+
+	return nil
+}
+
+// PrivateKey searches the key store for a given kid and returns the private key.
+func (v *Vault) PrivateKey(kid string) (string, error) {
+	if pem, exists := v.cached(kid); exists {
+		return pem, nil
+	}
+
+	var resp struct {
+		Data struct {
+			Data map[string]string `json:"data"`
+		} `json:"data"`
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := v.do(ctx, http.MethodGet, kid, nil, &resp); err != nil {
+		v.evict(kid)
+		return "", fmt.Errorf("lookup: kid[%s]: %w", kid, err)
+	}
+
+	pem, exists := resp.Data.Data["key"]
+	if !exists || pem == "" {
+		return "", fmt.Errorf("lookup: kid[%s]: secret has no key field", kid)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.store[kid] = entry{pem: pem, expires: time.Now().Add(v.cacheTTL)}
+
+	return pem, nil
+}
+
+// PublicKey searches the key store for a given kid and returns the public key.
+func (v *Vault) PublicKey(kid string) (string, error) {
+	pem, err := v.PrivateKey(kid)
+	if err != nil {
+		return "", err
+	}
+
+	return keystore.PublicPEM([]byte(pem))
+}
+
+// =============================================================================
+
+func (v *Vault) cached(kid string) (string, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	e, exists := v.store[kid]
+	if !exists || time.Now().After(e.expires) {
+		return "", false
+	}
+
+	return e.pem, true
+}
+
+func (v *Vault) evict(kid string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.store, kid)
+}
+
+// do performs the request against the kv v2 data endpoint for the kid and
+// maps the vault status codes to our errors.
+func (v *Vault) do(ctx context.Context, method string, kid string, body io.Reader, result any) error {
+	u := fmt.Sprintf("%s/v1/%s/data/%s", v.address, v.mountPath, url.PathEscape(kid))
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+
+	req.Header.Set("X-Vault-Token", v.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	default:
+		var verr struct {
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&verr)
+
+		return fmt.Errorf("vault status %d: %s", resp.StatusCode, strings.Join(verr.Errors, ", "))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(result); err != nil {
+		return fmt.Errorf("decoding: %w", err)
+	}
+
+	return nil
+}
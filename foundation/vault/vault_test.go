@@ -0,0 +1,169 @@
+package vault_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/vault"
+)
+
+const (
+	token     = "mytoken"
+	mountPath = "secret"
+)
+
+// server is a stand-in for the vault kv v2 api.
+type server struct {
+	mu      sync.Mutex
+	secrets map[string]string
+	reads   int
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != token {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string][]string{"errors": {"permission denied"}})
+		return
+	}
+
+	kid, found := strings.CutPrefix(r.URL.Path, "/v1/"+mountPath+"/data/")
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		s.reads++
+
+		if kid == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string][]string{"errors": {"internal error"}})
+			return
+		}
+
+		key, exists := s.secrets[kid]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string][]string{"errors": {}})
+			return
+		}
+
+		resp := map[string]any{
+			"data": map[string]any{
+				"data": map[string]string{"key": key},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+
+	case http.MethodPost:
+		var req struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		s.secrets[kid] = req.Data["key"]
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]int{"version": 1}})
+	}
+}
+
+func Test_Vault(t *testing.T) {
+	srv := server{secrets: make(map[string]string)}
+	ts := httptest.NewServer(&srv)
+	defer ts.Close()
+
+	v, err := vault.New(vault.Config{
+		Address:   ts.URL,
+		Token:     token,
+		MountPath: mountPath,
+		CacheTTL:  time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct vault : %s", err)
+	}
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	ctx := context.Background()
+
+	// -------------------------------------------------------------------------
+
+	if err := v.AddPrivateKey(ctx, "ec", privatePEM); err != nil {
+		t.Fatalf("Should be able to add a private key : %s", err)
+	}
+
+	got, err := v.PrivateKey("ec")
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the private key : %s", err)
+	}
+
+	if got != string(privatePEM) {
+		t.Errorf("Should get back the same private key")
+	}
+
+	pub, err := v.PublicKey("ec")
+	if err != nil {
+		t.Fatalf("Should be able to retrieve the public key : %s", err)
+	}
+
+	if !strings.Contains(pub, "BEGIN PUBLIC KEY") {
+		t.Errorf("Should get back a PEM encoded public key : %s", pub)
+	}
+
+	if srv.reads != 1 {
+		t.Logf("got: %v", srv.reads)
+		t.Logf("exp: %v", 1)
+		t.Errorf("Should read the key from vault only once")
+	}
+
+	// -------------------------------------------------------------------------
+
+	if _, err := v.PrivateKey("missing"); !errors.Is(err, vault.ErrNotFound) {
+		t.Errorf("Should get ErrNotFound for a missing key : %v", err)
+	}
+
+	if _, err := v.PrivateKey("broken"); err == nil || !strings.Contains(err.Error(), "internal error") {
+		t.Errorf("Should get the vault error for a failed request : %v", err)
+	}
+
+	bad, err := vault.New(vault.Config{
+		Address:   ts.URL,
+		Token:     "badtoken",
+		MountPath: mountPath,
+	})
+	if err != nil {
+		t.Fatalf("Should be able to construct vault : %s", err)
+	}
+
+	if _, err := bad.PrivateKey("ec"); !errors.Is(err, vault.ErrUnauthorized) {
+		t.Errorf("Should get ErrUnauthorized for a bad token : %v", err)
+	}
+}
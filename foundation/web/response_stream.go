@@ -0,0 +1,294 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// StreamFormat represents how the values of a streamed response are encoded.
+type StreamFormat int
+
+// Set of formats a response can be streamed in.
+const (
+	// NDJSON writes every value as JSON on its own line.
+	NDJSON StreamFormat = iota + 1
+
+	// JSONArray writes the values as the elements of one JSON array.
+	JSONArray
+)
+
+// flushInterval is how long written values may sit in the buffers of the
+// response before they are pushed to the client.
+const flushInterval = 250 * time.Millisecond
+
+// =============================================================================
+
+// streamError is a type used when a streamed response fails after it has
+// started. The status and part of the body have been sent by then, so it is
+// too late to respond with the error.
+type streamError struct {
+	Err error
+}
+
+// Error is the implementation of the error interface.
+func (se *streamError) Error() string {
+	return fmt.Sprintf("streaming response: %s", se.Err)
+}
+
+// Unwrap returns the error that stopped the stream.
+func (se *streamError) Unwrap() error {
+	return se.Err
+}
+
+// IsStreamError checks to see if the error of a streamed response that
+// already started is contained in the specified error value.
+func IsStreamError(err error) bool {
+	var se *streamError
+	return errors.As(err, &se)
+}
+
+// =============================================================================
+
+// RespondIter converts the values next returns to JSON and sends them to the
+// client as they come, in the specified format, until next reports there are
+// no more. The status and headers are only sent with the first value, so an
+// error before then is returned as is and can still be responded with. An
+// error after that, including the context being cancelled, stops the stream
+// and is returned as a stream error. The server applies its WriteTimeout to
+// every response, which would cut the stream off, so the write deadline is
+// cleared for this one. Next is called on its own goroutine so the values
+// written are flushed while it works, RespondIter only returns once next
+// has.
+func RespondIter[T any](ctx context.Context, w http.ResponseWriter, format StreamFormat, statusCode int, next func(ctx context.Context) (T, bool, error)) error {
+	sw, err := newStreamWriter(ctx, w, format, statusCode)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	ch := make(chan T)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer close(ch)
+
+		for {
+			v, ok, err := next(ctx)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			if !ok {
+				return
+			}
+
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// The values next returns may be backed by resources the handler
+	// releases once this returns, so next has to be done with them first.
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	return drain(ctx, sw, ch, errs)
+}
+
+// RespondChan converts the values received from the channel to JSON and
+// sends them to the client as they come, in the specified format, until the
+// channel is closed. Values that are written are flushed while waiting on the
+// channel, so a slow producer doesn't hold them back. Errors are handled as
+// they are by RespondIter.
+func RespondChan[T any](ctx context.Context, w http.ResponseWriter, format StreamFormat, statusCode int, ch <-chan T) error {
+	sw, err := newStreamWriter(ctx, w, format, statusCode)
+	if err != nil {
+		return err
+	}
+
+	return drain(ctx, sw, ch, nil)
+}
+
+// drain writes the values received from the channel until it is closed,
+// flushing what was written every flushInterval. An error sent on errs
+// before the channel is closed fails the response.
+func drain[T any](ctx context.Context, sw *streamWriter, ch <-chan T, errs <-chan error) error {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		// A value that is ready is not written once the context is done.
+		if err := ctx.Err(); err != nil {
+			return sw.fail(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return sw.fail(ctx.Err())
+
+		case <-ticker.C:
+			if err := sw.flush(); err != nil {
+				return sw.fail(err)
+			}
+
+		case v, ok := <-ch:
+			if !ok {
+				select {
+				case err := <-errs:
+					return sw.fail(err)
+				default:
+					return sw.close()
+				}
+			}
+
+			if err := sw.write(v); err != nil {
+				return sw.fail(err)
+			}
+		}
+	}
+}
+
+// =============================================================================
+
+// streamWriter encodes the values of a streamed response.
+type streamWriter struct {
+	ctx        context.Context
+	lr         liveResponse
+	format     StreamFormat
+	statusCode int
+	started    bool
+	count      int
+	pending    bool
+}
+
+func newStreamWriter(ctx context.Context, w http.ResponseWriter, format StreamFormat, statusCode int) (*streamWriter, error) {
+	lr, err := newLiveResponse(w)
+	if err != nil {
+		return nil, err
+	}
+
+	sw := streamWriter{
+		ctx:        ctx,
+		lr:         lr,
+		format:     format,
+		statusCode: statusCode,
+	}
+
+	return &sw, nil
+}
+
+// start sends the status and headers of the response.
+func (sw *streamWriter) start() {
+	switch sw.format {
+	case NDJSON:
+		sw.lr.w.Header().Set("Content-Type", "application/x-ndjson")
+	default:
+		sw.lr.w.Header().Set("Content-Type", "application/json")
+	}
+
+	sw.lr.start(sw.ctx, sw.statusCode)
+
+	sw.started = true
+}
+
+// write encodes a value, it is flushed by the next call to flush.
+func (sw *streamWriter) write(v any) error {
+	// Marshal before anything is sent, so a value that can't be encoded
+	// fails the response while it can still be responded to.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if !sw.started {
+		sw.start()
+	}
+
+	switch {
+	case sw.format == JSONArray && sw.count == 0:
+		_, err = sw.lr.w.Write([]byte("["))
+	case sw.format == JSONArray:
+		_, err = sw.lr.w.Write([]byte(","))
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := sw.lr.w.Write(data); err != nil {
+		return err
+	}
+
+	if sw.format == NDJSON {
+		if _, err := sw.lr.w.Write([]byte("\n")); err != nil {
+			return err
+		}
+	}
+
+	sw.count++
+	sw.pending = true
+
+	return nil
+}
+
+// close ends the response once every value is written.
+func (sw *streamWriter) close() error {
+	if !sw.started {
+		sw.start()
+	}
+
+	if sw.format == JSONArray {
+		closing := "]"
+		if sw.count == 0 {
+			closing = "[]"
+		}
+
+		if _, err := sw.lr.w.Write([]byte(closing)); err != nil {
+			return sw.fail(err)
+		}
+		sw.pending = true
+	}
+
+	if err := sw.flush(); err != nil {
+		return sw.fail(err)
+	}
+
+	return nil
+}
+
+// flush pushes the values written so far to the client.
+func (sw *streamWriter) flush() error {
+	if !sw.pending {
+		return nil
+	}
+
+	if err := sw.lr.flush(); err != nil {
+		return err
+	}
+
+	sw.pending = false
+
+	return nil
+}
+
+// fail returns the error that stopped the response, marking it as a stream
+// error when the response had already started.
+func (sw *streamWriter) fail(err error) error {
+	if !sw.started || IsStreamError(err) {
+		return err
+	}
+
+	return &streamError{Err: err}
+}
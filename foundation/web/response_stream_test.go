@@ -0,0 +1,226 @@
+package web_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+)
+
+type item struct {
+	ID int `json:"id"`
+}
+
+func Test_RespondIter(t *testing.T) {
+	tt := []struct {
+		name   string
+		format web.StreamFormat
+		items  []item
+		ctype  string
+		body   string
+	}{
+		{"ndjson", web.NDJSON, []item{{1}, {2}}, "application/x-ndjson", "{\"id\":1}\n{\"id\":2}\n"},
+		{"array", web.JSONArray, []item{{1}, {2}}, "application/json", `[{"id":1},{"id":2}]`},
+		{"ndjson-empty", web.NDJSON, nil, "application/x-ndjson", ""},
+		{"array-empty", web.JSONArray, nil, "application/json", "[]"},
+	}
+
+	for _, tst := range tt {
+		t.Run(tst.name, func(t *testing.T) {
+			var v web.Values
+			ctx := web.SetValues(context.Background(), &v)
+
+			w := httptest.NewRecorder()
+
+			if err := web.RespondIter(ctx, w, tst.format, http.StatusCreated, iter(tst.items)); err != nil {
+				t.Fatalf("Should be able to stream the values : %s", err)
+			}
+
+			if w.Code != http.StatusCreated || v.StatusCode != http.StatusCreated {
+				t.Logf("got: %d %d", w.Code, v.StatusCode)
+				t.Logf("exp: %d", http.StatusCreated)
+				t.Errorf("Should send and record the status code")
+			}
+
+			if got := w.Header().Get("Content-Type"); got != tst.ctype {
+				t.Logf("got: %s", got)
+				t.Logf("exp: %s", tst.ctype)
+				t.Errorf("Should set the content type of the format")
+			}
+
+			if got := w.Body.String(); got != tst.body {
+				t.Logf("got: %q", got)
+				t.Logf("exp: %q", tst.body)
+				t.Errorf("Should frame the values in the format")
+			}
+		})
+	}
+}
+
+func Test_RespondIterErrors(t *testing.T) {
+	t.Run("before-start", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		// A channel can't be encoded to JSON.
+		next := func(ctx context.Context) (any, bool, error) {
+			return make(chan int), true, nil
+		}
+
+		err := web.RespondIter(context.Background(), w, web.JSONArray, http.StatusOK, next)
+		if err == nil {
+			t.Fatalf("Should NOT be able to stream a value that can't be encoded")
+		}
+
+		if web.IsStreamError(err) {
+			t.Errorf("Should NOT get a stream error before anything was sent : %s", err)
+		}
+
+		if err := web.Respond(context.Background(), w, map[string]string{"error": "failed"}, http.StatusInternalServerError); err != nil {
+			t.Fatalf("Should be able to respond with the error : %s", err)
+		}
+
+		if w.Code != http.StatusInternalServerError {
+			t.Logf("got: %d", w.Code)
+			t.Logf("exp: %d", http.StatusInternalServerError)
+			t.Errorf("Should respond with the status of the error")
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		w := httptest.NewRecorder()
+
+		var n int
+		next := func(ctx context.Context) (item, bool, error) {
+			n++
+			if n == 2 {
+				cancel()
+			}
+			return item{n}, true, nil
+		}
+
+		err := web.RespondIter(ctx, w, web.NDJSON, http.StatusOK, next)
+
+		if !web.IsStreamError(err) {
+			t.Fatalf("Should get a stream error once the stream started : %v", err)
+		}
+
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Should get back the error of the context : %s", err)
+		}
+
+		if w.Code != http.StatusOK {
+			t.Logf("got: %d", w.Code)
+			t.Logf("exp: %d", http.StatusOK)
+			t.Errorf("Should have sent the status of the stream")
+		}
+	})
+}
+
+func Test_RespondIterFlush(t *testing.T) {
+	w := flushWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+		flushed:          make(chan struct{}, 1),
+	}
+
+	release := make(chan struct{})
+
+	// The second call waits until the first value was pushed to the client.
+	var n int
+	next := func(ctx context.Context) (item, bool, error) {
+		n++
+		if n == 1 {
+			return item{1}, true, nil
+		}
+
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return item{}, false, nil
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- web.RespondIter(context.Background(), &w, web.NDJSON, http.StatusOK, next)
+	}()
+
+	select {
+	case <-w.flushed:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Should flush the values written while next is waiting")
+	}
+
+	close(release)
+
+	if err := <-errs; err != nil {
+		t.Fatalf("Should be able to stream the values : %s", err)
+	}
+
+	exp := "{\"id\":1}\n"
+	if got := w.Body.String(); got != exp {
+		t.Logf("got: %q", got)
+		t.Logf("exp: %q", exp)
+		t.Errorf("Should stream the values next returned")
+	}
+}
+
+func Test_RespondChan(t *testing.T) {
+	ch := make(chan item, 2)
+	ch <- item{1}
+	ch <- item{2}
+	close(ch)
+
+	w := httptest.NewRecorder()
+
+	if err := web.RespondChan(context.Background(), w, web.JSONArray, http.StatusOK, ch); err != nil {
+		t.Fatalf("Should be able to stream the values : %s", err)
+	}
+
+	exp := `[{"id":1},{"id":2}]`
+	if got := w.Body.String(); got != exp {
+		t.Logf("got: %q", got)
+		t.Logf("exp: %q", exp)
+		t.Errorf("Should stream the values received from the channel")
+	}
+
+	if !w.Flushed {
+		t.Errorf("Should flush the response once the channel is closed")
+	}
+}
+
+// =============================================================================
+
+func iter(items []item) func(ctx context.Context) (item, bool, error) {
+	return func(ctx context.Context) (item, bool, error) {
+		if len(items) == 0 {
+			return item{}, false, nil
+		}
+
+		v := items[0]
+		items = items[1:]
+
+		return v, true, nil
+	}
+}
+
+// flushWriter signals every time the response is flushed.
+type flushWriter struct {
+	*httptest.ResponseRecorder
+	flushed chan struct{}
+}
+
+func (w *flushWriter) Flush() {
+	w.ResponseRecorder.Flush()
+
+	select {
+	case w.flushed <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Stream represents a long-lived response that sends Server-Sent Events to
+// the client.
+type Stream struct {
+	lr liveResponse
+}
+
+// NewStream starts a Server-Sent Events response. The handler ends the stream
+// by returning, usually once the context is done.
+func NewStream(ctx context.Context, w http.ResponseWriter) (*Stream, error) {
+	lr, err := newLiveResponse(w)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	lr.start(ctx, http.StatusOK)
+
+	if err := lr.flush(); err != nil {
+		return nil, err
+	}
+
+	return &Stream{lr: lr}, nil
+}
+
+// Send converts a Go value to JSON and sends it to the client as an event of
+// the specified name. The id lets a client that reconnects tell the server
+// the last event it got, it is left out when empty.
+func (s *Stream) Send(event string, id string, data any) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", id)
+	}
+	fmt.Fprintf(&b, "event: %s\n", event)
+	fmt.Fprintf(&b, "data: %s\n\n", jsonData)
+
+	if _, err := s.lr.w.Write([]byte(b.String())); err != nil {
+		return err
+	}
+
+	return s.lr.flush()
+}
+
+// Comment sends a comment the client ignores. Sending one now and then keeps
+// proxies from closing a stream that has been quiet for a while.
+func (s *Stream) Comment(text string) error {
+	if _, err := fmt.Fprintf(s.lr.w, ": %s\n\n", text); err != nil {
+		return err
+	}
+
+	return s.lr.flush()
+}
+
+// =============================================================================
+
+// liveResponse is a response that stays open and pushes what is written to
+// the client as it goes, instead of once the handler returns.
+type liveResponse struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newLiveResponse prepares a live response. The server applies its
+// WriteTimeout to every response, which would cut a live one off, so the
+// write deadline is cleared for it.
+func newLiveResponse(w http.ResponseWriter) (liveResponse, error) {
+	rc := http.NewResponseController(w)
+
+	// A writer that doesn't support deadlines has no deadline to clear.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return liveResponse{}, fmt.Errorf("clearing write deadline: %w", err)
+	}
+
+	lr := liveResponse{
+		w:  w,
+		rc: rc,
+	}
+
+	return lr, nil
+}
+
+// start sends the status with the headers that were set.
+func (lr liveResponse) start(ctx context.Context, statusCode int) {
+	SetStatusCode(ctx, statusCode)
+
+	// Proxies like nginx buffer responses unless they are told not to.
+	lr.w.Header().Set("X-Accel-Buffering", "no")
+
+	lr.w.WriteHeader(statusCode)
+}
+
+// flush pushes what was written so far to the client.
+func (lr liveResponse) flush() error {
+	// A writer that can't flush sends what was written when the handler
+	// returns.
+	if err := lr.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("flushing stream: %w", err)
+	}
+
+	return nil
+}
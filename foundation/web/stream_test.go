@@ -0,0 +1,74 @@
+package web_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MinaMamdouh2/Web-Services-With-Kubernetes/foundation/web"
+)
+
+func Test_Stream(t *testing.T) {
+	var v web.Values
+	ctx := web.SetValues(context.Background(), &v)
+
+	w := httptest.NewRecorder()
+
+	stream, err := web.NewStream(ctx, w)
+	if err != nil {
+		t.Fatalf("Should be able to start the stream : %s", err)
+	}
+
+	if w.Code != http.StatusOK || v.StatusCode != http.StatusOK {
+		t.Logf("got: %d %d", w.Code, v.StatusCode)
+		t.Logf("exp: %d", http.StatusOK)
+		t.Errorf("Should send and record the status code")
+	}
+
+	headers := map[string]string{
+		"Content-Type":      "text/event-stream",
+		"Cache-Control":     "no-cache",
+		"X-Accel-Buffering": "no",
+	}
+
+	for k, exp := range headers {
+		if got := w.Header().Get(k); got != exp {
+			t.Logf("got: %s", got)
+			t.Logf("exp: %s", exp)
+			t.Errorf("Should set the %s header of the stream", k)
+		}
+	}
+
+	if !w.Flushed {
+		t.Errorf("Should flush the headers once the stream starts")
+	}
+
+	// -------------------------------------------------------------------------
+
+	if err := stream.Send("product.created", "7", item{1}); err != nil {
+		t.Fatalf("Should be able to send an event : %s", err)
+	}
+
+	if err := stream.Send("product.deleted", "", item{2}); err != nil {
+		t.Fatalf("Should be able to send an event without an id : %s", err)
+	}
+
+	if err := stream.Comment("heartbeat"); err != nil {
+		t.Fatalf("Should be able to send a comment : %s", err)
+	}
+
+	exp := "id: 7\nevent: product.created\ndata: {\"id\":1}\n\n" +
+		"event: product.deleted\ndata: {\"id\":2}\n\n" +
+		": heartbeat\n\n"
+
+	if got := w.Body.String(); got != exp {
+		t.Logf("got: %q", got)
+		t.Logf("exp: %q", exp)
+		t.Errorf("Should frame the events and comments")
+	}
+
+	if err := stream.Send("product.created", "", make(chan int)); err == nil {
+		t.Errorf("Should NOT be able to send a value that can't be encoded")
+	}
+}